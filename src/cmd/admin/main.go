@@ -0,0 +1,203 @@
+// Command admin provides operator tooling for a running lift simulation API.
+//
+// Usage:
+//
+//	admin keygen [-keys folder]
+//	admin token [-keys folder] [-kid id] [-iss issuer] [-sub subject] [-roles list] [-ttl duration]
+//	admin snapshot export [-url base] [-token jwt] [-o file]
+//	admin snapshot import [-url base] [-token jwt] -f file
+//
+// keygen writes a new signing key to the keys folder and prints its kid. token
+// signs with the key named by -kid, or else $AUTH_ACTIVE_KID, or else the only
+// private key in the folder. The snapshot commands send the token in -token, or else in $LIFT_TOKEN.
+// Snapshots need the admin role.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+)
+
+const defaultBaseURL = "http://localhost:8080"
+
+// The defaults match the API's own auth configuration
+const (
+	defaultKeysFolder = "zarf/keys/"
+	defaultIssuer     = "lift simulation project"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "admin:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) > 0 && args[0] == "keygen" {
+		return keygen(args[1:])
+	}
+	if len(args) > 0 && args[0] == "token" {
+		return token(args[1:])
+	}
+	if len(args) < 2 || args[0] != "snapshot" {
+		return errors.New("usage: admin keygen [flags] | admin token [flags] | admin snapshot <export|import> [flags]")
+	}
+
+	switch args[1] {
+	case "export":
+		return snapshotExport(args[2:])
+	case "import":
+		return snapshotImport(args[2:])
+	default:
+		return fmt.Errorf("unknown snapshot command: %s", args[1])
+	}
+}
+
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	keys := fs.String("keys", defaultKeysFolder, "folder to write the <kid>.pem signing key to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	kid, err := auth.GenerateKey(*keys)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(kid)
+	return nil
+}
+
+func token(args []string) error {
+	fs := flag.NewFlagSet("token", flag.ContinueOnError)
+	keys := fs.String("keys", defaultKeysFolder, "folder of <kid>.pem signing keys")
+	kid := fs.String("kid", os.Getenv("AUTH_ACTIVE_KID"), "ID of the key to sign with (default the only key in the folder)")
+	issuer := fs.String("iss", defaultIssuer, "issuer of the token")
+	subject := fs.String("sub", "admin", "subject the token is issued to")
+	roleList := fs.String("roles", string(auth.RoleAdmin), "comma separated roles: viewer, operator, maintenance, admin")
+	ttl := fs.Duration("ttl", 8*time.Hour, "how long the token is valid")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var roles []auth.Role
+	for _, name := range strings.Split(*roleList, ",") {
+		role, err := auth.ParseRole(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		roles = append(roles, role)
+	}
+
+	a, err := auth.New(auth.Config{KeysFolder: *keys, ActiveKID: *kid, Issuer: *issuer})
+	if err != nil {
+		return fmt.Errorf("failed to load keys: %w", err)
+	}
+
+	signed, err := a.GenerateToken(*subject, roles, *ttl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(signed)
+	return nil
+}
+
+func snapshotExport(args []string) error {
+	fs := flag.NewFlagSet("snapshot export", flag.ContinueOnError)
+	baseURL := fs.String("url", defaultBaseURL, "base URL of the lift simulation API")
+	jwt := fs.String("token", os.Getenv("LIFT_TOKEN"), "bearer token with the admin role")
+	out := fs.String("o", "", "file to write the snapshot to (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	body, err := call(http.MethodGet, *baseURL+"/api/v1/system/snapshot", *jwt, nil)
+	if err != nil {
+		return fmt.Errorf("failed to export snapshot: %w", err)
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err != nil {
+		return fmt.Errorf("failed to format snapshot: %w", err)
+	}
+	pretty.WriteByte('\n')
+
+	if *out == "" {
+		_, err = os.Stdout.Write(pretty.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*out, pretty.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+func snapshotImport(args []string) error {
+	fs := flag.NewFlagSet("snapshot import", flag.ContinueOnError)
+	baseURL := fs.String("url", defaultBaseURL, "base URL of the lift simulation API")
+	jwt := fs.String("token", os.Getenv("LIFT_TOKEN"), "bearer token with the admin role")
+	in := fs.String("f", "", "snapshot file to import")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return errors.New("snapshot import: -f is required")
+	}
+
+	doc, err := os.ReadFile(*in)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	body, err := call(http.MethodPost, *baseURL+"/api/v1/system/snapshot", *jwt, doc)
+	if err != nil {
+		return fmt.Errorf("failed to import snapshot: %w", err)
+	}
+
+	fmt.Println(string(body))
+	return nil
+}
+
+// call performs a JSON request and returns the response body, turning non-2xx
+// responses into errors that carry the API's error document
+func call(method, url, token string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
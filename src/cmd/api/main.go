@@ -18,14 +18,25 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/recover"
 
 	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
 	"github.com/Avyukth/lift-simulation/internal/application/services"
 	"github.com/Avyukth/lift-simulation/internal/config"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/eventbus"
 	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/handlers"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/middleware"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/problem"
 	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/routes"
 	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/metrics"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/persistence/postgres"
+	redisstore "github.com/Avyukth/lift-simulation/internal/infrastructure/persistence/redis"
 	"github.com/Avyukth/lift-simulation/internal/infrastructure/persistence/sqlite"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/ratelimit"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/webhook"
 	"github.com/Avyukth/lift-simulation/pkg/logger"
 	"github.com/Avyukth/lift-simulation/pkg/web"
+	goredis "github.com/redis/go-redis/v9"
 )
 
 var build = "develop"
@@ -74,43 +85,232 @@ func run(ctx context.Context, log *logger.Logger, fiberLog *logger.FiberLogger)
 
 	expvar.NewString("build").Set(build)
 
+	// -------------------------------------------------------------------------
+	// Initialize authentication support
+
+	log.Info(ctx, "startup", "status", "initializing authentication support", "keys_folder", cfg.Auth.KeysFolder, "active_kid", cfg.Auth.ActiveKID)
+
+	authn, err := auth.New(auth.Config{
+		KeysFolder: cfg.Auth.KeysFolder,
+		ActiveKID:  cfg.Auth.ActiveKID,
+		Issuer:     cfg.Auth.Issuer,
+	})
+	if err != nil {
+		return fmt.Errorf("constructing auth: %w", err)
+	}
+
+	// -------------------------------------------------------------------------
+	// Metrics Support
+
+	log.Info(ctx, "startup", "status", "initializing metrics support")
+
+	appMetrics := metrics.New(log)
+
 	// -------------------------------------------------------------------------
 	// Database Support
 
-	log.Info(ctx, "startup", "status", "initializing database support", "path", cfg.DB.Path)
+	log.Info(ctx, "startup", "status", "initializing database support", "driver", cfg.DB.Driver)
 
-	repo, err := sqlite.NewRepository(cfg.DB.Path, log)
+	durable, err := openRepository(cfg, log)
 	if err != nil {
 		return fmt.Errorf("connecting to db: %w", err)
 	}
-	defer repo.Close()
+	defer durable.Close()
+
+	var repo ports.Repository = durable
+
+	// -------------------------------------------------------------------------
+	// Redis Support
+
+	rateLimitBackend, err := ratelimit.ParseBackend(cfg.RateLimit.Backend)
+	if err != nil {
+		return fmt.Errorf("configuring rate limits: %w", err)
+	}
+
+	var redisClient *goredis.Client
+	if cfg.Redis.Enabled || rateLimitBackend == ratelimit.BackendRedis {
+		log.Info(ctx, "startup", "status", "initializing redis support", "host", cfg.Redis.Host, "port", cfg.Redis.Port)
+
+		client, err := redisstore.NewClient(ctx, redisstore.Config{
+			Host:     cfg.Redis.Host,
+			Port:     cfg.Redis.Port,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+			PoolSize: cfg.Redis.PoolSize,
+		})
+		if err != nil {
+			return fmt.Errorf("connecting to redis: %w", err)
+		}
+		defer client.Close()
+
+		redisClient = client
+	}
+
+	// -------------------------------------------------------------------------
+	// Live State Support
+
+	if cfg.Redis.Enabled {
+		log.Info(ctx, "startup", "status", "initializing redis live state")
+
+		live := redisstore.NewRepository(redisClient, durable, log)
+		if err := live.Warm(ctx); err != nil {
+			return fmt.Errorf("warming redis live state: %w", err)
+		}
+		repo = live
+	}
+
+	repo = appMetrics.InstrumentRepository(repo)
+	appMetrics.RegisterState(repo)
+
+	// -------------------------------------------------------------------------
+	// Rate Limiting Support
+
+	log.Info(ctx, "startup", "status", "initializing rate limits", "backend", rateLimitBackend)
+
+	var limitStore ratelimit.Store
+	switch rateLimitBackend {
+	case ratelimit.BackendMemory:
+		limitStore = ratelimit.NewMemoryStore()
+	case ratelimit.BackendRedis:
+		limitStore = ratelimit.NewRedisStore(redisClient)
+	}
+
+	clientLimiter, err := ratelimit.NewLimiter("client", ratelimit.Policy{
+		Rate:  cfg.RateLimit.ClientRate,
+		Burst: cfg.RateLimit.ClientBurst,
+	}, limitStore, log)
+	if err != nil {
+		return fmt.Errorf("configuring rate limits: %w", err)
+	}
+
+	floorLimiter, err := ratelimit.NewLimiter("floor", ratelimit.Policy{
+		Rate:  cfg.RateLimit.FloorRate,
+		Burst: cfg.RateLimit.FloorBurst,
+	}, limitStore, log)
+	if err != nil {
+		return fmt.Errorf("configuring rate limits: %w", err)
+	}
+
+	expvar.Publish("rate_limits", expvar.Func(func() any {
+		return map[string]ratelimit.Stats{
+			"client": clientLimiter.Stats(),
+			"floor":  floorLimiter.Stats(),
+		}
+	}))
+	appMetrics.RegisterRateLimiter("client", clientLimiter)
+	appMetrics.RegisterRateLimiter("floor", floorLimiter)
 
 	// -------------------------------------------------------------------------
 	// Event Bus Support
-	log.Info(ctx, "startup", "status", "initializing event bus")
+	log.Info(ctx, "startup", "status", "initializing event bus", "workers", cfg.EventBus.Workers, "queue_capacity", cfg.EventBus.QueueCapacity, "overflow", cfg.EventBus.Overflow)
+
+	bus, err := eventbus.ProvideEventBus(cfg, appMetrics, log)
+	if err != nil {
+		return err
+	}
+	appMetrics.RegisterEventBus(bus)
 
 	// -------------------------------------------------------------------------
 	// Initialize WebSocket hub
 
-	hub := ws.NewWebSocketHub(log)
+	hub := ws.NewWebSocketHub(ws.HubConfig{
+		SendQueue:    cfg.WebSocket.SendQueue,
+		PingInterval: cfg.WebSocket.PingInterval,
+		PongWait:     cfg.WebSocket.PongWait,
+		WriteWait:    cfg.WebSocket.WriteWait,
+		ReplayBuffer: cfg.WebSocket.ReplayBuffer,
+	}, repo, log)
 	go hub.Run(ctx)
 
+	expvar.Publish("websocket_hub", expvar.Func(func() any { return hub.Stats() }))
+	appMetrics.RegisterWebSocketHub(hub)
+
 	// -------------------------------------------------------------------------
 	// Initialize Services
 
-	eventBus := events.NewInMemoryEventBus()
-	liftService := services.NewLiftService(repo, eventBus, hub, log)
-	floorService := services.NewFloorService(repo, eventBus, log, hub)
-	systemService := services.NewSystemService(repo, log)
-
-	liftHandler := handlers.NewLiftHandler(liftService)
+	eventBus := events.NewRecordingEventBus(bus, repo, log)
+	callService := services.NewCallService(repo, eventBus, appMetrics, log)
+	liftService := services.NewLiftService(repo, callService, eventBus, hub, appMetrics, services.DispatchConfig{
+		Interval: cfg.Dispatch.Interval,
+		MaxWait:  cfg.Dispatch.MaxWait,
+	}, log)
+	floorService := services.NewFloorService(repo, callService, eventBus, log, hub)
+	moveService := services.NewMoveService(liftService, repo, eventBus, log)
+	systemService := services.NewSystemService(repo, liftService, moveService, eventBus, log)
+	eventService := services.NewEventService(repo, log)
+	webhookService := services.NewWebhookService(repo, webhook.NewHTTPSender(cfg.Webhook.Timeout), eventBus, services.WebhookConfig{
+		MaxAttempts:    cfg.Webhook.MaxAttempts,
+		InitialBackoff: cfg.Webhook.InitialBackoff,
+		MaxBackoff:     cfg.Webhook.MaxBackoff,
+		PollInterval:   cfg.Webhook.PollInterval,
+		Workers:        cfg.Webhook.Workers,
+	}, log)
+	apiKeyService := services.NewAPIKeyService(repo, log)
+	auditService := services.NewAuditService(repo, log)
+	idempotencyService := services.NewIdempotencyService(repo, services.IdempotencyConfig{
+		TTL: cfg.Idempotency.TTL,
+	}, log)
+
+	liftHandler := handlers.NewLiftHandler(liftService, moveService)
+	moveHandler := handlers.NewMoveHandler(moveService)
+	callHandler := handlers.NewCallHandler(callService)
 	floorHandler := handlers.NewFloorHandler(floorService)
+	commandHandler := handlers.NewCommandHandler(liftService, moveService, floorService)
 
 	systemHandler := handlers.NewSystemHandler(systemService)
+	eventHandler := handlers.NewEventHandler(eventService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	streamHandler := handlers.NewStreamHandler(eventService, eventBus.Feed(), cfg.Stream.KeepAlive, log)
+
+	// -------------------------------------------------------------------------
+	// Start Webhook Delivery
+
+	webhookCtx, stopWebhooks := context.WithCancel(ctx)
+	webhooksDone := make(chan struct{})
+	go func() {
+		defer close(webhooksDone)
+		webhookService.Run(webhookCtx)
+	}()
+	defer func() {
+		stopWebhooks()
+		<-webhooksDone
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Hall Call Dispatcher
+
+	dispatchCtx, stopDispatch := context.WithCancel(ctx)
+	dispatchDone := make(chan struct{})
+	go func() {
+		defer close(dispatchDone)
+		liftService.RunDispatcher(dispatchCtx)
+	}()
+	defer func() {
+		stopDispatch()
+		<-dispatchDone
+	}()
+
+	// -------------------------------------------------------------------------
+	// Start Idempotency Record Purging
+
+	idempotencyCtx, stopIdempotency := context.WithCancel(ctx)
+	idempotencyDone := make(chan struct{})
+	go func() {
+		defer close(idempotencyDone)
+		idempotencyService.Run(idempotencyCtx)
+	}()
+	defer func() {
+		stopIdempotency()
+		<-idempotencyDone
+	}()
 
 	// -------------------------------------------------------------------------
 	// Start Debug Service
 
+	http.Handle("/metrics", appMetrics.Handler())
+
 	go func() {
 		log.Info(ctx, "startup", "status", "debug router started", "host", cfg.Web.DebugHostPort)
 
@@ -131,17 +331,33 @@ func run(ctx context.Context, log *logger.Logger, fiberLog *logger.FiberLogger)
 		ErrorHandler: customErrorHandler(fiberLog),
 	})
 
+	app.Use(middleware.Metrics(appMetrics))
 	app.Use(recover.New())
+	app.Use(middleware.Trace())
 	app.Use(cors.New())
 
 	routeConfig := config.RouteConfig{
-		App:           app,
-		LiftHandler:   liftHandler,
-		FloorHandler:  floorHandler,
-		SystemHandler: systemHandler,
-		Hub:           hub,
-		FiberLog:      fiberLog,
-		Repo:          repo,
+		App:            app,
+		LiftHandler:    liftHandler,
+		FloorHandler:   floorHandler,
+		MoveHandler:    moveHandler,
+		CallHandler:    callHandler,
+		SystemHandler:  systemHandler,
+		EventHandler:   eventHandler,
+		WebhookHandler: webhookHandler,
+		APIKeyHandler:  apiKeyHandler,
+		AuditHandler:   auditHandler,
+		StreamHandler:  streamHandler,
+		CommandHandler: commandHandler,
+		Hub:            hub,
+		FiberLog:       fiberLog,
+		Repo:           repo,
+		Auth:           authn,
+		APIKeys:        apiKeyService,
+		Auditor:        auditService,
+		Idempotency:    idempotencyService,
+		ClientLimiter:  clientLimiter,
+		FloorLimiter:   floorLimiter,
 	}
 
 	routes.SetupRoutes(routeConfig)
@@ -208,29 +424,56 @@ func run(ctx context.Context, log *logger.Logger, fiberLog *logger.FiberLogger)
 		ctx, cancel := context.WithTimeout(ctx, cfg.Web.ShutdownTimeout)
 		defer cancel()
 
+		// Event streams never finish on their own; end them so the server
+		// can close their connections
+		eventBus.Feed().Close()
+
 		if err := app.ShutdownWithContext(ctx); err != nil {
 			app.Shutdown()
 			return fmt.Errorf("could not stop server gracefully: %w", err)
 		}
+
+		// Let lifts already sent to hall calls arrive, while they can still
+		// publish their events
+		if err := liftService.StopServing(ctx); err != nil {
+			return fmt.Errorf("could not wait for lifts serving hall calls: %w", err)
+		}
+
+		// Let in-flight lift requests finish once no new calls can arrive
+		if err := bus.Close(ctx); err != nil {
+			return fmt.Errorf("could not drain event bus: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// repository is the persistence adapter selected by configuration.
+type repository interface {
+	ports.Repository
+	Close() error
+}
+
+func openRepository(cfg config.Config, log *logger.Logger) (repository, error) {
+	switch cfg.DB.Driver {
+	case "postgres":
+		return postgres.NewRepository(postgres.Config{
+			URL:          cfg.DB.URL,
+			MaxOpenConns: cfg.DB.MaxOpenConns,
+			MaxIdleConns: cfg.DB.MaxIdleConns,
+			MaxLifetime:  cfg.DB.MaxLifetime,
+		}, log)
+	case "sqlite", "":
+		return sqlite.NewRepository(cfg.DB.Path, log)
+	default:
+		return nil, fmt.Errorf("unsupported db driver: %s", cfg.DB.Driver)
+	}
+}
+
 func customErrorHandler(fiberLog *logger.FiberLogger) func(*fiber.Ctx, error) error {
 	return func(c *fiber.Ctx, err error) error {
 		fiberLog.ErrorFiber(c, "request error", "error", err, "path", c.Path())
 
-		code := fiber.StatusInternalServerError
-		msg := "Internal Server Error"
-
-		if e, ok := err.(*fiber.Error); ok {
-			code = e.Code
-			msg = e.Message
-		}
-
-		return c.Status(code).JSON(fiber.Map{
-			"error": msg,
-		})
+		return problem.Write(c, err)
 	}
 }
@@ -1,15 +1,23 @@
 package events
 
 import (
+	"context"
+
 	"github.com/Avyukth/lift-simulation/internal/domain"
 )
 
+// EventHandler handles an event delivered by an EventBus. The context carries
+// the trace ID and envelope of the event, so anything the handler publishes
+// is linked back to it.
 type EventHandler interface {
-	Handle(event domain.Event)
+	Handle(ctx context.Context, env domain.Envelope)
 }
 
 type EventBus interface {
-	Publish(event domain.Event)
+	// Publish wraps the event in a new envelope and delivers it
+	Publish(ctx context.Context, event domain.Event)
+	// PublishEnvelope delivers an event that is already enveloped
+	PublishEnvelope(ctx context.Context, env domain.Envelope)
 	Subscribe(eventType domain.EventType, handler EventHandler)
 	Unsubscribe(eventType domain.EventType, handler EventHandler)
 }
@@ -0,0 +1,52 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/web"
+	"github.com/google/uuid"
+)
+
+type ctxKey int
+
+const envelopeKey ctxKey = iota
+
+// NewEnvelope wraps an event published from ctx. When ctx belongs to the
+// handling of another event, the new event inherits its system, trace and
+// correlation IDs and records it as the cause; otherwise the event starts a
+// new correlation under the trace ID of the request.
+func NewEnvelope(ctx context.Context, event domain.Event) domain.Envelope {
+	env := domain.Envelope{
+		ID:         uuid.New().String(),
+		OccurredAt: time.Now().UTC(),
+		Event:      event,
+	}
+
+	if parent, ok := EnvelopeFromContext(ctx); ok {
+		env.SystemID = parent.SystemID
+		env.CausationID = parent.ID
+		env.CorrelationID = parent.CorrelationID
+		env.TraceID = parent.TraceID
+		return env
+	}
+
+	env.CorrelationID = env.ID
+	env.TraceID = web.GetTraceID(ctx)
+	return env
+}
+
+// EnvelopeFromContext returns the envelope of the event being handled
+func EnvelopeFromContext(ctx context.Context) (domain.Envelope, bool) {
+	env, ok := ctx.Value(envelopeKey).(domain.Envelope)
+	return env, ok
+}
+
+// HandlerContext builds the context an event is handled with. It is detached
+// from the publisher's context, which may be a finished request, and carries
+// only the envelope and its trace ID.
+func HandlerContext(env domain.Envelope) context.Context {
+	ctx := web.WithTraceID(context.Background(), env.TraceID)
+	return context.WithValue(ctx, envelopeKey, env)
+}
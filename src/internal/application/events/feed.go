@@ -0,0 +1,72 @@
+package events
+
+import "sync"
+
+// Feed tells followers of the event store when new events have been
+// recorded. It carries no events itself: followers read the store from the
+// last sequence they have seen, so they always observe events in sequence
+// order and without gaps, whichever goroutine recorded them. This holds as
+// long as the store makes events visible in sequence order, as
+// ports.EventStore requires.
+type Feed struct {
+	mu     sync.Mutex
+	subs   map[chan struct{}]struct{}
+	closed bool
+}
+
+// NewFeed creates an empty feed
+func NewFeed() *Feed {
+	return &Feed{subs: make(map[chan struct{}]struct{})}
+}
+
+// Subscribe returns a channel that receives a value whenever events have
+// been recorded since it was last drained, and a function that ends the
+// subscription. The channel is closed when the feed is closed.
+func (f *Feed) Subscribe() (<-chan struct{}, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if f.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	f.subs[ch] = struct{}{}
+
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subs[ch]; ok {
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Notify wakes every subscriber without blocking
+func (f *Feed) Notify() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close ends every subscription so followers can finish before shutdown
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+	f.closed = true
+	for ch := range f.subs {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"context"
 	"sync"
 
 	"github.com/Avyukth/lift-simulation/internal/domain"
@@ -17,13 +18,17 @@ func NewInMemoryEventBus() *InMemoryEventBus {
 	}
 }
 
-func (b *InMemoryEventBus) Publish(event domain.Event) {
+func (b *InMemoryEventBus) Publish(ctx context.Context, event domain.Event) {
+	b.PublishEnvelope(ctx, NewEnvelope(ctx, event))
+}
+
+func (b *InMemoryEventBus) PublishEnvelope(ctx context.Context, env domain.Envelope) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	if handlers, exists := b.handlers[event.Type()]; exists {
+	if handlers, exists := b.handlers[env.Event.Type()]; exists {
 		for _, handler := range handlers {
-			go handler.Handle(event)
+			go handler.Handle(HandlerContext(env), env)
 		}
 	}
 }
@@ -0,0 +1,320 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+)
+
+// OverflowPolicy decides what Publish does when a partition queue is full
+type OverflowPolicy int
+
+const (
+	// Block waits for room in the queue for up to PublishTimeout, then drops
+	// the event. The timeout keeps a handler that publishes into its own full
+	// queue from deadlocking its worker.
+	Block OverflowPolicy = iota
+	// Drop discards the event immediately.
+	Drop
+)
+
+// ParseOverflowPolicy parses "block" or "drop"
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch s {
+	case "block", "":
+		return Block, nil
+	case "drop":
+		return Drop, nil
+	default:
+		return Block, fmt.Errorf("unknown overflow policy: %s", s)
+	}
+}
+
+// ErrBusClosed is returned by Close when the bus has already been closed
+var ErrBusClosed = errors.New("event bus closed")
+
+const defaultPublishTimeout = 5 * time.Second
+
+// HandlerObserver is told how long each event handler took
+type HandlerObserver interface {
+	ObserveHandler(eventType domain.EventType, d time.Duration)
+}
+
+// PartitionedBusConfig configures a PartitionedEventBus
+type PartitionedBusConfig struct {
+	Workers        int
+	QueueCapacity  int
+	Overflow       OverflowPolicy
+	PublishTimeout time.Duration
+	// Observer, when set, is told how long every handler took
+	Observer HandlerObserver
+}
+
+// BusStats is a point-in-time view of the bus counters
+type BusStats struct {
+	Published  uint64 `json:"published"`
+	Delivered  uint64 `json:"delivered"`
+	Dropped    uint64 `json:"dropped"`
+	Panics     uint64 `json:"panics"`
+	QueueDepth int    `json:"queue_depth"`
+}
+
+// PartitionedEventBus delivers events on a fixed pool of workers. Every event
+// is routed by its partition (the lift or floor it concerns) to one worker,
+// so events for the same lift or floor are handled one at a time and in
+// publish order, while different lifts and floors proceed in parallel.
+// Queues are bounded; a full queue either blocks the publisher or drops the
+// event, depending on the overflow policy.
+type PartitionedEventBus struct {
+	cfg    PartitionedBusConfig
+	log    *logger.Logger
+	queues []chan domain.Envelope
+	wg     sync.WaitGroup
+
+	hmu      sync.RWMutex
+	handlers map[domain.EventType][]EventHandler
+
+	// mu is held for reading by publishers so Close cannot close a queue
+	// while an event is being sent to it
+	mu     sync.RWMutex
+	closed bool
+
+	published atomic.Uint64
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	panics    atomic.Uint64
+}
+
+// NewPartitionedEventBus creates the bus and starts its workers
+func NewPartitionedEventBus(cfg PartitionedBusConfig, log *logger.Logger) *PartitionedEventBus {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueCapacity < 1 {
+		cfg.QueueCapacity = 1
+	}
+	if cfg.PublishTimeout <= 0 {
+		cfg.PublishTimeout = defaultPublishTimeout
+	}
+
+	b := &PartitionedEventBus{
+		cfg:      cfg,
+		log:      log,
+		queues:   make([]chan domain.Envelope, cfg.Workers),
+		handlers: make(map[domain.EventType][]EventHandler),
+	}
+
+	for i := range b.queues {
+		b.queues[i] = make(chan domain.Envelope, cfg.QueueCapacity)
+		b.wg.Add(1)
+		go b.work(b.queues[i])
+	}
+
+	return b
+}
+
+// Publish wraps the event in an envelope and queues it
+func (b *PartitionedEventBus) Publish(ctx context.Context, event domain.Event) {
+	b.PublishEnvelope(ctx, NewEnvelope(ctx, event))
+}
+
+// PublishEnvelope queues the event on the worker that owns its partition
+func (b *PartitionedEventBus) PublishEnvelope(ctx context.Context, env domain.Envelope) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		b.drop(ctx, env, "bus closed")
+		return
+	}
+
+	queue := b.queues[b.worker(env.Event)]
+
+	select {
+	case queue <- env:
+		b.published.Add(1)
+		return
+	default:
+	}
+
+	if b.cfg.Overflow == Drop {
+		b.drop(ctx, env, "queue full")
+		return
+	}
+
+	timer := time.NewTimer(b.cfg.PublishTimeout)
+	defer timer.Stop()
+
+	select {
+	case queue <- env:
+		b.published.Add(1)
+	case <-timer.C:
+		b.drop(ctx, env, "publish timed out")
+	}
+}
+
+func (b *PartitionedEventBus) Subscribe(eventType domain.EventType, handler EventHandler) {
+	b.hmu.Lock()
+	defer b.hmu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+func (b *PartitionedEventBus) Unsubscribe(eventType domain.EventType, handler EventHandler) {
+	b.hmu.Lock()
+	defer b.hmu.Unlock()
+
+	handlers := b.handlers[eventType]
+	for i, h := range handlers {
+		if h == handler {
+			b.handlers[eventType] = append(handlers[:i:i], handlers[i+1:]...)
+			break
+		}
+	}
+}
+
+// Close stops accepting events and waits until every queued event has been
+// handled or ctx is done
+func (b *PartitionedEventBus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBusClosed
+	}
+	b.closed = true
+	for _, queue := range b.queues {
+		close(queue)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("draining event bus: %w", ctx.Err())
+	}
+}
+
+// Stats returns the current bus counters
+func (b *PartitionedEventBus) Stats() BusStats {
+	depth := 0
+	for _, queue := range b.queues {
+		depth += len(queue)
+	}
+
+	return BusStats{
+		Published:  b.published.Load(),
+		Delivered:  b.delivered.Load(),
+		Dropped:    b.dropped.Load(),
+		Panics:     b.panics.Load(),
+		QueueDepth: depth,
+	}
+}
+
+func (b *PartitionedEventBus) work(queue <-chan domain.Envelope) {
+	defer b.wg.Done()
+
+	for env := range queue {
+		b.hmu.RLock()
+		handlers := b.handlers[env.Event.Type()]
+		b.hmu.RUnlock()
+
+		ctx := HandlerContext(env)
+		for _, handler := range handlers {
+			b.dispatch(ctx, handler, env)
+		}
+	}
+}
+
+// dispatch runs one handler, isolating the worker from a panic in it
+func (b *PartitionedEventBus) dispatch(ctx context.Context, handler EventHandler, env domain.Envelope) {
+	start := time.Now()
+	defer func() {
+		if b.cfg.Observer != nil {
+			b.cfg.Observer.ObserveHandler(env.Event.Type(), time.Since(start))
+		}
+		if r := recover(); r != nil {
+			b.panics.Add(1)
+			b.log.Error(ctx, "Event handler panicked",
+				"event_type", env.Event.Type().String(),
+				"event_id", env.ID,
+				"panic", r,
+				"stack", string(debug.Stack()))
+		}
+	}()
+
+	handler.Handle(ctx, env)
+	b.delivered.Add(1)
+}
+
+func (b *PartitionedEventBus) drop(ctx context.Context, env domain.Envelope, reason string) {
+	b.dropped.Add(1)
+	b.log.Warn(ctx, "Dropped event", "event_type", env.Event.Type().String(), "event_id", env.ID, "reason", reason)
+}
+
+func (b *PartitionedEventBus) worker(event domain.Event) int {
+	h := fnv.New32a()
+	h.Write([]byte(partitionKey(event)))
+	return int(h.Sum32() % uint32(len(b.queues)))
+}
+
+// partitionKey names the lift or floor an event concerns. Lift events are
+// keyed by lift so a lift's movement is always seen in order; hall call
+// events are keyed by floor.
+func partitionKey(event domain.Event) string {
+	switch e := event.(type) {
+	case domain.LiftArrivedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftAssignedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftDepartedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftPassedFloorEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftDoorsOpenedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftDoorsClosedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftStatusChangedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftResetEvent:
+		return "lift:" + e.LiftID
+	case domain.MoveFinishedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftAddedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftUpdatedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftDecommissioningEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftRemovedEvent:
+		return "lift:" + e.LiftID
+	case domain.LiftRequestedEvent:
+		return "floor:" + strconv.Itoa(e.FloorNumber)
+	case domain.HallCallClosedEvent:
+		return "floor:" + strconv.Itoa(e.FloorNumber)
+	case domain.HallCallEscalatedEvent:
+		return "floor:" + strconv.Itoa(e.FloorNumber)
+	case domain.FloorAtCapacityEvent:
+		return "floor:" + strconv.Itoa(e.FloorNumber)
+	case domain.HallCallClearedEvent:
+		return "floor:" + strconv.Itoa(e.FloorNumber)
+	default:
+		return event.Type().String()
+	}
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+)
+
+// RecordStore is the persistence needed by RecordingEventBus
+type RecordStore interface {
+	ports.EventStore
+	GetSystem(ctx context.Context) (*domain.System, error)
+}
+
+// systemRefresh is how long the system ID stamped on events is trusted
+// before it is read again. The system events published by this instance
+// update it at once; the refresh picks up a system configured or reset
+// through another instance sharing the store.
+const systemRefresh = 30 * time.Second
+
+// RecordingEventBus appends every published event to the event store before
+// handing it to the wrapped bus, so the store is a complete, ordered audit
+// trail of what the building did.
+type RecordingEventBus struct {
+	EventBus
+	store RecordStore
+	feed  *Feed
+	log   *logger.Logger
+
+	mu       sync.Mutex
+	systemID string
+	readAt   time.Time
+}
+
+// NewRecordingEventBus wraps bus so that published events are persisted
+func NewRecordingEventBus(bus EventBus, store RecordStore, log *logger.Logger) *RecordingEventBus {
+	return &RecordingEventBus{
+		EventBus: bus,
+		store:    store,
+		feed:     NewFeed(),
+		log:      log,
+	}
+}
+
+// Feed is notified every time an event has been recorded
+func (b *RecordingEventBus) Feed() *Feed {
+	return b.feed
+}
+
+// Publish wraps the event in an envelope, records it and then dispatches it
+// to the subscribers
+func (b *RecordingEventBus) Publish(ctx context.Context, event domain.Event) {
+	b.PublishEnvelope(ctx, NewEnvelope(ctx, event))
+}
+
+// PublishEnvelope records the event and then dispatches it to the subscribers
+func (b *RecordingEventBus) PublishEnvelope(ctx context.Context, env domain.Envelope) {
+	if env.SystemID == "" {
+		env.SystemID = b.currentSystem(ctx, env.Event)
+	}
+
+	if _, err := b.store.AppendEvent(ctx, env); err != nil {
+		b.log.Error(ctx, "Failed to record event", "event_type", env.Event.Type().String(), "event_id", env.ID, "error", err)
+	} else {
+		b.feed.Notify()
+	}
+
+	b.EventBus.PublishEnvelope(ctx, env)
+}
+
+// currentSystem returns the ID of the system an event belongs to. The ID is
+// cached so that publishing does not read the store for every event; it is
+// taken from the events announcing a new or reset system and otherwise read
+// again once systemRefresh has passed.
+func (b *RecordingEventBus) currentSystem(ctx context.Context, event domain.Event) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch e := event.(type) {
+	case domain.SystemConfiguredEvent:
+		b.systemID, b.readAt = e.SystemID, time.Now()
+		return e.SystemID
+	case domain.SystemResetEvent:
+		b.systemID, b.readAt = "", time.Time{}
+		return e.SystemID
+	}
+
+	if b.systemID == "" || time.Since(b.readAt) > systemRefresh {
+		b.systemID = ""
+		if system, err := b.store.GetSystem(ctx); err == nil {
+			b.systemID, b.readAt = system.ID, time.Now()
+		}
+	}
+	return b.systemID
+}
@@ -0,0 +1,11 @@
+package ports
+
+import "github.com/Avyukth/lift-simulation/internal/domain"
+
+// DomainMetrics counts what the lifts have done, for monitoring
+type DomainMetrics interface {
+	// TripRecorded counts a completed trip
+	TripRecorded(trip *domain.Trip)
+	// HallCallClosed counts a call that was served, cancelled or rejected
+	HallCallClosed(call *domain.HallCall)
+}
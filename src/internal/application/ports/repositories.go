@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/Avyukth/lift-simulation/internal/domain"
 )
@@ -41,20 +42,137 @@ type LiftFloorManager interface {
 	UnassignLiftFromFloor(ctx context.Context, liftID string, floorID string) error
 	UnassignBulk(ctx context.Context) error
 	AssignLiftToFloor(ctx context.Context, liftID string, floorID string, floorNumber int) error
+	// AssignLiftToFloorWithLimit atomically assigns the lift unless the floor
+	// already has maxLifts assignments, in which case it returns
+	// domain.ErrFloorAtCapacity.
+	AssignLiftToFloorWithLimit(ctx context.Context, liftID string, floorID string, floorNumber int, maxLifts int) error
 	GetAssignedLiftsForFloor(ctx context.Context, floorID string) ([]*domain.Lift, error)
 }
 
+// EventStore defines the interface for the append-only domain event log
+type EventStore interface {
+	// AppendEvent records an event and returns its sequence number. Events
+	// must become visible in sequence order, so that a reader never sees an
+	// event before one with a lower sequence.
+	AppendEvent(ctx context.Context, env domain.Envelope) (int64, error)
+	// ListEvents returns up to limit events with a sequence number greater
+	// than since, in sequence order.
+	ListEvents(ctx context.Context, since int64, limit int) ([]*domain.StoredEvent, error)
+	// LatestEventSequence returns the sequence number of the newest event,
+	// or zero when the store is empty.
+	LatestEventSequence(ctx context.Context) (int64, error)
+}
+
+// TripRepository defines the interface for trip history persistence
+type TripRepository interface {
+	SaveTrip(ctx context.Context, trip *domain.Trip) error
+	// ListTrips returns one page of trips matching the filter, newest first,
+	// together with the total number of matching trips.
+	ListTrips(ctx context.Context, filter domain.TripFilter) ([]*domain.Trip, int, error)
+}
+
+// HallCallRepository defines the interface for hall call persistence
+type HallCallRepository interface {
+	SaveHallCall(ctx context.Context, call *domain.HallCall) error
+	GetHallCall(ctx context.Context, id string) (*domain.HallCall, error)
+	UpdateHallCall(ctx context.Context, call *domain.HallCall) error
+	// ListHallCalls returns one page of calls matching the filter, newest
+	// first, together with the total number of matching calls.
+	ListHallCalls(ctx context.Context, filter domain.HallCallFilter) ([]*domain.HallCall, int, error)
+}
+
+// WebhookRepository defines the interface for webhook subscriptions and their
+// delivery log
+type WebhookRepository interface {
+	SaveWebhook(ctx context.Context, hook *domain.Webhook) error
+	GetWebhook(ctx context.Context, id string) (*domain.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*domain.Webhook, error)
+	UpdateWebhook(ctx context.Context, hook *domain.Webhook) error
+	// DeleteWebhook removes the webhook together with its deliveries and
+	// their attempts.
+	DeleteWebhook(ctx context.Context, id string) error
+
+	SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	// ListWebhookDeliveries returns the deliveries matching the filter,
+	// newest first.
+	ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error)
+	// ListDueWebhookDeliveries returns up to limit pending deliveries whose
+	// next attempt is due at now, oldest first.
+	ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error)
+
+	AppendWebhookAttempt(ctx context.Context, attempt *domain.WebhookAttempt) error
+	ListWebhookAttempts(ctx context.Context, deliveryID string) ([]*domain.WebhookAttempt, error)
+}
+
+// APIKeyRepository defines the interface for API key persistence
+type APIKeyRepository interface {
+	SaveAPIKey(ctx context.Context, key *domain.APIKey) error
+	GetAPIKey(ctx context.Context, id string) (*domain.APIKey, error)
+	// GetAPIKeyByHash looks a key up by the hash of the key presented by a
+	// client.
+	GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error)
+	// ListAPIKeys returns every key, revoked and expired ones included,
+	// oldest first.
+	ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error)
+	UpdateAPIKey(ctx context.Context, key *domain.APIKey) error
+}
+
+// AuditRepository defines the interface for the append-only audit log
+type AuditRepository interface {
+	// AppendAudit records an entry and returns its sequence number.
+	AppendAudit(ctx context.Context, entry *domain.AuditEntry) (int64, error)
+	// ListAudit returns one page of the entries matching the filter, newest
+	// first, together with the total number of matching entries.
+	ListAudit(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, int, error)
+}
+
+// IdempotencyRepository defines the interface for the responses remembered
+// for idempotency keys
+type IdempotencyRepository interface {
+	// ReserveIdempotencyKey stores rec unless its principal already holds
+	// the key. A record that has expired, or whose request was still being
+	// handled at abandonedBefore, is replaced. It reports whether rec was
+	// stored.
+	ReserveIdempotencyKey(ctx context.Context, rec *domain.IdempotencyRecord, abandonedBefore time.Time) (bool, error)
+	GetIdempotencyRecord(ctx context.Context, principal, key string) (*domain.IdempotencyRecord, error)
+	// CompleteIdempotencyRecord stores the response to a reserved key.
+	CompleteIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error
+	// ReleaseIdempotencyKey removes a reservation whose request has no
+	// response worth keeping, so the key can be used again.
+	ReleaseIdempotencyKey(ctx context.Context, principal, key string) error
+	// DeleteExpiredIdempotencyRecords removes the records expired at now and
+	// returns how many were removed.
+	DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error)
+}
+
+// WebhookSender posts a signed delivery to a webhook endpoint. It returns the
+// HTTP status code of the response, if one was received, and an error unless
+// the endpoint accepted the delivery.
+type WebhookSender interface {
+	Send(ctx context.Context, hook *domain.Webhook, delivery *domain.WebhookDelivery) (int, error)
+}
+
 // Repository combines all repository interfaces
 type Repository interface {
 	LiftRepository
 	FloorRepository
 	SystemRepository
 	LiftFloorManager
+	EventStore
+	TripRepository
+	HallCallRepository
+	WebhookRepository
+	APIKeyRepository
+	AuditRepository
+	IdempotencyRepository
 }
 
 type LiftOperations interface {
 	LiftRepository
 	LiftFloorManager
+	TripRepository
 	GetSystem(ctx context.Context) (*domain.System, error)
 }
 
@@ -64,6 +182,11 @@ type FloorOperations interface {
 	GetSystem(ctx context.Context) (*domain.System, error)
 }
 
+type CallOperations interface {
+	HallCallRepository
+	FloorRepository
+}
+
 // Transaction defines the interface for database transactions
 type Transaction interface {
 	Commit() error
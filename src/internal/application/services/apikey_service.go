@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// apiKeyPrefix starts every API key, so leaked keys are easy to spot
+	apiKeyPrefix = "lsk_"
+	// apiKeyShownLength is how much of a key is kept as its prefix
+	apiKeyShownLength = len(apiKeyPrefix) + 8
+	// apiKeyUsageInterval bounds how often the last use of a key is written,
+	// so a busy client does not cost a write per request
+	apiKeyUsageInterval = time.Minute
+	maxAPIKeyNameLength = 100
+)
+
+// APIKeyInput describes an API key to create
+type APIKeyInput struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresAt is when the key stops working. A key without one works until
+	// it is revoked.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyService issues, checks and revokes the API keys machine clients use
+// instead of JWTs. Keys are stored as SHA-256 hashes; they are random enough
+// that a slow password hash would add nothing.
+type APIKeyService struct {
+	repo ports.APIKeyRepository
+	log  *logger.Logger
+}
+
+// NewAPIKeyService creates a new instance of APIKeyService
+func NewAPIKeyService(repo ports.APIKeyRepository, log *logger.Logger) *APIKeyService {
+	return &APIKeyService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// CreateAPIKey issues a new key on behalf of createdBy. The key itself is
+// returned only here.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, input APIKeyInput, createdBy string) (*domain.APIKey, string, error) {
+	scopes, err := validateAPIKeyInput(input, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := apiKeyPrefix + hex.EncodeToString(b)
+
+	var expiresAt *time.Time
+	if input.ExpiresAt != nil {
+		t := input.ExpiresAt.UTC()
+		expiresAt = &t
+	}
+
+	key := &domain.APIKey{
+		ID:        uuid.New().String(),
+		Name:      input.Name,
+		Prefix:    secret[:apiKeyShownLength],
+		Hash:      hashAPIKey(secret),
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+
+	if err := s.repo.SaveAPIKey(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	s.log.Info(ctx, "Created API key", "key_id", key.ID, "name", key.Name, "scopes", key.Scopes, "created_by", createdBy)
+	return key, secret, nil
+}
+
+// GetAPIKey returns an API key by ID
+func (s *APIKeyService) GetAPIKey(ctx context.Context, id string) (*domain.APIKey, error) {
+	key, err := s.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns every API key, including revoked and expired ones
+func (s *APIKeyService) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	keys, err := s.repo.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops a key from working. Revoked keys are kept so their use
+// can still be traced.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id string) (*domain.APIKey, error) {
+	key, err := s.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return nil, fmt.Errorf("API key %s: %w", id, domain.ErrAPIKeyRevoked)
+	}
+
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	if err := s.repo.UpdateAPIKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.log.Info(ctx, "Revoked API key", "key_id", key.ID, "name", key.Name)
+	return key, nil
+}
+
+// Authenticate returns the key matching a key presented by a client, and
+// records that it was used. Unknown, revoked and expired keys are reported as
+// domain.ErrUnauthenticated.
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*domain.APIKey, error) {
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(secret))
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: unknown API key", domain.ErrUnauthenticated)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if !key.Usable(now) {
+		return nil, fmt.Errorf("%w: API key %s is revoked or expired", domain.ErrUnauthenticated, key.Prefix)
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyUsageInterval {
+		key.LastUsedAt = &now
+		if err := s.repo.UpdateAPIKey(ctx, key); err != nil {
+			s.log.Warn(ctx, "Failed to record API key use", "key_id", key.ID, "error", err)
+		}
+	}
+
+	return key, nil
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func validateAPIKeyInput(input APIKeyInput, now time.Time) ([]domain.APIKeyScope, error) {
+	var problems []string
+
+	switch {
+	case input.Name == "":
+		problems = append(problems, "name is required")
+	case len(input.Name) > maxAPIKeyNameLength:
+		problems = append(problems, fmt.Sprintf("name must be at most %d characters", maxAPIKeyNameLength))
+	}
+
+	if len(input.Scopes) == 0 {
+		problems = append(problems, "scopes must name at least one of read-only, calls-only, admin")
+	}
+	scopes := make([]domain.APIKeyScope, 0, len(input.Scopes))
+	for i, name := range input.Scopes {
+		scope, err := domain.ParseAPIKeyScope(name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("scopes[%d]: %v", i, err))
+			continue
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(now) {
+		problems = append(problems, "expires_at must be in the future")
+	}
+
+	if len(problems) > 0 {
+		return nil, &domain.ValidationError{Problems: problems}
+	}
+	return scopes, nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+)
+
+// maxAuditPayload is how much of a request body is kept in the audit log
+const maxAuditPayload = 1024
+
+// auditRedactedFields are the parts of body field names whose values never
+// reach the audit log
+var auditRedactedFields = []string{"secret", "key", "token", "password"}
+
+// auditIgnoredFields are fields left out of diffs. A lift's last move time
+// is not stored, so it reads as the time the lift was loaded.
+var auditIgnoredFields = []string{"last_move_time"}
+
+// AuditService keeps the append-only record of who changed what through the
+// API. The transport captures the state of a call's subject before and after
+// the call; the service stores the difference.
+type AuditService struct {
+	repo ports.Repository
+	log  *logger.Logger
+}
+
+// NewAuditService creates a new instance of AuditService
+func NewAuditService(repo ports.Repository, log *logger.Logger) *AuditService {
+	return &AuditService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// State returns the current state of a subject as JSON. A subject that does
+// not exist, such as a lift before it is added, has a null state.
+func (s *AuditService) State(ctx context.Context, subject domain.AuditSubject) (json.RawMessage, error) {
+	var state any
+	var err error
+
+	switch subject.Kind {
+	case domain.AuditSubjectLift:
+		state, err = s.repo.GetLift(ctx, subject.ID)
+	case domain.AuditSubjectLifts:
+		state, err = s.repo.ListLifts(ctx)
+	case domain.AuditSubjectFloor:
+		var floorNum int
+		floorNum, err = strconv.Atoi(subject.ID)
+		if err != nil {
+			return json.RawMessage("null"), nil
+		}
+		state, err = s.repo.GetFloorByNumber(ctx, floorNum)
+	case domain.AuditSubjectSystem:
+		state, err = s.systemState(ctx)
+	case domain.AuditSubjectCall:
+		state, err = s.repo.GetHallCall(ctx, subject.ID)
+	case domain.AuditSubjectWebhook:
+		state, err = s.repo.GetWebhook(ctx, subject.ID)
+	case domain.AuditSubjectAPIKey:
+		state, err = s.repo.GetAPIKey(ctx, subject.ID)
+	default:
+		return nil, fmt.Errorf("unknown audit subject kind: %s", subject.Kind)
+	}
+	if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrSystemNotConfigured) {
+		return json.RawMessage("null"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s state: %w", subject, err)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s state: %w", subject, err)
+	}
+	return data, nil
+}
+
+// systemState is the system configuration together with its lifts
+func (s *AuditService) systemState(ctx context.Context) (any, error) {
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lifts, err := s.repo.ListLifts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		*domain.System
+		Lifts []*domain.Lift `json:"lifts"`
+	}{system, lifts}, nil
+}
+
+// Record appends an entry with the changes between the before and after
+// states of its subject. The entry's payload is summarised and its outcome
+// derived from its HTTP status. The call it records has already been
+// answered, so failures are logged rather than returned.
+func (s *AuditService) Record(ctx context.Context, entry *domain.AuditEntry, before, after json.RawMessage) {
+	entry.Payload = summarisePayload(entry.Payload)
+	entry.Outcome = auditOutcome(entry.Status)
+
+	changes, err := diffStates(before, after)
+	if err != nil {
+		s.log.Warn(ctx, "Failed to diff audit states", "subject", entry.Subject, "error", err)
+	}
+	entry.Changes = changes
+	if entry.Changes == nil {
+		entry.Changes = []domain.AuditChange{}
+	}
+
+	seq, err := s.repo.AppendAudit(ctx, entry)
+	if err != nil {
+		s.log.Error(ctx, "Failed to record audit entry", "principal", entry.Principal, "method", entry.Method, "path", entry.Path, "error", err)
+		return
+	}
+	entry.Sequence = seq
+}
+
+// ListAudit returns one page of the audit entries matching the filter, newest
+// first, together with the total number of matching entries
+func (s *AuditService) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, int, error) {
+	entries, total, err := s.repo.ListAudit(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, total, nil
+}
+
+// summarisePayload cuts a request body down for the audit log. Secrets in a
+// JSON object are redacted and long bodies are cut short.
+func summarisePayload(body string) string {
+	if body == "" {
+		return ""
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(body), &doc); err == nil {
+		for field := range doc {
+			for _, redacted := range auditRedactedFields {
+				if strings.Contains(strings.ToLower(field), redacted) {
+					doc[field] = "[redacted]"
+				}
+			}
+		}
+		if data, err := json.Marshal(doc); err == nil {
+			body = string(data)
+		}
+	}
+
+	if len(body) > maxAuditPayload {
+		return body[:maxAuditPayload] + "…"
+	}
+	return body
+}
+
+// auditOutcome tells from the HTTP status of a call how it ended
+func auditOutcome(status int) domain.AuditOutcome {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return domain.AuditDenied
+	case status >= http.StatusBadRequest:
+		return domain.AuditFailed
+	default:
+		return domain.AuditSucceeded
+	}
+}
+
+// diffStates lists the fields that differ between two JSON documents, sorted
+// by field
+func diffStates(before, after json.RawMessage) ([]domain.AuditChange, error) {
+	if before == nil && after == nil {
+		return nil, nil
+	}
+
+	old, err := flattenState(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := flattenState(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []domain.AuditChange
+	for field, value := range old {
+		if other, ok := updated[field]; !ok || !reflect.DeepEqual(value, other) {
+			changes = append(changes, domain.AuditChange{Field: field, Before: value, After: other})
+		}
+	}
+	for field, value := range updated {
+		if _, ok := old[field]; !ok {
+			changes = append(changes, domain.AuditChange{Field: field, After: value})
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b domain.AuditChange) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return changes, nil
+}
+
+// flattenState maps every leaf of a JSON document to its path. Elements of
+// arrays are addressed by their "id" when they have one, so a lift keeps its
+// path when the list is reordered.
+func flattenState(doc json.RawMessage) (map[string]any, error) {
+	fields := make(map[string]any)
+	if len(doc) == 0 {
+		return fields, nil
+	}
+
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return nil, err
+	}
+	flattenValue("", v, fields)
+	return fields, nil
+}
+
+func flattenValue(path string, v any, fields map[string]any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenValue(key, child, fields)
+		}
+	case []any:
+		for i, child := range v {
+			key := strconv.Itoa(i)
+			if obj, ok := child.(map[string]any); ok {
+				if id, ok := obj["id"].(string); ok && id != "" {
+					key = id
+				}
+			}
+			flattenValue(path+"["+key+"]", child, fields)
+		}
+	case nil:
+		// A missing subject has no fields
+	default:
+		for _, ignored := range auditIgnoredFields {
+			if path == ignored || strings.HasSuffix(path, "."+ignored) {
+				return
+			}
+		}
+		fields[path] = v
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// maxWaitingCalls bounds the calls considered in one dispatch pass
+const maxWaitingCalls = 500
+
+// CallService keeps track of hall calls from the moment a button is pressed
+// until a lift arrives or the call is cancelled or rejected
+type CallService struct {
+	repo     ports.CallOperations
+	eventBus events.EventBus
+	metrics  ports.DomainMetrics
+	log      *logger.Logger
+
+	// mu serialises changes to a call's state, so a cancellation cannot be
+	// overwritten by the dispatcher assigning a lift at the same time
+	mu    sync.Mutex
+	stops map[string]chan struct{} // assigned calls, closed when cancelled
+}
+
+// NewCallService creates a new instance of CallService
+func NewCallService(repo ports.CallOperations, eventBus events.EventBus, metrics ports.DomainMetrics, log *logger.Logger) *CallService {
+	return &CallService{
+		repo:     repo,
+		eventBus: eventBus,
+		metrics:  metrics,
+		log:      log,
+		stops:    make(map[string]chan struct{}),
+	}
+}
+
+// GetCall returns a hall call by ID
+func (s *CallService) GetCall(ctx context.Context, id string) (*domain.HallCall, error) {
+	call, err := s.repo.GetHallCall(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hall call: %w", err)
+	}
+	return call, nil
+}
+
+// ListCalls returns one page of the hall calls matching the filter, newest
+// first, together with the total number of matching calls
+func (s *CallService) ListCalls(ctx context.Context, filter domain.HallCallFilter) ([]*domain.HallCall, int, error) {
+	calls, total, err := s.repo.ListHallCalls(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list hall calls: %w", err)
+	}
+	return calls, total, nil
+}
+
+// CancelCall withdraws a call that has not been served yet. A lift already on
+// its way stops at the next floor it reaches.
+func (s *CallService) CancelCall(ctx context.Context, id string) (*domain.HallCall, error) {
+	call, err := s.closeCall(ctx, id, domain.CallCancelled, "")
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info(ctx, "Hall call cancelled", "call_id", id, "floor", call.FloorNumber, "lift_id", call.LiftID)
+	return call, nil
+}
+
+// exclusive runs fn while no call can change state, so a snapshot import can
+// replace the calls without the dispatcher or a cancellation writing over it
+func (s *CallService) exclusive(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn()
+}
+
+// replaced announces the calls a snapshot import closed and the ones it
+// restored. A lift still on its way to a closed call stops at the next floor.
+func (s *CallService) replaced(ctx context.Context, closed, restored []*domain.HallCall) {
+	s.mu.Lock()
+	for _, call := range closed {
+		if stop, ok := s.stops[call.ID]; ok {
+			close(stop)
+			delete(s.stops, call.ID)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, call := range closed {
+		s.metrics.HallCallClosed(call)
+		s.eventBus.Publish(ctx, domain.HallCallClosedEvent{
+			CallID:      call.ID,
+			FloorNumber: call.FloorNumber,
+			Direction:   call.Direction,
+			LiftID:      call.LiftID,
+			Status:      call.Status,
+			Reason:      call.Reason,
+		})
+	}
+	for _, call := range restored {
+		s.eventBus.Publish(ctx, domain.LiftRequestedEvent{
+			FloorNumber: call.FloorNumber,
+			Direction:   call.Direction,
+			CallID:      call.ID,
+		})
+	}
+}
+
+// open records a new pending call
+func (s *CallService) open(ctx context.Context, floorNum int, direction domain.Direction) (*domain.HallCall, error) {
+	now := time.Now()
+	call := &domain.HallCall{
+		ID:          uuid.New().String(),
+		FloorNumber: floorNum,
+		Direction:   direction,
+		Status:      domain.CallPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.SaveHallCall(ctx, call); err != nil {
+		return nil, fmt.Errorf("failed to save hall call: %w", err)
+	}
+	return call, nil
+}
+
+// assign records the lift sent to a pending call. The returned channel is
+// closed if the call is cancelled while the lift is on its way. It returns
+// domain.ErrHallCallClosed if the call was cancelled before a lift was found.
+func (s *CallService) assign(ctx context.Context, id, liftID string, eta time.Time) (<-chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, err := s.repo.GetHallCall(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hall call: %w", err)
+	}
+	if call.Status != domain.CallPending {
+		return nil, fmt.Errorf("hall call %s is %s: %w", id, call.Status, domain.ErrHallCallClosed)
+	}
+
+	now := time.Now()
+	call.Status = domain.CallAssigned
+	call.LiftID = liftID
+	call.ETA = &eta
+	call.AssignedAt = &now
+	call.UpdatedAt = now
+	if err := s.repo.UpdateHallCall(ctx, call); err != nil {
+		return nil, fmt.Errorf("failed to update hall call: %w", err)
+	}
+
+	stop := make(chan struct{})
+	s.stops[id] = stop
+	return stop, nil
+}
+
+// waiting returns the calls still waiting for a lift, longest waiting first
+func (s *CallService) waiting(ctx context.Context) ([]*domain.HallCall, error) {
+	calls, _, err := s.repo.ListHallCalls(ctx, domain.HallCallFilter{
+		Status:      domain.CallPending,
+		OldestFirst: true,
+		Limit:       maxWaitingCalls,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list waiting hall calls: %w", err)
+	}
+	return calls, nil
+}
+
+// requeue puts an assigned call back in the queue after its lift turned out
+// to be unable to go
+func (s *CallService) requeue(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, err := s.repo.GetHallCall(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get hall call: %w", err)
+	}
+	if call.Status != domain.CallAssigned {
+		return nil
+	}
+
+	call.Status = domain.CallPending
+	call.LiftID = ""
+	call.ETA = nil
+	call.AssignedAt = nil
+	call.UpdatedAt = time.Now()
+	if err := s.repo.UpdateHallCall(ctx, call); err != nil {
+		return fmt.Errorf("failed to update hall call: %w", err)
+	}
+
+	delete(s.stops, id)
+	return nil
+}
+
+// escalate flags a call that has waited too long for a lift
+func (s *CallService) escalate(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, err := s.repo.GetHallCall(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get hall call: %w", err)
+	}
+	if call.Status != domain.CallPending || call.EscalatedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	call.EscalatedAt = &now
+	call.UpdatedAt = now
+	if err := s.repo.UpdateHallCall(ctx, call); err != nil {
+		return fmt.Errorf("failed to update hall call: %w", err)
+	}
+
+	s.log.Warn(ctx, "Hall call escalated", "call_id", id, "floor", call.FloorNumber, "waiting", now.Sub(call.CreatedAt))
+	s.eventBus.Publish(ctx, domain.HallCallEscalatedEvent{
+		CallID:       call.ID,
+		FloorNumber:  call.FloorNumber,
+		Direction:    call.Direction,
+		WaitingSince: call.CreatedAt,
+	})
+	return nil
+}
+
+// progress moves the ETA of an assigned call as its lift reaches each floor.
+// Failures only leave a stale ETA behind, so they are logged.
+func (s *CallService) progress(ctx context.Context, id string, eta time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, err := s.repo.GetHallCall(ctx, id)
+	if err != nil {
+		s.log.Warn(ctx, "Failed to get hall call", "call_id", id, "error", err)
+		return
+	}
+	if call.Status != domain.CallAssigned {
+		return
+	}
+
+	call.ETA = &eta
+	call.UpdatedAt = time.Now()
+	if err := s.repo.UpdateHallCall(ctx, call); err != nil {
+		s.log.Warn(ctx, "Failed to update hall call ETA", "call_id", id, "error", err)
+	}
+}
+
+// finish closes a call on behalf of the dispatcher. A call that was cancelled
+// in the meantime keeps its state.
+func (s *CallService) finish(ctx context.Context, id string, status domain.HallCallStatus, reason string) {
+	call, err := s.closeCall(ctx, id, status, reason)
+	if errors.Is(err, domain.ErrHallCallClosed) {
+		return
+	}
+	if err != nil {
+		s.log.Warn(ctx, "Failed to close hall call", "call_id", id, "status", status, "error", err)
+		return
+	}
+	s.log.Info(ctx, "Hall call closed", "call_id", id, "status", status, "floor", call.FloorNumber, "reason", reason)
+}
+
+// closeCall puts a call in a final state. The hall button is turned off when
+// no other call is waiting in the same direction.
+func (s *CallService) closeCall(ctx context.Context, id string, status domain.HallCallStatus, reason string) (*domain.HallCall, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, err := s.repo.GetHallCall(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hall call: %w", err)
+	}
+	if call.Status.Closed() {
+		return nil, fmt.Errorf("hall call %s is %s: %w", id, call.Status, domain.ErrHallCallClosed)
+	}
+
+	now := time.Now()
+	call.Status = status
+	call.Reason = reason
+	call.ETA = nil
+	call.ClosedAt = &now
+	call.UpdatedAt = now
+	if err := s.repo.UpdateHallCall(ctx, call); err != nil {
+		return nil, fmt.Errorf("failed to update hall call: %w", err)
+	}
+	s.metrics.HallCallClosed(call)
+
+	if stop, ok := s.stops[id]; ok {
+		close(stop)
+		delete(s.stops, id)
+	}
+
+	cleared, err := s.clearButton(ctx, call)
+	if err != nil {
+		s.log.Warn(ctx, "Failed to clear hall button", "call_id", id, "floor", call.FloorNumber, "error", err)
+	}
+
+	s.eventBus.Publish(ctx, domain.HallCallClosedEvent{
+		CallID:        call.ID,
+		FloorNumber:   call.FloorNumber,
+		Direction:     call.Direction,
+		LiftID:        call.LiftID,
+		Status:        call.Status,
+		Reason:        call.Reason,
+		ButtonCleared: cleared,
+	})
+	return call, nil
+}
+
+// clearButton turns off the hall button of a closed call unless another call
+// is still waiting in the same direction. The caller holds s.mu.
+func (s *CallService) clearButton(ctx context.Context, call *domain.HallCall) (bool, error) {
+	for _, status := range []domain.HallCallStatus{domain.CallPending, domain.CallAssigned} {
+		_, open, err := s.repo.ListHallCalls(ctx, domain.HallCallFilter{
+			Floor:     &call.FloorNumber,
+			Direction: &call.Direction,
+			Status:    status,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to count open hall calls: %w", err)
+		}
+		if open > 0 {
+			return false, nil
+		}
+	}
+
+	floor, err := s.repo.GetFloorByNumber(ctx, call.FloorNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to get floor %d: %w", call.FloorNumber, err)
+	}
+	if err := floor.CancelRequest(call.Direction); err != nil {
+		return false, err
+	}
+	if err := s.repo.UpdateFloor(ctx, floor); err != nil {
+		return false, fmt.Errorf("failed to update floor %d: %w", call.FloorNumber, err)
+	}
+	return true, nil
+}
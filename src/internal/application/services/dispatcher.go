@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+)
+
+// DispatchConfig configures how hall calls wait for a free lift
+type DispatchConfig struct {
+	// Interval is how often waiting calls are looked at when nothing else
+	// wakes the dispatcher, e.g. when a door hold runs out
+	Interval time.Duration
+	// MaxWait is how long a call may wait for a lift before it is escalated
+	MaxWait time.Duration
+}
+
+// RunDispatcher hands free lifts to the hall calls waiting for one until ctx
+// is done. It wakes whenever a lift may have become free, and at least every
+// DispatchConfig.Interval.
+func (s *LiftService) RunDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(s.dispatch.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+		s.dispatchWaiting(ctx)
+	}
+}
+
+// StopServing stops handing lifts to hall calls and waits until the lifts
+// already sent reach their floors or ctx is done. Calls still waiting stay
+// pending, to be served once the dispatcher runs again.
+func (s *LiftService) StopServing(ctx context.Context) error {
+	s.dispatchMu.Lock()
+	s.stopped = true
+	s.dispatchMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.serving.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// processLiftRequest queues a new hall call and dispatches the waiting calls
+func (s *LiftService) processLiftRequest(ctx context.Context, callID string) {
+	// Keep the request's trace, so the call can still be followed if it has
+	// to wait for a lift
+	s.dispatchMu.Lock()
+	s.traces[callID] = context.WithoutCancel(ctx)
+	s.dispatchMu.Unlock()
+
+	s.dispatchWaiting(ctx)
+}
+
+// liftFreed wakes the dispatcher because a lift may have become available
+func (s *LiftService) liftFreed() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchWaiting gives free lifts to the waiting calls, longest waiting
+// first, and escalates calls that have waited longer than MaxWait
+func (s *LiftService) dispatchWaiting(ctx context.Context) {
+	s.dispatchMu.Lock()
+	defer s.dispatchMu.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	calls, err := s.calls.waiting(ctx)
+	if err != nil {
+		s.log.Error(ctx, "Failed to get waiting hall calls", "error", err)
+		return
+	}
+
+	now := time.Now()
+	traces := make(map[string]context.Context, len(calls))
+	liftsFree := true
+	for _, call := range calls {
+		callCtx, ok := s.traces[call.ID]
+		if !ok {
+			callCtx = context.WithoutCancel(ctx)
+		}
+
+		if liftsFree {
+			lift, stop, err := s.assignLift(callCtx, call)
+			switch {
+			case err == nil:
+				s.serving.Add(1)
+				go func() {
+					defer s.serving.Done()
+					s.serveCall(callCtx, call, lift, stop)
+				}()
+				continue
+			case errors.Is(err, domain.ErrHallCallClosed), errors.Is(err, domain.ErrFloorNotFound):
+				continue
+			case errors.Is(err, domain.ErrFloorAtCapacity):
+				// Only this floor is full; the call waits for the next pass
+			default:
+				// Every lift is busy, or the repository failed and would
+				// likely fail for the next call too. The calls keep waiting
+				// and are tried again on the next pass.
+				liftsFree = false
+			}
+		}
+
+		traces[call.ID] = callCtx
+		if call.EscalatedAt == nil && now.Sub(call.CreatedAt) > s.dispatch.MaxWait {
+			if err := s.calls.escalate(callCtx, call.ID); err != nil {
+				s.log.Error(callCtx, "Failed to escalate hall call", "call_id", call.ID, "error", err)
+			}
+		}
+	}
+	s.traces = traces
+}
+
+// assignLift picks a lift for a waiting call and reserves it. It returns
+// domain.ErrNoLiftAvailable when every lift is busy. A call whose floor is at
+// capacity, or that fails on a repository error, stays in the queue and is
+// tried again on the next pass; only a call for a floor that no longer exists
+// is rejected. The caller holds s.dispatchMu.
+func (s *LiftService) assignLift(ctx context.Context, call *domain.HallCall) (*domain.Lift, <-chan struct{}, error) {
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		s.log.Error(ctx, "Failed to get system information", "error", err)
+		return nil, nil, err
+	}
+
+	maxLiftsPerFloor := max(int(math.Ceil(float64(system.TotalLifts)*0.1)), 2)
+	floor, err := s.repo.GetFloorByNumber(ctx, call.FloorNumber)
+	if errors.Is(err, domain.ErrFloorNotFound) {
+		s.log.Warn(ctx, "Hall call for a floor that no longer exists", "call_id", call.ID, "floor", call.FloorNumber)
+		s.calls.finish(ctx, call.ID, domain.CallRejected, err.Error())
+		return nil, nil, err
+	}
+	if err != nil {
+		s.log.Error(ctx, "Failed to get floor information", "floor_num", call.FloorNumber, "error", err)
+		return nil, nil, err
+	}
+
+	assignedLifts, err := s.repo.GetAssignedLiftsForFloor(ctx, floor.ID)
+	if err != nil {
+		s.log.Error(ctx, "Failed to get assigned lifts for floor", "floor", call.FloorNumber, "error", err)
+		return nil, nil, err
+	}
+
+	if len(assignedLifts) >= maxLiftsPerFloor {
+		s.log.Debug(ctx, "Hall call waiting for room at its floor", "call_id", call.ID, "floor", call.FloorNumber, "max_capacity", maxLiftsPerFloor)
+		return nil, nil, domain.ErrFloorAtCapacity
+	}
+
+	lift, err := s.findAvailableLift(ctx, floor)
+	if errors.Is(err, domain.ErrNoLiftAvailable) {
+		s.log.Debug(ctx, "Hall call waiting for a free lift", "call_id", call.ID, "floor", call.FloorNumber)
+		return nil, nil, err
+	}
+	if err != nil {
+		s.log.Error(ctx, "Failed to find available lift", "error", err)
+		return nil, nil, err
+	}
+
+	distance := time.Duration(abs(lift.CurrentFloor-call.FloorNumber)) * domain.FloorTravelTime
+	stop, err := s.calls.assign(ctx, call.ID, lift.ID, time.Now().Add(distance))
+	if err != nil {
+		s.log.Info(ctx, "Hall call not dispatched", "call_id", call.ID, "floor", call.FloorNumber, "reason", err)
+		return nil, nil, err
+	}
+
+	s.reserve(lift.ID)
+	return lift, stop, nil
+}
+
+// serveCall takes a reserved lift to the floor of an assigned call and keeps
+// the call up to date until the lift arrives
+func (s *LiftService) serveCall(ctx context.Context, call *domain.HallCall, lift *domain.Lift, stop <-chan struct{}) {
+	defer s.release(lift.ID)
+
+	floorNum := call.FloorNumber
+	if lift.CurrentFloor == floorNum {
+		s.log.Info(ctx, "Lift already at requested floor", "lift_id", lift.ID, "floor", floorNum)
+		s.eventBus.Publish(ctx, domain.LiftArrivedEvent{LiftID: lift.ID, FloorNumber: floorNum})
+		s.eventBus.Publish(ctx, domain.LiftDoorsOpenedEvent{LiftID: lift.ID, FloorNumber: floorNum})
+		s.broadcastLift(lift, domain.LiftArrived)
+		s.calls.finish(ctx, call.ID, domain.CallServed, "")
+		return
+	}
+
+	s.log.Info(ctx, "Lift is Moving", "lift_id", lift.ID, "target_floor", floorNum, "direction", call.Direction)
+
+	stopFloor, err := s.moveLift(ctx, lift.ID, floorNum, 0, domain.TripHallCall, &moveControl{
+		stop: stop,
+		reached: func(reached int) {
+			eta := time.Now().Add(time.Duration(abs(floorNum-reached)) * domain.FloorTravelTime)
+			s.calls.progress(ctx, call.ID, eta)
+		},
+	})
+	switch {
+	case errors.Is(err, domain.ErrLiftBusy), errors.Is(err, domain.ErrLiftOutOfService),
+		errors.Is(err, domain.ErrLiftDoorsHeld), errors.Is(err, domain.ErrLiftAlreadyAtFloor):
+		// The lift was moved or taken out of service after it was chosen
+		s.log.Warn(ctx, "Assigned lift cannot go, requeueing hall call", "lift_id", lift.ID, "call_id", call.ID, "error", err)
+		if err := s.calls.requeue(ctx, call.ID); err != nil {
+			s.log.Error(ctx, "Failed to requeue hall call", "call_id", call.ID, "error", err)
+		}
+	case err != nil:
+		s.log.Error(ctx, "Failed to move lift", "lift_id", lift.ID, "target_floor", floorNum, "error", err)
+		s.calls.finish(ctx, call.ID, domain.CallRejected, err.Error())
+	case stopFloor != floorNum:
+		s.log.Info(ctx, "Lift stopped for a cancelled call", "lift_id", lift.ID, "call_id", call.ID, "floor", stopFloor)
+	default:
+		s.calls.finish(ctx, call.ID, domain.CallServed, "")
+		s.log.Info(ctx, "Lift arrived at requested floor", "lift_id", lift.ID, "floor", floorNum)
+	}
+}
+
+// reserve keeps a lift chosen for a hall call from being chosen again before
+// it departs
+func (s *LiftService) reserve(liftID string) {
+	s.reservedMu.Lock()
+	defer s.reservedMu.Unlock()
+	s.reserved[liftID] = true
+}
+
+// release ends the reservation of a lift and lets the dispatcher use it
+func (s *LiftService) release(liftID string) {
+	s.reservedMu.Lock()
+	delete(s.reserved, liftID)
+	s.reservedMu.Unlock()
+
+	s.liftFreed()
+}
+
+func (s *LiftService) isReserved(liftID string) bool {
+	s.reservedMu.Lock()
+	defer s.reservedMu.Unlock()
+	return s.reserved[liftID]
+}
+
+// drain stops the lift from being given new hall calls and moves
+func (s *LiftService) drain(liftID string) {
+	s.drainingMu.Lock()
+	defer s.drainingMu.Unlock()
+	s.draining[liftID] = true
+}
+
+// undrain forgets a lift that has been removed, or could not be
+func (s *LiftService) undrain(liftID string) {
+	s.drainingMu.Lock()
+	defer s.drainingMu.Unlock()
+	delete(s.draining, liftID)
+}
+
+func (s *LiftService) isDraining(liftID string) bool {
+	s.drainingMu.Lock()
+	defer s.drainingMu.Unlock()
+	return s.draining[liftID]
+}
+
+// drainingLifts returns the IDs of the lifts being drained
+func (s *LiftService) drainingLifts() []string {
+	s.drainingMu.Lock()
+	defer s.drainingMu.Unlock()
+
+	ids := make([]string, 0, len(s.draining))
+	for id := range s.draining {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// forgetLifts drops the reservations, drains and door holds of the lifts not
+// in keep, once a snapshot import has removed the lifts
+func (s *LiftService) forgetLifts(keep map[string]bool) {
+	s.reservedMu.Lock()
+	for id := range s.reserved {
+		if !keep[id] {
+			delete(s.reserved, id)
+		}
+	}
+	s.reservedMu.Unlock()
+
+	s.drainingMu.Lock()
+	for id := range s.draining {
+		if !keep[id] {
+			delete(s.draining, id)
+		}
+	}
+	s.drainingMu.Unlock()
+
+	s.holdsMu.Lock()
+	for id := range s.holds {
+		if !keep[id] {
+			delete(s.holds, id)
+		}
+	}
+	s.holdsMu.Unlock()
+}
+
+// idle reports whether a lift is parked with nothing to do: it is not moving,
+// not chosen for a hall call and its doors are not held
+func (s *LiftService) idle(ctx context.Context, liftID string) (bool, error) {
+	// A dispatch pass reserves the lifts it picks before it lets go of
+	// dispatchMu, so no lift is between being chosen and being reserved here
+	s.dispatchMu.Lock()
+	defer s.dispatchMu.Unlock()
+
+	lift, err := s.repo.GetLift(ctx, liftID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get lift: %w", err)
+	}
+	return lift.Status != domain.Occupied && !s.isMoving(liftID) && !s.isReserved(liftID) && !s.doorsHeld(liftID), nil
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+)
+
+// replayPageSize is the number of events read from the store per query while
+// replaying
+const replayPageSize = 500
+
+// EventService exposes the domain event store and rebuilds projections from it
+type EventService struct {
+	repo ports.Repository
+	log  *logger.Logger
+}
+
+// ReplayResult summarises a projection rebuild
+type ReplayResult struct {
+	SystemID     string `json:"system_id"`
+	Events       int    `json:"events"`
+	LastSequence int64  `json:"last_sequence"`
+	Lifts        int    `json:"lifts"`
+	Floors       int    `json:"floors"`
+}
+
+// NewEventService creates a new instance of EventService
+func NewEventService(repo ports.Repository, log *logger.Logger) *EventService {
+	return &EventService{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// ListEvents returns up to limit stored events after the given sequence number
+func (s *EventService) ListEvents(ctx context.Context, since int64, limit int) ([]*domain.StoredEvent, error) {
+	events, err := s.repo.ListEvents(ctx, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	return events, nil
+}
+
+// LatestSequence returns the sequence number of the newest stored event
+func (s *EventService) LatestSequence(ctx context.Context) (int64, error) {
+	seq, err := s.repo.LatestEventSequence(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest event sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// RebuildProjections resets every lift and floor of the current system and
+// replays the event store over them to reconstruct their state, including
+// which lifts are parked at which floors.
+func (s *EventService) RebuildProjections(ctx context.Context) (*ReplayResult, error) {
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system configuration: %w", err)
+	}
+
+	lifts, err := s.repo.GetAllLifts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lifts: %w", err)
+	}
+
+	floors, err := s.repo.GetAllFloors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get floors: %w", err)
+	}
+
+	p := newProjection(lifts, floors)
+	result := &ReplayResult{SystemID: system.ID, Lifts: len(lifts), Floors: len(floors)}
+
+	var since int64
+	for {
+		page, err := s.repo.ListEvents(ctx, since, replayPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read events after %d: %w", since, err)
+		}
+
+		for _, stored := range page {
+			since = stored.Sequence
+			if stored.SystemID != system.ID {
+				continue
+			}
+			p.apply(stored.Event)
+			result.Events++
+			result.LastSequence = stored.Sequence
+		}
+
+		if len(page) < replayPageSize {
+			break
+		}
+	}
+
+	for _, lift := range p.lifts {
+		if err := s.repo.UpdateLift(ctx, lift); err != nil {
+			return nil, fmt.Errorf("failed to update lift %s: %w", lift.ID, err)
+		}
+	}
+
+	for _, floor := range p.floors {
+		if err := s.repo.UpdateFloor(ctx, floor); err != nil {
+			return nil, fmt.Errorf("failed to update floor %d: %w", floor.Number, err)
+		}
+	}
+
+	if err := s.repo.UnassignBulk(ctx); err != nil {
+		return nil, fmt.Errorf("failed to clear lift assignments: %w", err)
+	}
+	for liftID, floorNum := range p.parked {
+		floor, ok := p.floors[floorNum]
+		if !ok {
+			continue
+		}
+		if err := s.repo.AssignLiftToFloor(ctx, liftID, floor.ID, floorNum); err != nil {
+			return nil, fmt.Errorf("failed to assign lift %s to floor %d: %w", liftID, floorNum, err)
+		}
+	}
+
+	s.log.Info(ctx, "Rebuilt projections from event store",
+		"system_id", system.ID,
+		"events", result.Events,
+		"last_sequence", result.LastSequence)
+	return result, nil
+}
+
+// projection is the in-memory lift and floor state built up during a replay
+type projection struct {
+	lifts  map[string]*domain.Lift
+	floors map[int]*domain.Floor
+	parked map[string]int
+}
+
+func newProjection(lifts []*domain.Lift, floors []*domain.Floor) *projection {
+	p := &projection{
+		lifts:  make(map[string]*domain.Lift, len(lifts)),
+		floors: make(map[int]*domain.Floor, len(floors)),
+		parked: make(map[string]int),
+	}
+
+	for _, lift := range lifts {
+		lift.Reset()
+		p.lifts[lift.ID] = lift
+	}
+	for _, floor := range floors {
+		floor.ResetButtons()
+		p.floors[floor.Number] = floor
+	}
+
+	return p
+}
+
+func (p *projection) apply(event domain.Event) {
+	switch e := event.(type) {
+	case domain.LiftRequestedEvent:
+		if floor, ok := p.floors[e.FloorNumber]; ok {
+			floor.RequestLift(e.Direction)
+		}
+
+	case domain.HallCallClearedEvent:
+		if floor, ok := p.floors[e.FloorNumber]; ok {
+			floor.ResetButtons()
+		}
+
+	case domain.HallCallClosedEvent:
+		if floor, ok := p.floors[e.FloorNumber]; ok && e.ButtonCleared {
+			floor.CancelRequest(e.Direction)
+		}
+
+	case domain.LiftDepartedEvent:
+		if lift, ok := p.lifts[e.LiftID]; ok {
+			lift.SetCurrentFloor(e.FromFloor)
+			lift.Depart(e.TargetFloor)
+			delete(p.parked, e.LiftID)
+		}
+
+	case domain.LiftPassedFloorEvent:
+		if lift, ok := p.lifts[e.LiftID]; ok {
+			lift.SetCurrentFloor(e.FloorNumber)
+		}
+
+	case domain.LiftArrivedEvent:
+		if lift, ok := p.lifts[e.LiftID]; ok {
+			lift.TargetFloor = e.FloorNumber
+			lift.Arrive()
+			p.parked[e.LiftID] = e.FloorNumber
+		}
+
+	case domain.LiftStatusChangedEvent:
+		if lift, ok := p.lifts[e.LiftID]; ok {
+			lift.SetStatus(e.Status)
+		}
+
+	case domain.LiftResetEvent:
+		if lift, ok := p.lifts[e.LiftID]; ok {
+			lift.Reset()
+			delete(p.parked, e.LiftID)
+		}
+	}
+}
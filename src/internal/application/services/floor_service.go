@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"math"
 	"strconv"
@@ -17,6 +16,7 @@ import (
 // FloorService handles the business logic for floor operations
 type FloorService struct {
 	repo     ports.FloorOperations
+	calls    *CallService
 	eventBus events.EventBus
 	log      *logger.Logger
 	hub      *websockets.WebSocketHub
@@ -30,9 +30,10 @@ type LiftArrivedHandler struct {
 }
 
 // NewFloorService creates a new instance of FloorService
-func NewFloorService(repo ports.FloorOperations, eventBus events.EventBus, log *logger.Logger, hub *websockets.WebSocketHub) *FloorService {
+func NewFloorService(repo ports.FloorOperations, calls *CallService, eventBus events.EventBus, log *logger.Logger, hub *websockets.WebSocketHub) *FloorService {
 	service := &FloorService{
 		repo:     repo,
+		calls:    calls,
 		eventBus: eventBus,
 		log:      log,
 		hub:      hub,
@@ -42,68 +43,67 @@ func NewFloorService(repo ports.FloorOperations, eventBus events.EventBus, log *
 	return service
 
 }
-func (h *LiftAssignedHandler) Handle(event domain.Event) {
-	if liftAssignedEvent, ok := event.(domain.LiftAssignedEvent); ok {
-		h.service.handleLiftAssigned(context.Background(), liftAssignedEvent.FloorNumber, liftAssignedEvent.LiftID)
+func (h *LiftAssignedHandler) Handle(ctx context.Context, env domain.Envelope) {
+	if liftAssignedEvent, ok := env.Event.(domain.LiftAssignedEvent); ok {
+		h.service.handleLiftAssigned(ctx, liftAssignedEvent.FloorNumber, liftAssignedEvent.LiftID)
 	}
 }
 
-func (h *LiftArrivedHandler) Handle(event domain.Event) {
-	if liftArrivedEvent, ok := event.(domain.LiftArrivedEvent); ok {
-		h.service.handleLiftArrived(context.Background(), liftArrivedEvent.LiftID, liftArrivedEvent.FloorNumber)
+func (h *LiftArrivedHandler) Handle(ctx context.Context, env domain.Envelope) {
+	if liftArrivedEvent, ok := env.Event.(domain.LiftArrivedEvent); ok {
+		h.service.handleLiftArrived(ctx, liftArrivedEvent.LiftID, liftArrivedEvent.FloorNumber)
 	}
 }
 
 func (s *FloorService) handleLiftAssigned(ctx context.Context, floorNum int, liftID string) {
 	s.log.Info(ctx, "Lift assigned to floor", "floor", floorNum, "lift_id", liftID)
-	if err := s.updateFloorDisplay(ctx, floorNum, liftID); err != nil {
+	if err := s.updateFloorDisplay(ctx, floorNum, liftID, domain.LiftAssigned); err != nil {
 		s.log.Error(ctx, "Failed to update floor display", "floor", floorNum, "lift_id", liftID, "error", err)
 	}
 }
 
 func (s *FloorService) handleLiftArrived(ctx context.Context, liftID string, floorNum int) {
+	// The hall button of the call the lift came for is cleared when the call
+	// is closed; a button pressed for the other direction stays lit
 	s.log.Info(ctx, "Lift arrived at floor", "floor", floorNum, "lift_id", liftID)
-	if err := s.ResetFloorButtons(ctx, floorNum); err != nil {
-		s.log.Error(ctx, "Failed to reset floor buttons", "floor", floorNum, "error", err)
-	}
-	if err := s.updateFloorDisplay(ctx, floorNum, liftID); err != nil {
+	if err := s.updateFloorDisplay(ctx, floorNum, liftID, domain.LiftArrived); err != nil {
 		s.log.Error(ctx, "Failed to update floor display", "floor", floorNum, "lift_id", liftID, "error", err)
 	}
 }
 
-func (s *FloorService) updateFloorDisplay(ctx context.Context, floorNum int, liftID string) error {
+func (s *FloorService) updateFloorDisplay(ctx context.Context, floorNum int, liftID string, cause domain.EventType) error {
 	// For now, we'll just log the information
 	s.log.Info(ctx, "Floor display updated", "floor", floorNum, "assigned_lift", liftID)
-	s.sendWebSocketUpdate(ctx, "floor", strconv.Itoa(floorNum), fmt.Sprintf("display_updated:%s", liftID), floorNum)
+	s.sendWebSocketUpdate(ctx, "floor", strconv.Itoa(floorNum), fmt.Sprintf("display_updated:%s", liftID), floorNum, cause)
 	return nil
 }
 
-func (s *FloorService) sendWebSocketUpdate(ctx context.Context, updateType, id, status string, currentFloor int) {
+func (s *FloorService) sendWebSocketUpdate(ctx context.Context, updateType, id, status string, currentFloor int, cause domain.EventType) {
 	update := websockets.StatusUpdate{
 		Type:         updateType,
 		ID:           id,
+		Event:        cause.String(),
 		Status:       status,
 		CurrentFloor: currentFloor,
 	}
 
 	s.hub.BroadcastUpdate(update)
-	s.log.Info(ctx, "WebSocket update sent", "type", updateType, "id", id, "status", status)
+	s.log.Info(ctx, "WebSocket update sent", "type", updateType, "id", id, "event", update.Event, "status", status)
 }
 
-func (s *FloorService) CallLift(ctx context.Context, floorNum int, direction domain.Direction) error {
+// CallLift presses the hall button of a floor and returns the call, which is
+// followed until a lift arrives at the floor
+func (s *FloorService) CallLift(ctx context.Context, floorNum int, direction domain.Direction) (*domain.HallCall, error) {
 	floor, err := s.repo.GetFloorByNumber(ctx, floorNum)
 	if err != nil {
-		if errors.Is(err, domain.ErrFloorNotFound) {
-			return domain.ErrFloorNotFound
-		}
-		return fmt.Errorf("failed to get floor %d: %w", floorNum, err)
+		return nil, fmt.Errorf("failed to get floor %d: %w", floorNum, err)
 	}
 
 	// Check floor capacity before requesting a lift
 	system, err := s.repo.GetSystem(ctx)
 	if err != nil {
 		s.log.Error(ctx, "Failed to get system information", "error", err)
-		return fmt.Errorf("failed to get system information: %w", err)
+		return nil, fmt.Errorf("failed to get system information: %w", err)
 	}
 
 	maxLiftsPerFloor := max(int(math.Ceil(float64(system.TotalLifts)*0.1)), 2)
@@ -112,24 +112,39 @@ func (s *FloorService) CallLift(ctx context.Context, floorNum int, direction dom
 	s.log.Info(ctx, "Assigned lifts for floor", "floor", floorNum, "assignedLifts", assignedLifts)
 	if err != nil {
 		s.log.Error(ctx, "Failed to get assigned lifts for floor", "floor", floorNum, "error", err)
-		return fmt.Errorf("failed to get assigned lifts for floor: %w", err)
+		return nil, fmt.Errorf("failed to get assigned lifts for floor: %w", err)
 	}
 
 	if len(assignedLifts) >= maxLiftsPerFloor {
 		s.log.Warn(ctx, "Floor has reached maximum lift capacity", "floor", floorNum, "max_capacity", maxLiftsPerFloor)
-		s.eventBus.Publish(domain.FloorAtCapacityEvent{FloorNumber: floorNum})
-		return fmt.Errorf("floor %d has reached maximum lift capacity", floorNum)
+		s.eventBus.Publish(ctx, domain.FloorAtCapacityEvent{FloorNumber: floorNum})
+		return nil, fmt.Errorf("floor %d: %w", floorNum, domain.ErrFloorAtCapacity)
+	}
+
+	// Light the hall button until a lift arrives
+	if err := floor.RequestLift(direction); err != nil {
+		return nil, fmt.Errorf("failed to request lift: %w", err)
+	}
+	if err := s.repo.UpdateFloor(ctx, floor); err != nil {
+		s.log.Error(ctx, "Failed to update floor buttons", "floor", floorNum, "error", err)
+		return nil, fmt.Errorf("failed to update floor %d: %w", floorNum, err)
+	}
+
+	call, err := s.calls.open(ctx, floorNum, direction)
+	if err != nil {
+		return nil, err
 	}
 
 	// If the floor hasn't reached capacity, proceed with the lift request
 	event := domain.LiftRequestedEvent{
 		FloorNumber: floorNum,
 		Direction:   direction,
+		CallID:      call.ID,
 	}
-	s.eventBus.Publish(event)
+	s.eventBus.Publish(ctx, event)
 
-	s.log.Info(ctx, "Lift requested", "floor", floor.Number, "direction", direction)
-	return nil
+	s.log.Info(ctx, "Lift requested", "floor", floor.Number, "direction", direction, "call_id", call.ID)
+	return call, nil
 }
 
 // GetFloorStatus retrieves the current status of a floor
@@ -150,7 +165,7 @@ func (s *FloorService) ListFloors(ctx context.Context) ([]*domain.Floor, error)
 	return floors, nil
 }
 
-// ResetFloorButtons resets the call buttons on a floor after a lift has arrived
+// ResetFloorButtons switches off both call buttons of a floor
 func (s *FloorService) ResetFloorButtons(ctx context.Context, floorNum int) error {
 	floor, err := s.repo.GetFloorByNumber(ctx, floorNum)
 	if err != nil {
@@ -163,6 +178,7 @@ func (s *FloorService) ResetFloorButtons(ctx context.Context, floorNum int) erro
 		return fmt.Errorf("failed to update floor %d: %w", floorNum, err)
 	}
 
+	s.eventBus.Publish(ctx, domain.HallCallClearedEvent{FloorNumber: floorNum})
 	return nil
 }
 
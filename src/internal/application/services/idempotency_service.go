@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	// idempotencyAbandonAfter is how long a key stays reserved by a request
+	// that never finished, e.g. because its API instance stopped. It is well
+	// above the time any request may take.
+	idempotencyAbandonAfter = time.Minute
+	// idempotencyPurgeInterval is how often expired records are removed
+	idempotencyPurgeInterval = 10 * time.Minute
+)
+
+// IdempotencyConfig holds the configuration for idempotency keys
+type IdempotencyConfig struct {
+	// TTL is how long the response to a key is kept
+	TTL time.Duration
+}
+
+// IdempotencyService remembers the first response to each request sent with
+// an idempotency key, so that clients can retry mutating requests without
+// calling a lift twice
+type IdempotencyService struct {
+	repo ports.IdempotencyRepository
+	cfg  IdempotencyConfig
+	log  *logger.Logger
+}
+
+// NewIdempotencyService creates a new instance of IdempotencyService
+func NewIdempotencyService(repo ports.IdempotencyRepository, cfg IdempotencyConfig, log *logger.Logger) *IdempotencyService {
+	return &IdempotencyService{
+		repo: repo,
+		cfg:  cfg,
+		log:  log,
+	}
+}
+
+// Begin claims key for a request by principal. When the key was already used
+// for the same request, the record of the first response is returned with
+// replay set. Otherwise the key is reserved and the returned record must be
+// passed to Finish or Release once the request has been handled. A key used
+// for a different request is rejected with domain.ErrIdempotencyKeyReused,
+// and one whose first request is still being handled with
+// domain.ErrIdempotencyKeyInUse.
+func (s *IdempotencyService) Begin(ctx context.Context, principal, key, method, path string, body []byte) (*domain.IdempotencyRecord, bool, error) {
+	if !validIdempotencyKey(key) {
+		return nil, false, fmt.Errorf("%w: must be 1 to %d printable ASCII characters", domain.ErrInvalidIdempotencyKey, maxIdempotencyKeyLength)
+	}
+
+	now := time.Now().UTC()
+	rec := &domain.IdempotencyRecord{
+		Principal:   principal,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: hashRequest(method, path, body),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.cfg.TTL),
+	}
+
+	reserved, err := s.repo.ReserveIdempotencyKey(ctx, rec, now.Add(-idempotencyAbandonAfter))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	if reserved {
+		return rec, false, nil
+	}
+
+	existing, err := s.repo.GetIdempotencyRecord(ctx, principal, key)
+	if errors.Is(err, domain.ErrIdempotencyKeyNotFound) {
+		// Purged since it was found to be taken; the client may retry
+		return nil, false, fmt.Errorf("idempotency key %s: %w", key, domain.ErrIdempotencyKeyInUse)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+
+	if existing.RequestHash != rec.RequestHash {
+		return nil, false, fmt.Errorf("idempotency key %s: %w", key, domain.ErrIdempotencyKeyReused)
+	}
+	if !existing.Completed() {
+		return nil, false, fmt.Errorf("idempotency key %s: %w", key, domain.ErrIdempotencyKeyInUse)
+	}
+
+	s.log.Info(ctx, "Replaying idempotent response", "principal", principal, "key", key, "method", method, "path", path, "status", existing.Status)
+	return existing, true, nil
+}
+
+// Finish stores the response to a reserved key. The request has already been
+// answered, so failures are logged rather than returned.
+func (s *IdempotencyService) Finish(ctx context.Context, rec *domain.IdempotencyRecord) {
+	if err := s.repo.CompleteIdempotencyRecord(ctx, rec); err != nil {
+		s.log.Error(ctx, "Failed to store idempotent response", "principal", rec.Principal, "key", rec.Key, "error", err)
+	}
+}
+
+// Release frees a reserved key without storing a response, so the request
+// can be retried with it
+func (s *IdempotencyService) Release(ctx context.Context, rec *domain.IdempotencyRecord) {
+	if err := s.repo.ReleaseIdempotencyKey(ctx, rec.Principal, rec.Key); err != nil {
+		s.log.Error(ctx, "Failed to release idempotency key", "principal", rec.Principal, "key", rec.Key, "error", err)
+	}
+}
+
+// Run removes expired records until ctx is cancelled
+func (s *IdempotencyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := s.repo.DeleteExpiredIdempotencyRecords(ctx, time.Now().UTC())
+		if err != nil {
+			s.log.Error(ctx, "Failed to purge idempotency records", "error", err)
+			continue
+		}
+		if deleted > 0 {
+			s.log.Info(ctx, "Purged idempotency records", "count", deleted)
+		}
+	}
+}
+
+// hashRequest identifies a request by its method, path and canonical body
+func hashRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(canonicalBody(body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalBody compacts a JSON body and sorts its object keys, so a retry
+// that only lays out the same document differently still matches. Other
+// bodies are used as they are.
+func canonicalBody(body []byte) []byte {
+	if !json.Valid(body) {
+		return body
+	}
+
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return body
+	}
+
+	canonical, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return canonical
+}
+
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -4,43 +4,83 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
 	"sync"
+	"time"
 
 	"github.com/Avyukth/lift-simulation/internal/application/events"
 	"github.com/Avyukth/lift-simulation/internal/application/ports"
 	"github.com/Avyukth/lift-simulation/internal/domain"
 	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
 	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/google/uuid"
 )
 
+// maxLiftsAssignedPerFloor is the number of lifts that may be parked at a
+// single floor at the same time.
+const maxLiftsAssignedPerFloor = 2
+
 // LiftService handles the business logic for lift operations
 type LiftService struct {
 	repo     ports.LiftOperations
+	calls    *CallService
 	eventBus events.EventBus
 	wsHub    *ws.WebSocketHub
-	mu       sync.RWMutex
+	metrics  ports.DomainMetrics
 	log      *logger.Logger
+
+	holdsMu sync.Mutex
+	holds   map[string]time.Time // lift ID to the time its doors are released
+
+	movingMu sync.Mutex
+	moving   map[string]bool // lifts a moveLift call is under way for
+
+	dispatch   DispatchConfig
+	dispatchMu sync.Mutex                 // one dispatch pass at a time
+	traces     map[string]context.Context // request contexts of waiting calls
+	wake       chan struct{}
+	stopped    bool           // set by StopServing; no new calls are served
+	serving    sync.WaitGroup // serveCall goroutines
+
+	reservedMu sync.Mutex
+	reserved   map[string]bool // lifts chosen for a hall call but not yet departed
+
+	drainingMu sync.Mutex
+	draining   map[string]bool // lifts being decommissioned, which take no new work
 }
 
 type LiftRequestedHandler struct {
 	service *LiftService
 }
 
-func (h *LiftRequestedHandler) Handle(event domain.Event) {
-	if liftRequestedEvent, ok := event.(domain.LiftRequestedEvent); ok {
-		h.service.processLiftRequest(context.Background(), liftRequestedEvent.FloorNumber, liftRequestedEvent.Direction)
+func (h *LiftRequestedHandler) Handle(ctx context.Context, env domain.Envelope) {
+	if liftRequestedEvent, ok := env.Event.(domain.LiftRequestedEvent); ok {
+		h.service.processLiftRequest(ctx, liftRequestedEvent.CallID)
 	}
 }
 
 // NewLiftService creates a new instance of LiftService
-func NewLiftService(repo ports.LiftOperations, eventBus events.EventBus, wsHub *ws.WebSocketHub, log *logger.Logger) *LiftService {
+func NewLiftService(repo ports.LiftOperations, calls *CallService, eventBus events.EventBus, wsHub *ws.WebSocketHub, metrics ports.DomainMetrics, cfg DispatchConfig, log *logger.Logger) *LiftService {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = 2 * time.Minute
+	}
+
 	service := &LiftService{
 		repo:     repo,
+		calls:    calls,
 		eventBus: eventBus,
 		wsHub:    wsHub,
-		mu:       sync.RWMutex{},
+		metrics:  metrics,
 		log:      log,
+		holds:    make(map[string]time.Time),
+		moving:   make(map[string]bool),
+		dispatch: cfg,
+		traces:   make(map[string]context.Context),
+		wake:     make(chan struct{}, 1),
+		reserved: make(map[string]bool),
+		draining: make(map[string]bool),
 	}
 
 	// Subscribe to LiftRequested events
@@ -49,58 +89,267 @@ func NewLiftService(repo ports.LiftOperations, eventBus events.EventBus, wsHub *
 	return service
 }
 
-// MoveLift moves a lift to a target floor
-func (s *LiftService) MoveLift(ctx context.Context, liftID string, targetFloor int) error {
-	s.log.Info(ctx, "Moving lift", "lift_id", liftID, "target_floor", targetFloor)
+// checkPassengers reports whether passengers can board the lift
+func checkPassengers(lift *domain.Lift, passengers int) error {
+	if passengers < 0 {
+		return fmt.Errorf("%w: %d", domain.ErrInvalidPassengers, passengers)
+	}
+	if lift.Passengers+passengers > lift.Capacity {
+		return fmt.Errorf("%w: %d on board, %d boarding, capacity %d", domain.ErrLiftFull, lift.Passengers, passengers, lift.Capacity)
+	}
+	return nil
+}
+
+// moveControl lets the caller of moveLift follow a move and stop it early
+type moveControl struct {
+	stop    <-chan struct{} // closed to stop the lift at the next floor
+	reached func(floor int) // called as the lift reaches each floor
+}
+
+func (c *moveControl) stopped() bool {
+	if c == nil {
+		return false
+	}
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *moveControl) floorReached(floor int) {
+	if c != nil && c.reached != nil {
+		c.reached(floor)
+	}
+}
+
+// HoldDoors keeps the doors of a stopped lift open for d. The lift cannot
+// depart, and is not dispatched to hall calls, until the hold ends. It
+// returns the time the doors are released.
+func (s *LiftService) HoldDoors(ctx context.Context, liftID string, d time.Duration) (time.Time, error) {
+	if d <= 0 || d > domain.MaxDoorHold {
+		return time.Time{}, fmt.Errorf("%w: %s, must be at most %s", domain.ErrInvalidDoorHold, d, domain.MaxDoorHold)
+	}
+
+	lift, err := s.repo.GetLift(ctx, liftID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get lift: %w", err)
+	}
+	switch lift.Status {
+	case domain.OutOfService:
+		return time.Time{}, domain.ErrLiftOutOfService
+	case domain.Occupied:
+		return time.Time{}, domain.ErrLiftBusy
+	}
+
+	until := time.Now().Add(d)
+	s.holdsMu.Lock()
+	s.holds[liftID] = until
+	s.holdsMu.Unlock()
+
+	s.log.Info(ctx, "Holding lift doors", "lift_id", liftID, "floor", lift.CurrentFloor, "until", until)
+	s.eventBus.Publish(ctx, domain.LiftDoorsOpenedEvent{LiftID: liftID, FloorNumber: lift.CurrentFloor})
+	return until, nil
+}
+
+// doorsHeld reports whether the doors of the lift are being held open
+func (s *LiftService) doorsHeld(liftID string) bool {
+	s.holdsMu.Lock()
+	defer s.holdsMu.Unlock()
+
+	until, ok := s.holds[liftID]
+	if ok && !time.Now().Before(until) {
+		delete(s.holds, liftID)
+		return false
+	}
+	return ok
+}
+
+// moveLift moves a lift to a target floor and records the trip with the
+// given trigger. A move stopped through ctl ends at the next floor the lift
+// reaches. It returns the floor the lift stopped at.
+func (s *LiftService) moveLift(ctx context.Context, liftID string, targetFloor, passengers int, trigger domain.TripTrigger, ctl *moveControl) (int, error) {
+	s.log.Info(ctx, "Moving lift", "lift_id", liftID, "target_floor", targetFloor, "trigger", trigger)
+
+	// The lift is read, checked and saved as Occupied in separate steps, so
+	// a second move could pass the same checks before the first one departs
+	if !s.claim(liftID) {
+		return 0, fmt.Errorf("failed to move lift: %w", domain.ErrLiftBusy)
+	}
+	defer s.unclaim(liftID)
+
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get system information: %w", err)
+	}
+	if targetFloor < 0 || targetFloor >= system.TotalFloors {
+		return 0, fmt.Errorf("%w: %d", domain.ErrInvalidFloor, targetFloor)
+	}
 
 	lift, err := s.repo.GetLift(ctx, liftID)
 	if err != nil {
 		s.log.Error(ctx, "Failed to retrieve lift", "lift_id", liftID, "error", err)
-		return fmt.Errorf("failed to retrieve lift: %w", err)
+		return 0, fmt.Errorf("failed to retrieve lift: %w", err)
 	}
 
-	// Check if the lift is already on the target floor
-	if lift.CurrentFloor == targetFloor {
-		s.log.Error(ctx, "Lift is already on the target floor", "lift_id", liftID, "current_floor", lift.CurrentFloor)
-		return fmt.Errorf("lift is already on floor %d", targetFloor)
+	if s.doorsHeld(liftID) {
+		return 0, fmt.Errorf("failed to move lift: %w", domain.ErrLiftDoorsHeld)
 	}
 
+	// Depart checks that the lift can make the trip before anything is
+	// changed in the repository
+	if err := checkPassengers(lift, passengers); err != nil {
+		return 0, fmt.Errorf("failed to move lift: %w", err)
+	}
+	originFloor := lift.CurrentFloor
+	startedAt := time.Now()
+	if err := lift.Depart(targetFloor); err != nil {
+		s.log.Error(ctx, "Failed to move lift", "lift_id", liftID, "target_floor", targetFloor, "error", err)
+		return 0, fmt.Errorf("failed to move lift: %w", err)
+	}
+	lift.Passengers += passengers
+
 	// Unassign the lift from its current floor
-	currentFloor, err := s.repo.GetFloorByNumber(ctx, lift.CurrentFloor)
+	currentFloor, err := s.repo.GetFloorByNumber(ctx, originFloor)
 	if err != nil {
-		s.log.Error(ctx, "Failed to get current floor", "floor_number", lift.CurrentFloor, "error", err)
-		return fmt.Errorf("failed to get current floor: %w", err)
+		s.log.Error(ctx, "Failed to get current floor", "floor_number", originFloor, "error", err)
+		return 0, fmt.Errorf("failed to get current floor: %w", err)
 	}
 	err = s.UnassignLiftFromFloor(ctx, liftID, currentFloor.ID)
 	if err != nil {
 		s.log.Error(ctx, "Failed to unassign lift from current floor", "lift_id", liftID, "floor_id", currentFloor.ID, "error", err)
-		return fmt.Errorf("failed to unassign lift from current floor: %w", err)
+		return 0, fmt.Errorf("failed to unassign lift from current floor: %w", err)
 	}
 
-	if err := lift.MoveTo(targetFloor); err != nil {
-		s.log.Error(ctx, "Failed to move lift", "lift_id", liftID, "target_floor", targetFloor, "error", err)
-		return fmt.Errorf("failed to move lift: %w", err)
+	// Persist the Occupied status before travelling so the dispatcher does not
+	// hand the same lift another request while it is moving.
+	if err := s.repo.UpdateLift(ctx, lift); err != nil {
+		s.log.Error(ctx, "Failed to update lift before move", "lift_id", liftID, "error", err)
+		return 0, fmt.Errorf("failed to update lift before move: %w", err)
+	}
+
+	s.eventBus.Publish(ctx, domain.LiftDoorsClosedEvent{LiftID: liftID, FloorNumber: originFloor})
+	s.eventBus.Publish(ctx, domain.LiftDepartedEvent{
+		LiftID:      liftID,
+		FromFloor:   originFloor,
+		TargetFloor: targetFloor,
+		Direction:   lift.Direction,
+	})
+	s.broadcastLift(lift, domain.LiftDeparted)
+
+	for lift.CurrentFloor != lift.TargetFloor {
+		time.Sleep(domain.FloorTravelTime)
+
+		reached := lift.Step()
+		if err := s.repo.UpdateLift(ctx, lift); err != nil {
+			s.log.Warn(ctx, "Failed to update lift position", "lift_id", liftID, "floor", reached, "error", err)
+		}
+		ctl.floorReached(reached)
+		if reached != targetFloor {
+			s.eventBus.Publish(ctx, domain.LiftPassedFloorEvent{
+				LiftID:      liftID,
+				FloorNumber: reached,
+				TargetFloor: targetFloor,
+				Direction:   lift.Direction,
+			})
+			s.broadcastLift(lift, domain.LiftPassedFloor)
+
+			if ctl.stopped() {
+				s.log.Info(ctx, "Stopping lift before its target", "lift_id", liftID, "floor", reached, "target_floor", targetFloor)
+				lift.TargetFloor = reached
+			}
+		}
 	}
 
+	lift.Arrive()
+	stopFloor := lift.CurrentFloor
+	// The passengers who boarded for this move get off where the lift stops
+	lift.Passengers -= passengers
+	if err := s.repo.UpdateLift(ctx, lift); err != nil {
+		s.log.Error(ctx, "Failed to update lift after move", "lift_id", liftID, "error", err)
+		return 0, fmt.Errorf("failed to update lift after move: %w", err)
+	}
+
+	s.eventBus.Publish(ctx, domain.LiftArrivedEvent{LiftID: liftID, FloorNumber: stopFloor})
+	s.eventBus.Publish(ctx, domain.LiftDoorsOpenedEvent{LiftID: liftID, FloorNumber: stopFloor})
+	s.broadcastLift(lift, domain.LiftArrived)
+	s.liftFreed()
+
+	s.recordTrip(ctx, &domain.Trip{
+		ID:               uuid.New().String(),
+		LiftID:           liftID,
+		OriginFloor:      originFloor,
+		DestinationFloor: stopFloor,
+		StartedAt:        startedAt,
+		EndedAt:          lift.LastMoveTime,
+		Trigger:          trigger,
+		Passengers:       passengers,
+	})
+
 	// Assign the lift to the target floor
-	floor, err := s.repo.GetFloorByNumber(ctx, targetFloor)
+	floor, err := s.repo.GetFloorByNumber(ctx, stopFloor)
 	if err != nil {
-		s.log.Error(ctx, "Failed to get target floor", "floor_number", targetFloor, "error", err)
-		return fmt.Errorf("failed to get target floor: %w", err)
+		s.log.Error(ctx, "Failed to get target floor", "floor_number", stopFloor, "error", err)
+		return 0, fmt.Errorf("failed to get target floor: %w", err)
 	}
 	err = s.AssignLiftToFloor(ctx, liftID, floor.ID, floor.Number)
 	if err != nil {
 		s.log.Error(ctx, "Failed to assign lift to target floor", "lift_id", liftID, "floor_id", floor.ID, "error", err)
-		return fmt.Errorf("failed to assign lift to target floor: %w", err)
+		return 0, fmt.Errorf("failed to assign lift to target floor: %w", err)
 	}
 
-	if err := s.repo.UpdateLift(ctx, lift); err != nil {
-		s.log.Error(ctx, "Failed to update lift after move", "lift_id", liftID, "error", err)
-		return fmt.Errorf("failed to update lift after move: %w", err)
+	s.log.Info(ctx, "Successfully moved lift", "lift_id", liftID, "target_floor", targetFloor, "stop_floor", stopFloor)
+	return stopFloor, nil
+}
+
+// claim marks the lift as being moved. It returns false if another move
+// already has it.
+func (s *LiftService) claim(liftID string) bool {
+	s.movingMu.Lock()
+	defer s.movingMu.Unlock()
+
+	if s.moving[liftID] {
+		return false
 	}
+	s.moving[liftID] = true
+	return true
+}
 
-	s.log.Info(ctx, "Successfully moved lift", "lift_id", liftID, "target_floor", targetFloor)
-	return nil
+// unclaim ends a move started with claim
+func (s *LiftService) unclaim(liftID string) {
+	s.movingMu.Lock()
+	defer s.movingMu.Unlock()
+	delete(s.moving, liftID)
+}
+
+func (s *LiftService) isMoving(liftID string) bool {
+	s.movingMu.Lock()
+	defer s.movingMu.Unlock()
+	return s.moving[liftID]
+}
+
+// broadcastLift pushes the state of the lift to its WebSocket subscribers
+func (s *LiftService) broadcastLift(lift *domain.Lift, cause domain.EventType) {
+	s.wsHub.BroadcastUpdate(ws.NewLiftUpdate(lift, cause))
+}
+
+// recordTrip stores and counts a completed trip. A failure only loses history,
+// so it is logged rather than failing the move.
+func (s *LiftService) recordTrip(ctx context.Context, trip *domain.Trip) {
+	if err := s.repo.SaveTrip(ctx, trip); err != nil {
+		s.log.Error(ctx, "Failed to record trip", "lift_id", trip.LiftID, "error", err)
+	}
+	s.metrics.TripRecorded(trip)
+}
+
+// ListTrips retrieves the trip history matching the filter
+func (s *LiftService) ListTrips(ctx context.Context, filter domain.TripFilter) ([]*domain.Trip, int, error) {
+	trips, total, err := s.repo.ListTrips(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list trips: %w", err)
+	}
+	return trips, total, nil
 }
 
 // GetLiftStatus retrieves the current status of a lift
@@ -141,16 +390,25 @@ func abs(x int) int {
 
 // SetLiftStatus sets the status of a lift
 func (s *LiftService) SetLiftStatus(ctx context.Context, liftID string, status domain.LiftStatus) error {
+	if status < domain.Available || status > domain.OutOfService {
+		return fmt.Errorf("%w: %d", domain.ErrInvalidStatus, status)
+	}
+
 	lift, err := s.repo.GetLift(ctx, liftID)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to get lift: %w", err)
 	}
 
 	lift.SetStatus(status)
 	if err := s.repo.UpdateLift(ctx, lift); err != nil {
-		return err
+		return fmt.Errorf("failed to update lift: %w", err)
 	}
 
+	s.eventBus.Publish(ctx, domain.LiftStatusChangedEvent{LiftID: liftID, Status: status})
+	s.broadcastLift(lift, domain.LiftStatusChanged)
+	if status == domain.Available {
+		s.liftFreed()
+	}
 	return nil
 }
 
@@ -158,25 +416,14 @@ func (s *LiftService) SetLiftStatus(ctx context.Context, liftID string, status d
 func (s *LiftService) AssignLiftToFloor(ctx context.Context, liftID, floorID string, floorNum int) error {
 	s.log.Info(ctx, "Attempting to assign lift to floor", "lift_id", liftID, "floor_id", floorID, "floor_num", floorNum)
 
-	// Use a mutex to prevent race conditions
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Check if there are already two lifts assigned to this floor
-	assignedLifts, err := s.repo.GetAssignedLiftsForFloor(ctx, floorID)
-	if err != nil {
-		s.log.Error(ctx, "Failed to get assigned lifts", "error", err, "floor_id", floorID)
-		return fmt.Errorf("failed to get assigned lifts: %w", err)
-	}
-
-	if len(assignedLifts) >= 2 {
-		s.log.Warn(ctx, "Floor already has maximum lifts assigned", "floor_id", floorID)
-		return fmt.Errorf("lift capacity exceeded")
-	}
-
-	// Assign the lift to the floor
-	err = s.repo.AssignLiftToFloor(ctx, liftID, floorID, floorNum)
+	// The repository checks the limit and inserts atomically, so concurrent
+	// requests (or other API replicas) cannot overfill a floor.
+	err := s.repo.AssignLiftToFloorWithLimit(ctx, liftID, floorID, floorNum, maxLiftsAssignedPerFloor)
 	if err != nil {
+		if errors.Is(err, domain.ErrFloorAtCapacity) {
+			s.log.Warn(ctx, "Floor already has maximum lifts assigned", "floor_id", floorID)
+			return fmt.Errorf("lift capacity exceeded: %w", err)
+		}
 		s.log.Error(ctx, "Failed to assign lift to floor", "error", err, "lift_id", liftID, "floor_id", floorID)
 		return fmt.Errorf("failed to assign lift to floor: %w", err)
 	}
@@ -213,7 +460,7 @@ func (s *LiftService) findAvailableLift(ctx context.Context, floor *domain.Floor
 	minDistance := int(^uint(0) >> 1)
 
 	for _, lift := range lifts {
-		if lift.IsAvailable() {
+		if lift.IsAvailable() && !s.isMoving(lift.ID) && !s.doorsHeld(lift.ID) && !s.isReserved(lift.ID) && !s.isDraining(lift.ID) {
 			if lift.CurrentFloor == 0 {
 				groundFloorLifts = append(groundFloorLifts, lift)
 			} else {
@@ -242,79 +489,27 @@ func (s *LiftService) findAvailableLift(ctx context.Context, floor *domain.Floor
 		return closestLift, nil
 	}
 
-	return nil, fmt.Errorf("no available lift found")
-}
-
-func (s *LiftService) processLiftRequest(ctx context.Context, floorNum int, direction domain.Direction) {
-	system, err := s.repo.GetSystem(ctx)
-	if err != nil {
-		s.log.Error(ctx, "Failed to get system information", "error", err)
-		return
-	}
-
-	maxLiftsPerFloor := max(int(math.Ceil(float64(system.TotalLifts)*0.1)), 2)
-	floor, err := s.repo.GetFloorByNumber(ctx, floorNum)
-
-	if err != nil {
-		s.log.Error(ctx, "Failed to get floor information: %w", err, "floor_num", floorNum)
-		return
-	}
-
-	assignedLifts, err := s.repo.GetAssignedLiftsForFloor(ctx, floor.ID)
-	if err != nil {
-		s.log.Error(ctx, "Failed to get assigned lifts for floor", "floor", floorNum, "error", err)
-		return
-	}
-
-	if len(assignedLifts) >= maxLiftsPerFloor {
-		s.log.Warn(ctx, "Floor has reached maximum lift capacity", "floor", floorNum, "max_capacity", maxLiftsPerFloor)
-		// Publish an event or notify the requester that the floor is at capacity
-		s.eventBus.Publish(domain.FloorAtCapacityEvent{FloorNumber: floorNum})
-		return
-	}
-
-	lift, err := s.findAvailableLift(ctx, floor)
-	if err != nil {
-		s.log.Error(ctx, "Failed to find available lift", "error", err)
-		return
-	}
-
-	s.log.Info(ctx, "Lift is Moving", "lift_id", lift.ID, "target_floor", floorNum, "direction", direction)
-
-	err = s.MoveLift(ctx, lift.ID, floorNum)
-	if err != nil {
-		s.log.Error(ctx, "Failed to move lift", "lift_id", lift.ID, "target_floor", floorNum, "error", err)
-		return
-	}
-
-	s.log.Info(ctx, "Lift arrived at requested floor", "lift_id", lift.ID, "floor", floorNum)
-
-	// Publish a LiftArrived event
-	s.eventBus.Publish(domain.LiftArrivedEvent{
-		LiftID:      lift.ID,
-		FloorNumber: floorNum,
-	})
+	return nil, domain.ErrNoLiftAvailable
 }
 
 func (s *LiftService) ResetLift(ctx context.Context, liftID string) error {
 	lift, err := s.repo.GetLift(ctx, liftID)
 	if err != nil {
 		s.log.Error(ctx, "Failed to reset lift", "lift", liftID, "error", err)
-		if errors.Is(err, domain.ErrLiftNotFound) {
-			return domain.ErrLiftNotFound
-		}
 		return fmt.Errorf("failed to get lift: %w", err)
 	}
 
 	// Reset lift properties
-	lift.CurrentFloor = 0
-	lift.Status = domain.LiftStatus(domain.Idle)
+	lift.Reset()
 
 	err = s.repo.UpdateLift(ctx, lift)
 	if err != nil {
 		return fmt.Errorf("failed to update lift: %w", err)
 	}
 
+	s.eventBus.Publish(ctx, domain.LiftResetEvent{LiftID: liftID})
+	s.broadcastLift(lift, domain.LiftReset)
+	s.liftFreed()
 	return nil
 }
 
@@ -327,15 +522,19 @@ func (s *LiftService) ResetLifts(ctx context.Context) error {
 
 	// Reset each lift
 	for _, lift := range lifts {
-		// Create a new lift with the same ID and name, but reset all other properties
-		resetLift := domain.NewLift(lift.ID, lift.Name)
+		// Reset the lift's state in place, keeping its name and capacity
+		lift.Reset()
 
 		// Update the lift in the repository
-		err = s.repo.UpdateLift(ctx, resetLift)
+		err = s.repo.UpdateLift(ctx, lift)
 		if err != nil {
 			return fmt.Errorf("failed to update lift %s: %w", lift.ID, err)
 		}
+
+		s.eventBus.Publish(ctx, domain.LiftResetEvent{LiftID: lift.ID})
+		s.broadcastLift(lift, domain.LiftReset)
 	}
 
+	s.liftFreed()
 	return nil
 }
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// moveRetention is how long a finished move can still be looked up
+const moveRetention = time.Hour
+
+// MoveService carries out lift moves in the background so that callers do
+// not wait for the lift to travel. The moves of one lift run one after the
+// other in the order they were submitted.
+type MoveService struct {
+	lifts    *LiftService
+	repo     ports.LiftOperations
+	eventBus events.EventBus
+	log      *logger.Logger
+
+	mu     sync.Mutex
+	moves  map[string]*moveJob
+	queues map[string][]*moveJob // unfinished moves by lift, the running one first
+}
+
+type moveJob struct {
+	move     domain.Move
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMoveService creates a new instance of MoveService
+func NewMoveService(lifts *LiftService, repo ports.LiftOperations, eventBus events.EventBus, log *logger.Logger) *MoveService {
+	return &MoveService{
+		lifts:    lifts,
+		repo:     repo,
+		eventBus: eventBus,
+		log:      log,
+		moves:    make(map[string]*moveJob),
+		queues:   make(map[string][]*moveJob),
+	}
+}
+
+// Submit checks that the lift can be sent to the floor and queues the move
+// behind the lift's earlier moves. It returns without waiting for the move.
+func (s *MoveService) Submit(ctx context.Context, liftID string, targetFloor, passengers int, trigger domain.TripTrigger) (*domain.Move, error) {
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system information: %w", err)
+	}
+	if targetFloor < 0 || targetFloor >= system.TotalFloors {
+		return nil, fmt.Errorf("%w: %d", domain.ErrInvalidFloor, targetFloor)
+	}
+
+	lift, err := s.repo.GetLift(ctx, liftID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lift: %w", err)
+	}
+	if lift.Status == domain.OutOfService {
+		return nil, domain.ErrLiftOutOfService
+	}
+	if err := checkPassengers(lift, passengers); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Checked under s.mu, so a lift being drained either sees this move in
+	// its queue or the move is refused
+	if s.lifts.isDraining(liftID) {
+		return nil, domain.ErrLiftDecommissioning
+	}
+	// A lift chosen for a hall call is about to leave for it
+	if s.lifts.isReserved(liftID) {
+		return nil, domain.ErrLiftBusy
+	}
+
+	s.prune(now)
+
+	job := &moveJob{
+		move: domain.Move{
+			ID:           uuid.New().String(),
+			LiftID:       liftID,
+			TargetFloor:  targetFloor,
+			Trigger:      trigger,
+			Status:       domain.MoveQueued,
+			Passengers:   passengers,
+			CurrentFloor: lift.CurrentFloor,
+			CreatedAt:    now,
+		},
+		stop: make(chan struct{}),
+	}
+
+	queue := s.queues[liftID]
+	s.moves[job.move.ID] = job
+	s.queues[liftID] = append(queue, job)
+
+	if len(queue) == 0 {
+		s.schedule(liftID, lift.CurrentFloor, now)
+		go s.run(context.WithoutCancel(ctx), liftID)
+	} else {
+		s.schedule(liftID, queue[0].move.CurrentFloor, now)
+	}
+
+	s.log.Info(ctx, "Move queued", "move_id", job.move.ID, "lift_id", liftID, "target_floor", targetFloor, "queue_length", len(queue)+1)
+	move := job.move
+	return &move, nil
+}
+
+// GetMove returns a move by ID
+func (s *MoveService) GetMove(ctx context.Context, id string) (*domain.Move, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.moves[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", domain.ErrMoveNotFound, id)
+	}
+	move := job.move
+	return &move, nil
+}
+
+// ListMoves returns the moves matching the filter, newest first
+func (s *MoveService) ListMoves(ctx context.Context, filter domain.MoveFilter) ([]*domain.Move, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(time.Now())
+
+	moves := make([]*domain.Move, 0, len(s.moves))
+	for _, job := range s.moves {
+		if filter.LiftID != "" && job.move.LiftID != filter.LiftID {
+			continue
+		}
+		if filter.Status != "" && job.move.Status != filter.Status {
+			continue
+		}
+		move := job.move
+		moves = append(moves, &move)
+	}
+	slices.SortFunc(moves, func(a, b *domain.Move) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return moves, nil
+}
+
+// CancelMove cancels a move. A queued move is dropped at once; a lift that is
+// already travelling stops at the next floor it reaches, after which the move
+// is reported as cancelled.
+func (s *MoveService) CancelMove(ctx context.Context, id string) (*domain.Move, error) {
+	s.mu.Lock()
+
+	job, ok := s.moves[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", domain.ErrMoveNotFound, id)
+	}
+
+	switch job.move.Status {
+	case domain.MoveQueued:
+		queue := s.queues[job.move.LiftID]
+		s.queues[job.move.LiftID] = slices.DeleteFunc(queue, func(j *moveJob) bool { return j == job })
+		if rest := s.queues[job.move.LiftID]; len(rest) > 0 {
+			s.schedule(job.move.LiftID, rest[0].move.CurrentFloor, time.Now())
+		}
+		event := s.finish(job, job.move.CurrentFloor, nil)
+		move := job.move
+		s.mu.Unlock()
+
+		s.log.Info(ctx, "Queued move cancelled", "move_id", id, "lift_id", move.LiftID)
+		s.eventBus.Publish(ctx, event)
+		return &move, nil
+
+	case domain.MoveInProgress:
+		job.stopOnce.Do(func() { close(job.stop) })
+		move := job.move
+		s.mu.Unlock()
+
+		s.log.Info(ctx, "Stopping move at the next floor", "move_id", id, "lift_id", move.LiftID)
+		return &move, nil
+
+	default:
+		s.mu.Unlock()
+		return nil, fmt.Errorf("move %s is %s: %w", id, job.move.Status, domain.ErrMoveFinished)
+	}
+}
+
+// queued reports whether the lift has moves that have not finished
+func (s *MoveService) queued(liftID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queues[liftID]) > 0
+}
+
+// forgetLifts cancels the queued moves of the lifts not in keep and stops
+// their running moves at the next floor, once a snapshot import has removed
+// the lifts
+func (s *MoveService) forgetLifts(ctx context.Context, keep map[string]bool) {
+	var events []domain.MoveFinishedEvent
+
+	s.mu.Lock()
+	for liftID, queue := range s.queues {
+		if keep[liftID] {
+			continue
+		}
+		for _, job := range queue {
+			switch job.move.Status {
+			case domain.MoveQueued:
+				events = append(events, s.finish(job, job.move.CurrentFloor, nil))
+			case domain.MoveInProgress:
+				job.stopOnce.Do(func() { close(job.stop) })
+			}
+		}
+		// The running move is taken off the queue by run once it ends
+		s.queues[liftID] = slices.DeleteFunc(queue, func(j *moveJob) bool { return j.move.Status != domain.MoveInProgress })
+	}
+	s.mu.Unlock()
+
+	for _, event := range events {
+		s.log.Info(ctx, "Queued move cancelled", "move_id", event.MoveID, "lift_id", event.LiftID, "reason", "lift removed")
+		s.eventBus.Publish(ctx, event)
+	}
+}
+
+// run carries out the queued moves of a lift until its queue is empty
+func (s *MoveService) run(ctx context.Context, liftID string) {
+	for {
+		s.mu.Lock()
+		queue := s.queues[liftID]
+		if len(queue) == 0 {
+			delete(s.queues, liftID)
+			s.mu.Unlock()
+			return
+		}
+
+		job := queue[0]
+		now := time.Now()
+		job.move.Status = domain.MoveInProgress
+		job.move.StartedAt = &now
+		s.schedule(liftID, job.move.CurrentFloor, now)
+		move := job.move
+		s.mu.Unlock()
+
+		stopFloor, err := s.lifts.moveLift(ctx, liftID, move.TargetFloor, move.Passengers, move.Trigger, &moveControl{
+			stop:    job.stop,
+			reached: func(floor int) { s.progress(job, floor) },
+		})
+		if err != nil {
+			s.log.Error(ctx, "Move failed", "move_id", move.ID, "lift_id", liftID, "error", err)
+		}
+
+		s.mu.Lock()
+		if err != nil {
+			stopFloor = job.move.CurrentFloor
+		}
+		s.queues[liftID] = slices.DeleteFunc(s.queues[liftID], func(j *moveJob) bool { return j == job })
+		event := s.finish(job, stopFloor, err)
+		for _, next := range s.queues[liftID] {
+			next.move.CurrentFloor = stopFloor
+		}
+		s.schedule(liftID, stopFloor, time.Now())
+		s.mu.Unlock()
+
+		s.eventBus.Publish(ctx, event)
+	}
+}
+
+// progress records the floor a running move has reached
+func (s *MoveService) progress(job *moveJob, floor int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.move.CurrentFloor = floor
+	s.schedule(job.move.LiftID, floor, time.Now())
+}
+
+// finish puts a move in its final state and returns the event announcing it.
+// The caller holds s.mu.
+func (s *MoveService) finish(job *moveJob, stopFloor int, err error) domain.MoveFinishedEvent {
+	now := time.Now()
+	move := &job.move
+
+	switch {
+	case err != nil:
+		move.Status = domain.MoveFailed
+		move.Error = err.Error()
+	case move.Status == domain.MoveQueued || stopFloor != move.TargetFloor:
+		move.Status = domain.MoveCancelled
+	default:
+		move.Status = domain.MoveCompleted
+	}
+	move.CurrentFloor = stopFloor
+	move.ETA = nil
+	move.FinishedAt = &now
+
+	return domain.MoveFinishedEvent{
+		MoveID:      move.ID,
+		LiftID:      move.LiftID,
+		TargetFloor: move.TargetFloor,
+		FinalFloor:  stopFloor,
+		Status:      move.Status,
+		Error:       move.Error,
+	}
+}
+
+// schedule recomputes the ETA of every unfinished move of the lift, given
+// that the lift is at floor and free to go at start. The caller holds s.mu.
+func (s *MoveService) schedule(liftID string, floor int, start time.Time) {
+	for _, job := range s.queues[liftID] {
+		start = start.Add(time.Duration(abs(job.move.TargetFloor-floor)) * domain.FloorTravelTime)
+		eta := start
+		job.move.ETA = &eta
+		floor = job.move.TargetFloor
+	}
+}
+
+// prune forgets moves that finished longer than moveRetention ago. The
+// caller holds s.mu.
+func (s *MoveService) prune(now time.Time) {
+	for id, job := range s.moves {
+		if job.move.FinishedAt != nil && now.Sub(*job.move.FinishedAt) > moveRetention {
+			delete(s.moves, id)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/google/uuid"
+)
+
+// drainPollInterval is how often a lift being decommissioned is checked for
+// work it still has to finish
+const drainPollInterval = time.Second
+
+// ReconfigureSystem adds floors and adds or removes lifts while the system is
+// running. Lifts are removed gracefully: the lifts chosen stop taking new
+// work and are removed once their assigned calls and queued moves are done,
+// idle lifts being chosen first. Floors can only be added.
+func (s *SystemService) ReconfigureSystem(ctx context.Context, change domain.SystemChange) (*domain.Reconfiguration, error) {
+	if change.Floors == nil && change.Lifts == nil {
+		return nil, fmt.Errorf("%w: nothing to change, set floors or lifts", domain.ErrInvalidArgument)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system configuration: %w", err)
+	}
+
+	lifts, err := s.repo.GetAllLifts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lifts: %w", err)
+	}
+	active := s.activeLifts(lifts)
+
+	floors := system.TotalFloors
+	if change.Floors != nil {
+		if *change.Floors < system.TotalFloors {
+			return nil, fmt.Errorf("%w: floors can only be added while the system is running, it has %d", domain.ErrInvalidArgument, system.TotalFloors)
+		}
+		floors = *change.Floors
+	}
+
+	wanted := len(active)
+	if change.Lifts != nil {
+		if *change.Lifts < 1 {
+			return nil, fmt.Errorf("%w: number of lifts must be at least 1", domain.ErrInvalidArgument)
+		}
+		wanted = *change.Lifts
+	}
+
+	s.log.Info(ctx, "Reconfiguring system",
+		"total_floors", system.TotalFloors,
+		"floors", floors,
+		"active_lifts", len(active),
+		"lifts", wanted)
+
+	result := &domain.Reconfiguration{
+		AddedLifts:    []*domain.Lift{},
+		DrainingLifts: []string{},
+	}
+
+	floorsAdded := floors > system.TotalFloors
+	if floorsAdded {
+		for n := system.TotalFloors; n < floors; n++ {
+			floor := domain.NewFloor(uuid.New().String(), n)
+			if err := s.repo.SaveFloor(ctx, floor, system.ID); err != nil {
+				return nil, fmt.Errorf("failed to save floor %d: %w", n, err)
+			}
+			s.log.Debug(ctx, "Floor created", "floor_id", floor.ID, "floor_number", n)
+		}
+
+		system.TotalFloors = floors
+		if err := s.repo.UpdateSystem(ctx, system); err != nil {
+			return nil, fmt.Errorf("failed to update system configuration: %w", err)
+		}
+	}
+
+	for i := len(active); i < wanted; i++ {
+		lift, err := s.addLift(ctx, system, nextLiftName(lifts), domain.DefaultLiftCapacity)
+		if err != nil {
+			return nil, err
+		}
+		lifts = append(lifts, lift)
+		result.AddedLifts = append(result.AddedLifts, lift)
+	}
+
+	if wanted < len(active) {
+		for i, lift := range s.drainOrder(active)[:len(active)-wanted] {
+			if err := s.decommission(ctx, lift, len(active)-i); err != nil {
+				return nil, err
+			}
+			result.DrainingLifts = append(result.DrainingLifts, lift.ID)
+		}
+	}
+
+	if floorsAdded || len(result.AddedLifts) > 0 {
+		s.reconfigured(ctx, system)
+	}
+
+	result.TotalFloors = system.TotalFloors
+	result.TotalLifts = system.TotalLifts
+	return result, nil
+}
+
+// AddLift adds a lift to the running system, parked at the ground floor. An
+// empty name picks the next free name of the form Ln, and a zero capacity
+// uses the default.
+func (s *SystemService) AddLift(ctx context.Context, name string, capacity int) (*domain.Lift, error) {
+	name = strings.TrimSpace(name)
+	if capacity < 0 {
+		return nil, fmt.Errorf("%w: %d, must be at least 1", domain.ErrInvalidCapacity, capacity)
+	}
+	if capacity == 0 {
+		capacity = domain.DefaultLiftCapacity
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system configuration: %w", err)
+	}
+
+	lifts, err := s.repo.GetAllLifts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lifts: %w", err)
+	}
+
+	if name == "" {
+		name = nextLiftName(lifts)
+	} else if liftNameTaken(lifts, name, "") {
+		return nil, fmt.Errorf("%w: %s", domain.ErrLiftNameTaken, name)
+	}
+
+	lift, err := s.addLift(ctx, system, name, capacity)
+	if err != nil {
+		return nil, err
+	}
+
+	s.reconfigured(ctx, system)
+	return lift, nil
+}
+
+// UpdateLift renames a lift or changes its capacity. A lift cannot be changed
+// while it is moving or on its way to a hall call, and its capacity cannot go
+// below the passengers on board.
+func (s *SystemService) UpdateLift(ctx context.Context, id string, change domain.LiftChange) (*domain.Lift, error) {
+	if change.Name == nil && change.Capacity == nil {
+		return nil, fmt.Errorf("%w: nothing to change, set name or capacity", domain.ErrInvalidArgument)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system configuration: %w", err)
+	}
+
+	lift, err := s.repo.GetLift(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lift: %w", err)
+	}
+
+	// A moving lift writes its own copy back at every floor, which would
+	// undo the change
+	if lift.Status == domain.Occupied || s.lifts.isReserved(id) {
+		return nil, fmt.Errorf("cannot change lift %s: %w", lift.Name, domain.ErrLiftBusy)
+	}
+
+	if change.Name != nil {
+		name := strings.TrimSpace(*change.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: lift name must not be empty", domain.ErrInvalidArgument)
+		}
+
+		lifts, err := s.repo.GetAllLifts(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lifts: %w", err)
+		}
+		if liftNameTaken(lifts, name, id) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrLiftNameTaken, name)
+		}
+		lift.Name = name
+	}
+
+	if change.Capacity != nil {
+		capacity := *change.Capacity
+		if capacity < 1 {
+			return nil, fmt.Errorf("%w: %d, must be at least 1", domain.ErrInvalidCapacity, capacity)
+		}
+		if capacity < lift.Passengers {
+			return nil, fmt.Errorf("%w: %d, %d passengers are on board", domain.ErrInvalidCapacity, capacity, lift.Passengers)
+		}
+		lift.SetCapacity(capacity)
+	}
+
+	if err := s.repo.SaveLift(ctx, lift, system.ID); err != nil {
+		return nil, fmt.Errorf("failed to save lift: %w", err)
+	}
+
+	s.log.Info(ctx, "Lift updated", "lift_id", id, "lift_name", lift.Name, "capacity", lift.Capacity)
+	s.eventBus.Publish(ctx, domain.LiftUpdatedEvent{LiftID: id, Name: lift.Name, Capacity: lift.Capacity})
+	s.lifts.broadcastLift(lift, domain.LiftUpdated)
+	return lift, nil
+}
+
+// DecommissionLift takes a lift out of the running system. The lift stops
+// taking new hall calls and moves at once, and is removed in the background
+// once the calls it was assigned and its queued moves are done.
+func (s *SystemService) DecommissionLift(ctx context.Context, id string) (*domain.Lift, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lift, err := s.repo.GetLift(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lift: %w", err)
+	}
+
+	lifts, err := s.repo.GetAllLifts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lifts: %w", err)
+	}
+
+	if err := s.decommission(ctx, lift, len(s.activeLifts(lifts))); err != nil {
+		return nil, err
+	}
+	return lift, nil
+}
+
+// DrainingLifts returns the IDs of the lifts being decommissioned
+func (s *SystemService) DrainingLifts() []string {
+	return s.lifts.drainingLifts()
+}
+
+// addLift creates a lift and counts it in the system. The caller holds s.mu.
+func (s *SystemService) addLift(ctx context.Context, system *domain.System, name string, capacity int) (*domain.Lift, error) {
+	lift := domain.NewLift(uuid.New().String(), name)
+	lift.SetCapacity(capacity)
+	if err := s.repo.SaveLift(ctx, lift, system.ID); err != nil {
+		return nil, fmt.Errorf("failed to save lift %s: %w", name, err)
+	}
+
+	system.TotalLifts++
+	if err := s.repo.UpdateSystem(ctx, system); err != nil {
+		return nil, fmt.Errorf("failed to update system configuration: %w", err)
+	}
+
+	s.log.Info(ctx, "Lift added", "lift_id", lift.ID, "lift_name", name, "capacity", capacity)
+	s.eventBus.Publish(ctx, domain.LiftAddedEvent{LiftID: lift.ID, Name: name, Capacity: capacity})
+	s.lifts.broadcastLift(lift, domain.LiftAdded)
+	s.lifts.liftFreed()
+	return lift, nil
+}
+
+// decommission starts draining a lift, given the number of lifts not being
+// drained yet. The caller holds s.mu.
+func (s *SystemService) decommission(ctx context.Context, lift *domain.Lift, active int) error {
+	if s.lifts.isDraining(lift.ID) {
+		return fmt.Errorf("lift %s: %w", lift.Name, domain.ErrLiftDecommissioning)
+	}
+	if active <= 1 {
+		return fmt.Errorf("lift %s: %w", lift.Name, domain.ErrLastLift)
+	}
+
+	s.lifts.drain(lift.ID)
+
+	s.log.Info(ctx, "Decommissioning lift", "lift_id", lift.ID, "lift_name", lift.Name)
+	s.eventBus.Publish(ctx, domain.LiftDecommissioningEvent{LiftID: lift.ID})
+	s.lifts.broadcastLift(lift, domain.LiftDecommissioning)
+
+	go s.drainLift(context.WithoutCancel(ctx), lift.ID)
+	return nil
+}
+
+// drainLift waits until a decommissioned lift has finished its work and then
+// removes it. A lift that disappears in the meantime, e.g. because the system
+// was reset, is forgotten.
+func (s *SystemService) drainLift(ctx context.Context, liftID string) {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		idle, err := s.lifts.idle(ctx, liftID)
+		if errors.Is(err, domain.ErrNotFound) {
+			s.log.Info(ctx, "Lift being decommissioned no longer exists", "lift_id", liftID)
+			s.lifts.undrain(liftID)
+			return
+		}
+		if err != nil {
+			s.log.Warn(ctx, "Failed to check lift being decommissioned", "lift_id", liftID, "error", err)
+			continue
+		}
+		if !idle || s.moves.queued(liftID) {
+			continue
+		}
+
+		if err := s.removeLift(ctx, liftID); err != nil {
+			s.log.Warn(ctx, "Failed to remove decommissioned lift", "lift_id", liftID, "error", err)
+			continue
+		}
+		return
+	}
+}
+
+// removeLift deletes a drained lift together with its floor assignment
+func (s *SystemService) removeLift(ctx context.Context, liftID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get system configuration: %w", err)
+	}
+
+	lift, err := s.repo.GetLift(ctx, liftID)
+	if err != nil {
+		return fmt.Errorf("failed to get lift: %w", err)
+	}
+
+	if err := s.repo.DeleteLift(ctx, liftID); err != nil {
+		return err
+	}
+
+	system.TotalLifts--
+	if err := s.repo.UpdateSystem(ctx, system); err != nil {
+		return fmt.Errorf("failed to update system configuration: %w", err)
+	}
+	s.lifts.undrain(liftID)
+
+	s.log.Info(ctx, "Lift removed", "lift_id", liftID, "lift_name", lift.Name, "floor", lift.CurrentFloor)
+	s.eventBus.Publish(ctx, domain.LiftRemovedEvent{LiftID: liftID})
+	s.lifts.broadcastLift(lift, domain.LiftRemoved)
+	s.reconfigured(ctx, system)
+	return nil
+}
+
+// reconfigured announces the new size of the building
+func (s *SystemService) reconfigured(ctx context.Context, system *domain.System) {
+	s.log.Info(ctx, "System reconfigured",
+		"system_id", system.ID,
+		"total_floors", system.TotalFloors,
+		"total_lifts", system.TotalLifts)
+	s.eventBus.Publish(ctx, domain.SystemReconfiguredEvent{
+		TotalFloors: system.TotalFloors,
+		TotalLifts:  system.TotalLifts,
+	})
+}
+
+// activeLifts returns the lifts that are not being decommissioned
+func (s *SystemService) activeLifts(lifts []*domain.Lift) []*domain.Lift {
+	active := make([]*domain.Lift, 0, len(lifts))
+	for _, lift := range lifts {
+		if !s.lifts.isDraining(lift.ID) {
+			active = append(active, lift)
+		}
+	}
+	return active
+}
+
+// drainOrder lists lifts in the order they are picked for decommissioning:
+// parked lifts before busy ones, most recently listed first
+func (s *SystemService) drainOrder(lifts []*domain.Lift) []*domain.Lift {
+	var parked, busy []*domain.Lift
+	for i := len(lifts) - 1; i >= 0; i-- {
+		lift := lifts[i]
+		if lift.Status == domain.Occupied || s.lifts.isReserved(lift.ID) {
+			busy = append(busy, lift)
+		} else {
+			parked = append(parked, lift)
+		}
+	}
+	return append(parked, busy...)
+}
+
+// nextLiftName returns the first name of the form Ln not used by any lift
+func nextLiftName(lifts []*domain.Lift) string {
+	for n := len(lifts) + 1; ; n++ {
+		name := fmt.Sprintf("L%d", n)
+		if !liftNameTaken(lifts, name, "") {
+			return name
+		}
+	}
+}
+
+// liftNameTaken reports whether a lift other than exceptID has the name
+func liftNameTaken(lifts []*domain.Lift, name, exceptID string) bool {
+	for _, lift := range lifts {
+		if lift.Name == name && lift.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
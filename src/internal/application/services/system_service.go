@@ -2,8 +2,13 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/Avyukth/lift-simulation/internal/application/events"
 	"github.com/Avyukth/lift-simulation/internal/application/ports"
 	"github.com/Avyukth/lift-simulation/internal/domain"
 	"github.com/Avyukth/lift-simulation/pkg/logger"
@@ -12,25 +17,34 @@ import (
 
 // SystemService handles the business logic for overall system operations
 type SystemService struct {
-	repo ports.Repository
-	log  *logger.Logger
+	repo     ports.Repository
+	lifts    *LiftService
+	moves    *MoveService
+	eventBus events.EventBus
+	log      *logger.Logger
+
+	// mu serialises changes to the building made while the system runs
+	mu sync.Mutex
 }
 
 // NewSystemService creates a new instance of SystemService
-func NewSystemService(repo ports.Repository, log *logger.Logger) *SystemService {
+func NewSystemService(repo ports.Repository, lifts *LiftService, moves *MoveService, eventBus events.EventBus, log *logger.Logger) *SystemService {
 	return &SystemService{
-		repo: repo,
-		log:  log,
+		repo:     repo,
+		lifts:    lifts,
+		moves:    moves,
+		eventBus: eventBus,
+		log:      log,
 	}
 }
 
 // ConfigureSystem sets up the lift system with the specified number of floors and lifts
 func (s *SystemService) ConfigureSystem(ctx context.Context, floors, lifts int) error {
 	if floors < 2 {
-		return fmt.Errorf("invalid number of floors: must be at least 2")
+		return fmt.Errorf("%w: number of floors must be at least 2", domain.ErrInvalidArgument)
 	}
 	if lifts < 1 {
-		return fmt.Errorf("invalid number of lifts: must be at least 1")
+		return fmt.Errorf("%w: number of lifts must be at least 1", domain.ErrInvalidArgument)
 	}
 	// maxLifts := int(math.Ceil(float64(floors) * 0.75))
 	// if lifts > maxLifts {
@@ -38,7 +52,7 @@ func (s *SystemService) ConfigureSystem(ctx context.Context, floors, lifts int)
 	// }
 	system, err := s.repo.GetSystem(ctx)
 	if err == nil && system != nil {
-		return fmt.Errorf("system exists with system id: %s, Total Floor : %d, Total Lifts : %d", system.ID, system.TotalFloors, system.TotalLifts)
+		return fmt.Errorf("%w: system id: %s, Total Floor : %d, Total Lifts : %d", domain.ErrSystemAlreadyConfigured, system.ID, system.TotalFloors, system.TotalLifts)
 	}
 
 	s.log.Info(ctx, "Configuring system", "total_floors", floors, "total_lifts", lifts)
@@ -80,6 +94,12 @@ func (s *SystemService) ConfigureSystem(ctx context.Context, floors, lifts int)
 		s.log.Debug(ctx, "Lift created", "lift_id", liftID, "lift_name", liftName)
 	}
 
+	s.eventBus.Publish(ctx, domain.SystemConfiguredEvent{
+		SystemID:    systemID,
+		TotalFloors: floors,
+		TotalLifts:  lifts,
+	})
+
 	s.log.Info(ctx, "System configuration completed successfully",
 		"system_id", systemID,
 		"total_floors", floors,
@@ -182,11 +202,12 @@ func (s *SystemService) ResetSystem(ctx context.Context) error {
 		return fmt.Errorf("all system reset %w", err)
 	}
 
+	s.eventBus.Publish(ctx, domain.SystemResetEvent{SystemID: system.ID})
 	return nil
 }
 
 // GetSystemMetrics retrieves various metrics about the system
-func (s *SystemService) GetSystemMetrics(ctx context.Context) (map[string]interface{}, error) {
+func (s *SystemService) GetSystemMetrics(ctx context.Context) (*domain.SystemMetrics, error) {
 	system, err := s.repo.GetSystem(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system: %w", err)
@@ -197,16 +218,286 @@ func (s *SystemService) GetSystemMetrics(ctx context.Context) (map[string]interf
 		return nil, fmt.Errorf("failed to get lifts: %w", err)
 	}
 
-	metrics := map[string]interface{}{
-		"systemID":          system.ID,
-		"totalFloors":       system.TotalFloors,
-		"totalLifts":        system.TotalLifts,
-		"availableLifts":    countAvailableLifts(lifts),
-		"occupiedLifts":     countOccupiedLifts(lifts),
-		"outOfServiceLifts": countOutOfServiceLifts(lifts),
+	return &domain.SystemMetrics{
+		SystemID:          system.ID,
+		TotalFloors:       system.TotalFloors,
+		TotalLifts:        system.TotalLifts,
+		AvailableLifts:    countAvailableLifts(lifts),
+		OccupiedLifts:     countOccupiedLifts(lifts),
+		OutOfServiceLifts: countOutOfServiceLifts(lifts),
+	}, nil
+}
+
+// ExportSnapshot captures the system, its floors, lifts, floor assignments and
+// open hall calls as a versioned snapshot document
+func (s *SystemService) ExportSnapshot(ctx context.Context) (*domain.Snapshot, error) {
+	system, err := s.repo.GetSystem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system configuration: %w", err)
+	}
+
+	floors, err := s.repo.GetAllFloors(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get floors: %w", err)
+	}
+
+	lifts, err := s.repo.GetAllLifts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lifts: %w", err)
+	}
+
+	snapshot := &domain.Snapshot{
+		Version:    domain.SnapshotVersion,
+		ExportedAt: time.Now().UTC(),
+		System: domain.SnapshotSystem{
+			ID:          system.ID,
+			TotalFloors: system.TotalFloors,
+			TotalLifts:  system.TotalLifts,
+		},
+		Floors:      make([]domain.SnapshotFloor, 0, len(floors)),
+		Lifts:       make([]domain.SnapshotLift, 0, len(lifts)),
+		Assignments: []domain.SnapshotAssignment{},
+		ActiveCalls: []domain.SnapshotCall{},
+	}
+
+	for _, floor := range floors {
+		snapshot.Floors = append(snapshot.Floors, domain.SnapshotFloor{
+			ID:     floor.ID,
+			Number: floor.Number,
+		})
+
+		assigned, err := s.repo.GetAssignedLiftsForFloor(ctx, floor.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get lifts assigned to floor %d: %w", floor.Number, err)
+		}
+		for _, lift := range assigned {
+			snapshot.Assignments = append(snapshot.Assignments, domain.SnapshotAssignment{
+				LiftID:      lift.ID,
+				FloorNumber: floor.Number,
+			})
+		}
+	}
+
+	calls, err := openCalls(ctx, s.repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, call := range calls {
+		snapshot.ActiveCalls = append(snapshot.ActiveCalls, domain.SnapshotCall{
+			FloorNumber: call.FloorNumber,
+			Direction:   call.Direction,
+			Status:      call.Status,
+			CreatedAt:   call.CreatedAt,
+		})
+	}
+
+	for _, lift := range lifts {
+		snapshot.Lifts = append(snapshot.Lifts, domain.SnapshotLift{
+			ID:           lift.ID,
+			Name:         lift.Name,
+			CurrentFloor: lift.CurrentFloor,
+			Status:       domain.LiftStatusToString(lift.Status),
+			Capacity:     lift.Capacity,
+		})
+	}
+
+	s.log.Info(ctx, "Exported system snapshot",
+		"system_id", system.ID,
+		"floors", len(snapshot.Floors),
+		"lifts", len(snapshot.Lifts),
+		"assignments", len(snapshot.Assignments),
+		"active_calls", len(snapshot.ActiveCalls))
+	return snapshot, nil
+}
+
+// ImportSnapshot validates the snapshot and replaces the current system, if
+// any, with its contents, in a single transaction. Nothing is changed when
+// validation or any write fails. The open hall calls of the current system are
+// cancelled and those of the snapshot are queued again, waiting since they
+// were first made; a call that had a lift on its way waits for a new one.
+func (s *SystemService) ImportSnapshot(ctx context.Context, snapshot *domain.Snapshot) error {
+	if err := snapshot.Validate(); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	txRepo, ok := s.repo.(ports.TransactionalRepository)
+	if !ok {
+		return errors.New("repository does not support transactions")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var system *domain.System
+	var closed, restored []*domain.HallCall
+	err := s.lifts.calls.exclusive(func() error {
+		tx, err := txRepo.BeginTx(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+
+		system, closed, restored, err = s.importSnapshot(ctx, txRepo.WithTx(tx), snapshot)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit snapshot: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Work still under way for lifts that are gone has nothing left to act on
+	keep := make(map[string]bool, len(snapshot.Lifts))
+	for _, l := range snapshot.Lifts {
+		keep[l.ID] = true
+	}
+	s.moves.forgetLifts(ctx, keep)
+	s.lifts.forgetLifts(keep)
+
+	s.eventBus.Publish(ctx, domain.SystemConfiguredEvent{
+		SystemID:    system.ID,
+		TotalFloors: system.TotalFloors,
+		TotalLifts:  system.TotalLifts,
+	})
+	s.lifts.calls.replaced(ctx, closed, restored)
+
+	s.log.Info(ctx, "Imported system snapshot",
+		"system_id", system.ID,
+		"exported_at", snapshot.ExportedAt,
+		"floors", len(snapshot.Floors),
+		"lifts", len(snapshot.Lifts),
+		"cancelled_calls", len(closed),
+		"restored_calls", len(restored))
+	return nil
+}
+
+// importSnapshot writes the contents of a validated snapshot through repo,
+// which is bound to the import's transaction. It returns the new system, the
+// calls it cancelled and the calls it restored.
+func (s *SystemService) importSnapshot(ctx context.Context, repo ports.Repository, snapshot *domain.Snapshot) (*domain.System, []*domain.HallCall, []*domain.HallCall, error) {
+	open, err := openCalls(ctx, repo)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	return metrics, nil
+	if current, err := repo.GetSystem(ctx); err == nil && current != nil {
+		if err := repo.ResetSystem(ctx, current.ID); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to reset current system: %w", err)
+		}
+	}
+	if err := repo.UnassignBulk(ctx); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to clear lift assignments: %w", err)
+	}
+
+	system, err := domain.NewSystem(snapshot.System.ID, snapshot.System.TotalFloors, snapshot.System.TotalLifts)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create system configuration: %w", err)
+	}
+	if err := repo.SaveSystem(ctx, system); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to save system configuration: %w", err)
+	}
+
+	floors := make(map[int]*domain.Floor, len(snapshot.Floors))
+	for _, f := range snapshot.Floors {
+		floors[f.Number] = domain.NewFloor(f.ID, f.Number)
+	}
+	for _, call := range snapshot.ActiveCalls {
+		floors[call.FloorNumber].RequestLift(call.Direction)
+	}
+	for _, f := range snapshot.Floors {
+		if err := repo.SaveFloor(ctx, floors[f.Number], system.ID); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to save floor %d: %w", f.Number, err)
+		}
+	}
+
+	for _, l := range snapshot.Lifts {
+		lift := domain.NewLift(l.ID, l.Name)
+		lift.SetCurrentFloor(l.CurrentFloor)
+		lift.TargetFloor = l.CurrentFloor
+		// A lift that was mid-trip when the snapshot was taken has nothing
+		// driving it after the import, so it comes back parked and available.
+		if status := domain.StringToLiftStatus(l.Status); status != domain.Occupied {
+			lift.SetStatus(status)
+		}
+		lift.Capacity = l.Capacity
+		if err := repo.SaveLift(ctx, lift, system.ID); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to save lift %s: %w", l.Name, err)
+		}
+	}
+
+	for _, a := range snapshot.Assignments {
+		floor := floors[a.FloorNumber]
+		if err := repo.AssignLiftToFloor(ctx, a.LiftID, floor.ID, floor.Number); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to assign lift %s to floor %d: %w", a.LiftID, a.FloorNumber, err)
+		}
+	}
+
+	now := time.Now()
+	for _, call := range open {
+		call.Status = domain.CallCancelled
+		call.Reason = "replaced by a snapshot import"
+		call.ETA = nil
+		call.ClosedAt = &now
+		call.UpdatedAt = now
+		if err := repo.UpdateHallCall(ctx, call); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to cancel hall call %s: %w", call.ID, err)
+		}
+	}
+
+	restored := make([]*domain.HallCall, 0, len(snapshot.ActiveCalls))
+	for _, c := range snapshot.ActiveCalls {
+		createdAt := c.CreatedAt
+		if createdAt.IsZero() {
+			createdAt = snapshot.ExportedAt
+		}
+		if createdAt.IsZero() {
+			createdAt = now
+		}
+		call := &domain.HallCall{
+			ID:          uuid.New().String(),
+			FloorNumber: c.FloorNumber,
+			Direction:   c.Direction,
+			Status:      domain.CallPending,
+			CreatedAt:   createdAt,
+			UpdatedAt:   now,
+		}
+		if err := repo.SaveHallCall(ctx, call); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to restore hall call at floor %d: %w", c.FloorNumber, err)
+		}
+		restored = append(restored, call)
+	}
+
+	return system, open, restored, nil
+}
+
+// openCalls returns the calls waiting for a lift or with a lift on its way,
+// oldest first
+func openCalls(ctx context.Context, repo ports.HallCallRepository) ([]*domain.HallCall, error) {
+	var open []*domain.HallCall
+	for _, status := range []domain.HallCallStatus{domain.CallPending, domain.CallAssigned} {
+		for offset := 0; ; offset += maxWaitingCalls {
+			calls, _, err := repo.ListHallCalls(ctx, domain.HallCallFilter{
+				Status:      status,
+				OldestFirst: true,
+				Limit:       maxWaitingCalls,
+				Offset:      offset,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s hall calls: %w", status, err)
+			}
+			open = append(open, calls...)
+			if len(calls) < maxWaitingCalls {
+				break
+			}
+		}
+	}
+	slices.SortStableFunc(open, func(a, b *domain.HallCall) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return open, nil
 }
 
 // SimulateTraffic simulates traffic in the system based on the given intensity and duration
@@ -0,0 +1,482 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// WebhookConfig tunes webhook delivery
+type WebhookConfig struct {
+	// MaxAttempts is the number of attempts made before a delivery is
+	// dead-lettered
+	MaxAttempts int
+	// InitialBackoff is the wait after the first failed attempt. It doubles
+	// after every further failure up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// PollInterval is how often due deliveries are looked up when no new
+	// event has been queued
+	PollInterval time.Duration
+	// Workers is the number of deliveries sent concurrently
+	Workers int
+}
+
+// WebhookInput is the writable part of a webhook subscription
+type WebhookInput struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// Secret signs the deliveries. A random secret is generated when it is
+	// left empty on create; on update an empty secret keeps the current one.
+	Secret string `json:"secret"`
+	Active *bool  `json:"active"`
+}
+
+// WebhookService manages webhook subscriptions and delivers domain events to
+// them. Every event is stored as a pending delivery per matching webhook and
+// sent by Run, which retries failures with exponential backoff and
+// dead-letters deliveries that run out of attempts.
+type WebhookService struct {
+	repo   ports.WebhookRepository
+	sender ports.WebhookSender
+	cfg    WebhookConfig
+	log    *logger.Logger
+	wake   chan struct{}
+}
+
+type webhookEventHandler struct {
+	service *WebhookService
+}
+
+func (h *webhookEventHandler) Handle(ctx context.Context, env domain.Envelope) {
+	h.service.enqueue(ctx, env)
+}
+
+// NewWebhookService creates a new instance of WebhookService subscribed to
+// every event type
+func NewWebhookService(repo ports.WebhookRepository, sender ports.WebhookSender, eventBus events.EventBus, cfg WebhookConfig, log *logger.Logger) *WebhookService {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = time.Second
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = cfg.InitialBackoff
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+
+	service := &WebhookService{
+		repo:   repo,
+		sender: sender,
+		cfg:    cfg,
+		log:    log,
+		wake:   make(chan struct{}, 1),
+	}
+
+	handler := &webhookEventHandler{service: service}
+	for _, eventType := range domain.AllEventTypes() {
+		eventBus.Subscribe(eventType, handler)
+	}
+
+	return service
+}
+
+// CreateWebhook registers a new webhook. The returned webhook carries its
+// secret, which is not shown again.
+func (s *WebhookService) CreateWebhook(ctx context.Context, input WebhookInput) (*domain.Webhook, error) {
+	eventTypes, err := validateWebhookInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	hook := &domain.Webhook{
+		ID:         uuid.New().String(),
+		URL:        input.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     input.Active == nil || *input.Active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.repo.SaveWebhook(ctx, hook); err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	s.log.Info(ctx, "Created webhook", "webhook_id", hook.ID, "url", hook.URL)
+	return hook, nil
+}
+
+// GetWebhook returns a webhook by ID
+func (s *WebhookService) GetWebhook(ctx context.Context, id string) (*domain.Webhook, error) {
+	hook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return hook, nil
+}
+
+// ListWebhooks returns every webhook
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	hooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// UpdateWebhook replaces the URL, event filter and active flag of a webhook,
+// and its secret when a new one is given
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id string, input WebhookInput) (*domain.Webhook, error) {
+	eventTypes, err := validateWebhookInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	hook.URL = input.URL
+	hook.EventTypes = eventTypes
+	if input.Secret != "" {
+		hook.Secret = input.Secret
+	}
+	if input.Active != nil {
+		hook.Active = *input.Active
+	}
+	hook.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.UpdateWebhook(ctx, hook); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	s.log.Info(ctx, "Updated webhook", "webhook_id", hook.ID, "url", hook.URL, "active", hook.Active)
+	return hook, nil
+}
+
+// DeleteWebhook removes a webhook together with its delivery log
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	if err := s.repo.DeleteWebhook(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	s.log.Info(ctx, "Deleted webhook", "webhook_id", id)
+	return nil
+}
+
+// ListDeliveries returns the deliveries matching the filter, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	deliveries, err := s.repo.ListWebhookDeliveries(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// GetDelivery returns a delivery together with its attempt log
+func (s *WebhookService) GetDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, []*domain.WebhookAttempt, error) {
+	delivery, err := s.repo.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	attempts, err := s.repo.ListWebhookAttempts(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list webhook attempts: %w", err)
+	}
+
+	return delivery, attempts, nil
+}
+
+// RetryDelivery moves a dead-lettered delivery back to the queue with a fresh
+// set of attempts
+func (s *WebhookService) RetryDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.repo.GetWebhookDelivery(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	if delivery.Status != domain.DeliveryDead {
+		return nil, fmt.Errorf("delivery %s is %s: %w", id, delivery.Status, domain.ErrDeliveryNotDead)
+	}
+
+	now := time.Now().UTC()
+	delivery.Status = domain.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.UpdatedAt = now
+
+	if err := s.repo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+
+	s.log.Info(ctx, "Requeued webhook delivery", "delivery_id", id, "webhook_id", delivery.WebhookID)
+	s.notify()
+	return delivery, nil
+}
+
+// Run sends due deliveries until ctx is cancelled
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// webhookEvent is the JSON body posted to webhook endpoints
+type webhookEvent struct {
+	ID            string       `json:"id"`
+	Type          string       `json:"type"`
+	SystemID      string       `json:"system_id,omitempty"`
+	OccurredAt    time.Time    `json:"occurred_at"`
+	CausationID   string       `json:"causation_id,omitempty"`
+	CorrelationID string       `json:"correlation_id,omitempty"`
+	TraceID       string       `json:"trace_id,omitempty"`
+	Payload       domain.Event `json:"payload"`
+}
+
+// enqueue stores a pending delivery of the event for every active webhook
+// subscribed to its type
+func (s *WebhookService) enqueue(ctx context.Context, env domain.Envelope) {
+	hooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		s.log.Error(ctx, "Failed to list webhooks", "event_id", env.ID, "error", err)
+		return
+	}
+
+	var payload []byte
+	queued := 0
+	for _, hook := range hooks {
+		if !hook.Active || !hook.Matches(env.Event.Type()) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(webhookEvent{
+				ID:            env.ID,
+				Type:          env.Event.Type().String(),
+				SystemID:      env.SystemID,
+				OccurredAt:    env.OccurredAt,
+				CausationID:   env.CausationID,
+				CorrelationID: env.CorrelationID,
+				TraceID:       env.TraceID,
+				Payload:       env.Event,
+			})
+			if err != nil {
+				s.log.Error(ctx, "Failed to encode webhook payload", "event_id", env.ID, "error", err)
+				return
+			}
+		}
+
+		now := time.Now().UTC()
+		delivery := &domain.WebhookDelivery{
+			ID:            uuid.New().String(),
+			WebhookID:     hook.ID,
+			EventID:       env.ID,
+			EventType:     env.Event.Type(),
+			Payload:       payload,
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := s.repo.SaveWebhookDelivery(ctx, delivery); err != nil {
+			s.log.Error(ctx, "Failed to queue webhook delivery", "webhook_id", hook.ID, "event_id", env.ID, "error", err)
+			continue
+		}
+		queued++
+	}
+
+	if queued > 0 {
+		s.notify()
+	}
+}
+
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDue sends every due delivery, Workers at a time
+func (s *WebhookService) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := s.repo.ListDueWebhookDeliveries(ctx, time.Now().UTC(), s.cfg.Workers)
+		if err != nil {
+			s.log.Error(ctx, "Failed to list due webhook deliveries", "error", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range due {
+			wg.Add(1)
+			go func(d *domain.WebhookDelivery) {
+				defer wg.Done()
+				s.deliver(ctx, d)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(due) < s.cfg.Workers {
+			return
+		}
+	}
+}
+
+// deliver makes one attempt at a delivery and records its outcome
+func (s *WebhookService) deliver(ctx context.Context, delivery *domain.WebhookDelivery) {
+	hook, err := s.repo.GetWebhook(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		s.deadLetter(ctx, delivery, "webhook was deleted")
+		return
+	case err != nil:
+		s.log.Error(ctx, "Failed to get webhook", "webhook_id", delivery.WebhookID, "error", err)
+		return
+	case !hook.Active:
+		s.deadLetter(ctx, delivery, "webhook is disabled")
+		return
+	}
+
+	start := time.Now()
+	statusCode, sendErr := s.sender.Send(ctx, hook, delivery)
+	if sendErr != nil && ctx.Err() != nil {
+		// Shutting down; the delivery stays due and is sent on the next run
+		return
+	}
+
+	delivery.Attempts++
+	attempt := &domain.WebhookAttempt{
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts,
+		StatusCode:  statusCode,
+		DurationMS:  time.Since(start).Milliseconds(),
+		AttemptedAt: start.UTC(),
+	}
+
+	now := time.Now().UTC()
+	delivery.UpdatedAt = now
+	switch {
+	case sendErr == nil:
+		delivery.Status = domain.DeliveryDelivered
+		delivery.LastError = ""
+	case delivery.Attempts >= s.cfg.MaxAttempts:
+		attempt.Error = sendErr.Error()
+		delivery.Status = domain.DeliveryDead
+		delivery.LastError = sendErr.Error()
+	default:
+		attempt.Error = sendErr.Error()
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = now.Add(s.backoff(delivery.Attempts))
+	}
+
+	if err := s.repo.AppendWebhookAttempt(ctx, attempt); err != nil {
+		s.log.Error(ctx, "Failed to record webhook attempt", "delivery_id", delivery.ID, "error", err)
+	}
+	if err := s.repo.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		s.log.Error(ctx, "Failed to update webhook delivery", "delivery_id", delivery.ID, "error", err)
+		return
+	}
+
+	switch delivery.Status {
+	case domain.DeliveryDelivered:
+		s.log.Info(ctx, "Delivered webhook", "delivery_id", delivery.ID, "webhook_id", hook.ID, "event_type", delivery.EventType.String(), "attempts", delivery.Attempts)
+	case domain.DeliveryDead:
+		s.log.Warn(ctx, "Dead-lettered webhook delivery", "delivery_id", delivery.ID, "webhook_id", hook.ID, "attempts", delivery.Attempts, "error", sendErr)
+	default:
+		s.log.Warn(ctx, "Webhook delivery failed", "delivery_id", delivery.ID, "webhook_id", hook.ID, "attempts", delivery.Attempts, "next_attempt_at", delivery.NextAttemptAt, "error", sendErr)
+	}
+}
+
+func (s *WebhookService) deadLetter(ctx context.Context, delivery *domain.WebhookDelivery, reason string) {
+	delivery.Status = domain.DeliveryDead
+	delivery.LastError = reason
+	delivery.UpdatedAt = time.Now().UTC()
+
+	if err := s.repo.UpdateWebhookDelivery(ctx, delivery); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		s.log.Error(ctx, "Failed to dead-letter webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// backoff returns the wait before the attempt following the given number of
+// failed attempts
+func (s *WebhookService) backoff(failures int) time.Duration {
+	wait := s.cfg.InitialBackoff
+	for i := 1; i < failures && wait < s.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, s.cfg.MaxBackoff)
+}
+
+func validateWebhookInput(input WebhookInput) ([]domain.EventType, error) {
+	var problems []string
+
+	u, err := url.Parse(input.URL)
+	switch {
+	case input.URL == "":
+		problems = append(problems, "url is required")
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		problems = append(problems, "url must be an absolute http or https URL")
+	}
+
+	eventTypes := make([]domain.EventType, 0, len(input.EventTypes))
+	for i, name := range input.EventTypes {
+		t, err := domain.ParseEventType(name)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("event_types[%d]: %v", i, err))
+			continue
+		}
+		eventTypes = append(eventTypes, t)
+	}
+
+	if len(problems) > 0 {
+		return nil, &domain.ValidationError{Problems: problems}
+	}
+	return eventTypes, nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,13 +1,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
 	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/handlers"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/middleware"
 	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
 	"github.com/Avyukth/lift-simulation/pkg/logger"
 	"github.com/ardanlabs/conf/v3"
@@ -31,23 +34,63 @@ type Config struct {
 	}
 	Auth struct {
 		KeysFolder string `conf:"default:zarf/keys/"`
-		ActiveKID  string `conf:"default:54bb2165-71e1-41a6-af3e-7da4a0e1e2c1"`
+		ActiveKID  string
 		Issuer     string `conf:"default:lift simulation project"`
-		JWTSecret  string
 	}
 	DB struct {
+		Driver       string        `conf:"default:sqlite"`
 		Path         string        `conf:"default:./db/lift_simulation.sqlite"`
+		URL          string        `conf:"mask"`
 		MaxOpenConns int           `conf:"default:10"`
 		MaxIdleConns int           `conf:"default:5"`
 		MaxLifetime  time.Duration `conf:"default:1h"`
 	}
 	Redis struct {
+		Enabled  bool
 		Host     string `conf:"default:localhost"`
 		Port     int    `conf:"default:6379"`
 		Password string
 		DB       int `conf:"default:0"`
 		PoolSize int `conf:"default:10"`
 	}
+	EventBus struct {
+		Workers        int           `conf:"default:16"`
+		QueueCapacity  int           `conf:"default:256"`
+		Overflow       string        `conf:"default:block"`
+		PublishTimeout time.Duration `conf:"default:5s"`
+	}
+	Webhook struct {
+		MaxAttempts    int           `conf:"default:6"`
+		InitialBackoff time.Duration `conf:"default:1s"`
+		MaxBackoff     time.Duration `conf:"default:5m"`
+		Timeout        time.Duration `conf:"default:10s"`
+		PollInterval   time.Duration `conf:"default:1s"`
+		Workers        int           `conf:"default:4"`
+	}
+	WebSocket struct {
+		SendQueue    int           `conf:"default:256"`
+		PingInterval time.Duration `conf:"default:30s"`
+		PongWait     time.Duration `conf:"default:60s"`
+		WriteWait    time.Duration `conf:"default:10s"`
+		ReplayBuffer int           `conf:"default:1024"`
+	}
+	Dispatch struct {
+		Interval time.Duration `conf:"default:1s"`
+		MaxWait  time.Duration `conf:"default:2m"`
+	}
+	Stream struct {
+		KeepAlive time.Duration `conf:"default:15s"`
+	}
+	Idempotency struct {
+		TTL time.Duration `conf:"default:24h"`
+	}
+	RateLimit struct {
+		Backend     string  `conf:"default:memory"`
+		ClientRate  float64 `conf:"default:10"`
+		ClientBurst int     `conf:"default:20"`
+		FloorRate   float64 `conf:"default:0.5"`
+		FloorBurst  int     `conf:"default:2"`
+	}
 	Lift struct {
 		MaxFloors     int `conf:"default:50"`
 		MaxLifts      int `conf:"default:10"`
@@ -61,13 +104,27 @@ type Config struct {
 }
 
 type RouteConfig struct {
-	App           *fiber.App
-	LiftHandler   *handlers.LiftHandler
-	FloorHandler  *handlers.FloorHandler
-	SystemHandler *handlers.SystemHandler
-	Hub           *ws.WebSocketHub
-	FiberLog      *logger.FiberLogger
-	Repo          ports.Repository
+	App            *fiber.App
+	LiftHandler    *handlers.LiftHandler
+	FloorHandler   *handlers.FloorHandler
+	MoveHandler    *handlers.MoveHandler
+	CallHandler    *handlers.CallHandler
+	SystemHandler  *handlers.SystemHandler
+	EventHandler   *handlers.EventHandler
+	WebhookHandler *handlers.WebhookHandler
+	APIKeyHandler  *handlers.APIKeyHandler
+	AuditHandler   *handlers.AuditHandler
+	StreamHandler  *handlers.StreamHandler
+	CommandHandler *handlers.CommandHandler
+	Hub            *ws.WebSocketHub
+	FiberLog       *logger.FiberLogger
+	Repo           ports.Repository
+	Auth           *auth.Auth
+	APIKeys        middleware.APIKeyAuthenticator
+	Auditor        middleware.Auditor
+	Idempotency    middleware.IdempotencyStore
+	ClientLimiter  middleware.RateLimiter
+	FloorLimiter   middleware.RateLimiter
 }
 
 // LoadConfig reads configuration from environment variables and .env file.
@@ -93,7 +150,9 @@ func LoadConfig(build string) (Config, error) {
 	}
 
 	// Override config with values from .env
-	cfg.Auth.JWTSecret = viper.GetString("JWT_SECRET")
+	cfg.Auth.KeysFolder = viper.GetString("AUTH_KEYS_FOLDER")
+	cfg.Auth.ActiveKID = viper.GetString("AUTH_ACTIVE_KID")
+	cfg.Auth.Issuer = viper.GetString("AUTH_ISSUER")
 	cfg.Redis.Password = viper.GetString("REDIS_PASSWORD")
 
 	cfg.Web.CertFile = viper.GetString("CERT_FILE")
@@ -101,8 +160,35 @@ func LoadConfig(build string) (Config, error) {
 
 	cfg.Redis.Host = viper.GetString("REDIS_HOST")
 	cfg.Redis.Port = viper.GetInt("REDIS_PORT")
+	cfg.Redis.Enabled = viper.GetBool("REDIS_ENABLED")
 	cfg.LogLevel = viper.GetString("LOG_LEVEL")
 	cfg.DB.Path = viper.GetString("DB_PATH")
+	cfg.DB.Driver = viper.GetString("DB_DRIVER")
+	cfg.DB.URL = viper.GetString("DB_URL")
+	cfg.EventBus.Workers = viper.GetInt("EVENT_BUS_WORKERS")
+	cfg.EventBus.QueueCapacity = viper.GetInt("EVENT_BUS_QUEUE_CAPACITY")
+	cfg.EventBus.Overflow = viper.GetString("EVENT_BUS_OVERFLOW")
+	cfg.EventBus.PublishTimeout = viper.GetDuration("EVENT_BUS_PUBLISH_TIMEOUT")
+	cfg.Webhook.MaxAttempts = viper.GetInt("WEBHOOK_MAX_ATTEMPTS")
+	cfg.Webhook.InitialBackoff = viper.GetDuration("WEBHOOK_INITIAL_BACKOFF")
+	cfg.Webhook.MaxBackoff = viper.GetDuration("WEBHOOK_MAX_BACKOFF")
+	cfg.Webhook.Timeout = viper.GetDuration("WEBHOOK_TIMEOUT")
+	cfg.Webhook.PollInterval = viper.GetDuration("WEBHOOK_POLL_INTERVAL")
+	cfg.Webhook.Workers = viper.GetInt("WEBHOOK_WORKERS")
+	cfg.Dispatch.Interval = viper.GetDuration("DISPATCH_INTERVAL")
+	cfg.Dispatch.MaxWait = viper.GetDuration("DISPATCH_MAX_WAIT")
+	cfg.Stream.KeepAlive = viper.GetDuration("STREAM_KEEP_ALIVE")
+	cfg.Idempotency.TTL = viper.GetDuration("IDEMPOTENCY_TTL")
+	cfg.RateLimit.Backend = viper.GetString("RATE_LIMIT_BACKEND")
+	cfg.RateLimit.ClientRate = viper.GetFloat64("RATE_LIMIT_CLIENT_RATE")
+	cfg.RateLimit.ClientBurst = viper.GetInt("RATE_LIMIT_CLIENT_BURST")
+	cfg.RateLimit.FloorRate = viper.GetFloat64("RATE_LIMIT_FLOOR_RATE")
+	cfg.RateLimit.FloorBurst = viper.GetInt("RATE_LIMIT_FLOOR_BURST")
+	cfg.WebSocket.SendQueue = viper.GetInt("WS_SEND_QUEUE")
+	cfg.WebSocket.PingInterval = viper.GetDuration("WS_PING_INTERVAL")
+	cfg.WebSocket.PongWait = viper.GetDuration("WS_PONG_WAIT")
+	cfg.WebSocket.WriteWait = viper.GetDuration("WS_WRITE_WAIT")
+	cfg.WebSocket.ReplayBuffer = viper.GetInt("WS_REPLAY_BUFFER")
 	cwd, err := os.Getwd()
 
 	if err != nil {
@@ -125,6 +211,13 @@ func LoadConfig(build string) (Config, error) {
 		return cfg, fmt.Errorf("parsing config: %w", err)
 	}
 
+	// Outside production a lone key in the keys folder signs tokens. In
+	// production the key must be named, so a stray key file cannot become
+	// the signing key.
+	if env == "production" && cfg.Auth.ActiveKID == "" {
+		return cfg, errors.New("AUTH_ACTIVE_KID must be set in production")
+	}
+
 	return cfg, nil
 }
 
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// APIKeyScope restricts what a machine client holding an API key may do
+type APIKeyScope string
+
+const (
+	// ScopeReadOnly keys may read the state of the building
+	ScopeReadOnly APIKeyScope = "read-only"
+	// ScopeCallsOnly keys may place, follow and cancel hall calls
+	ScopeCallsOnly APIKeyScope = "calls-only"
+	// ScopeAdmin keys may do anything an admin may
+	ScopeAdmin APIKeyScope = "admin"
+)
+
+// ParseAPIKeyScope validates a scope name
+func ParseAPIKeyScope(s string) (APIKeyScope, error) {
+	switch scope := APIKeyScope(s); scope {
+	case ScopeReadOnly, ScopeCallsOnly, ScopeAdmin:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("unknown scope: %s", s)
+	}
+}
+
+// APIKey lets a machine client authenticate without a JWT. Only a hash of the
+// key is kept; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Prefix is the start of the key, enough to tell keys apart in logs and
+	// listings without revealing them
+	Prefix     string        `json:"prefix"`
+	Hash       string        `json:"-"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	CreatedBy  string        `json:"created_by"`
+	CreatedAt  time.Time     `json:"created_at"`
+	ExpiresAt  *time.Time    `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time    `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty"`
+}
+
+// Usable reports whether the key may still be used at now
+func (k *APIKey) Usable(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// AuditOutcome is how a mutating API call ended
+type AuditOutcome string
+
+const (
+	// AuditSucceeded calls were carried out
+	AuditSucceeded AuditOutcome = "succeeded"
+	// AuditDenied calls were refused because the caller lacked a role
+	AuditDenied AuditOutcome = "denied"
+	// AuditFailed calls were rejected or failed
+	AuditFailed AuditOutcome = "failed"
+)
+
+// ParseAuditOutcome validates an outcome name
+func ParseAuditOutcome(s string) (AuditOutcome, error) {
+	switch o := AuditOutcome(s); o {
+	case AuditSucceeded, AuditDenied, AuditFailed:
+		return o, nil
+	default:
+		return "", fmt.Errorf("unknown outcome: %s", s)
+	}
+}
+
+// AuditSubject names the state a call may change, so it can be captured
+// before and after the call. Kinds without an ID, such as the lifts or the
+// system, stand for the whole collection.
+type AuditSubject struct {
+	Kind string
+	ID   string
+}
+
+// Audit subject kinds
+const (
+	AuditSubjectLift    = "lift"
+	AuditSubjectLifts   = "lifts"
+	AuditSubjectFloor   = "floor"
+	AuditSubjectSystem  = "system"
+	AuditSubjectCall    = "call"
+	AuditSubjectWebhook = "webhook"
+	AuditSubjectAPIKey  = "api-key"
+)
+
+func (s AuditSubject) String() string {
+	if s.ID == "" {
+		return s.Kind
+	}
+	return s.Kind + ":" + s.ID
+}
+
+// AuditChange is one field of the subject changed by a call. Field is a path
+// into the subject's JSON document such as "status" or "lifts[<id>].status".
+type AuditChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// AuditEntry records one mutating API call. Entries are only ever appended.
+type AuditEntry struct {
+	Sequence  int64  `json:"sequence"`
+	Principal string `json:"principal"`
+	SourceIP  string `json:"source_ip"`
+	TraceID   string `json:"trace_id"`
+	Method    string `json:"method"`
+	// Route is the route pattern that handled the call, e.g.
+	// /api/v1/lifts/:id/status, and Path the path that was requested
+	Route   string `json:"route"`
+	Path    string `json:"path"`
+	Subject string `json:"subject,omitempty"`
+	// Payload is the request body, with secrets redacted and cut short
+	Payload string        `json:"payload,omitempty"`
+	Changes []AuditChange `json:"changes"`
+	Status  int           `json:"status"`
+	Outcome AuditOutcome  `json:"outcome"`
+	Error   string        `json:"error,omitempty"`
+	At      time.Time     `json:"at"`
+}
+
+// AuditFilter narrows an audit log query. Zero values leave a field
+// unfiltered.
+type AuditFilter struct {
+	Principal string
+	// Subject matches entries about a subject, e.g. lift:<id>
+	Subject string
+	Route   string
+	Method  string
+	Outcome AuditOutcome
+	From    time.Time // entries at or after this time
+	To      time.Time // entries before this time
+	Limit   int
+	Offset  int
+}
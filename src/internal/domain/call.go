@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// HallCallStatus is the state of a call made with a floor's hall button
+type HallCallStatus string
+
+const (
+	// CallPending calls are waiting to be given a lift. Calls made while every
+	// lift is busy stay pending until one becomes free.
+	CallPending HallCallStatus = "pending"
+	// CallAssigned calls have a lift on its way to the floor
+	CallAssigned HallCallStatus = "assigned"
+	// CallServed calls had a lift arrive at the floor
+	CallServed HallCallStatus = "served"
+	// CallCancelled calls were withdrawn by the caller
+	CallCancelled HallCallStatus = "cancelled"
+	// CallRejected calls could not be served; Reason says why
+	CallRejected HallCallStatus = "rejected"
+)
+
+// ParseHallCallStatus validates a hall call status name
+func ParseHallCallStatus(s string) (HallCallStatus, error) {
+	switch st := HallCallStatus(s); st {
+	case CallPending, CallAssigned, CallServed, CallCancelled, CallRejected:
+		return st, nil
+	default:
+		return "", fmt.Errorf("unknown call status: %s", s)
+	}
+}
+
+// Closed reports whether the call has reached a final state
+func (s HallCallStatus) Closed() bool {
+	return s == CallServed || s == CallCancelled || s == CallRejected
+}
+
+// HallCall is a request for a lift made from a floor
+type HallCall struct {
+	ID          string         `json:"id"`
+	FloorNumber int            `json:"floor"`
+	Direction   Direction      `json:"direction"`
+	Status      HallCallStatus `json:"status"`
+	LiftID      string         `json:"lift_id,omitempty"`
+	// Reason explains why a call was rejected
+	Reason string `json:"reason,omitempty"`
+	// ETA is the expected arrival of the assigned lift, while assigned
+	ETA        *time.Time `json:"eta,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AssignedAt *time.Time `json:"assigned_at,omitempty"`
+	// EscalatedAt is set when the call has waited longer than the dispatcher
+	// allows for a lift
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// HallCallFilter narrows a hall call listing. Zero values leave a field
+// unfiltered.
+type HallCallFilter struct {
+	Floor     *int
+	Direction *Direction
+	LiftID    string
+	Status    HallCallStatus
+	// OldestFirst lists the calls in the order they were made
+	OldestFirst bool
+	Limit       int
+	Offset      int
+}
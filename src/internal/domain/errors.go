@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error kinds. Every error that callers are expected to act on wraps exactly
+// one of these, so transports can map an error to a response without knowing
+// the specific error behind it.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrCapacityExceeded    = errors.New("capacity exceeded")
+	ErrInvalidTransition   = errors.New("invalid state transition")
+	ErrSystemNotConfigured = errors.New("system not configured")
+	ErrInvalidArgument     = errors.New("invalid argument")
+	ErrUnauthenticated     = errors.New("unauthenticated")
+	ErrForbidden           = errors.New("forbidden")
+	ErrIdempotencyMismatch = errors.New("idempotency key mismatch")
+	ErrRateLimited         = errors.New("rate limited")
+)
+
+var (
+	ErrFloorNotFound = kindError(ErrNotFound, "floor not found")
+	ErrLiftNotFound  = kindError(ErrNotFound, "lift not found")
+
+	ErrWebhookNotFound         = kindError(ErrNotFound, "webhook not found")
+	ErrWebhookDeliveryNotFound = kindError(ErrNotFound, "webhook delivery not found")
+	ErrMoveNotFound            = kindError(ErrNotFound, "move not found")
+	ErrHallCallNotFound        = kindError(ErrNotFound, "hall call not found")
+	ErrAPIKeyNotFound          = kindError(ErrNotFound, "API key not found")
+	ErrIdempotencyKeyNotFound  = kindError(ErrNotFound, "idempotency key not found")
+
+	ErrSystemAlreadyConfigured = kindError(ErrConflict, "system already configured")
+	ErrNoLiftAvailable         = kindError(ErrConflict, "no available lift found")
+	ErrLiftNameTaken           = kindError(ErrConflict, "lift name is already in use")
+	ErrLastLift                = kindError(ErrConflict, "the last lift in service cannot be decommissioned")
+	ErrIdempotencyKeyInUse     = kindError(ErrConflict, "a request with this idempotency key is still being handled")
+
+	ErrFloorAtCapacity = kindError(ErrCapacityExceeded, "floor has reached maximum lift capacity")
+	ErrLiftFull        = kindError(ErrCapacityExceeded, "lift cannot carry that many passengers")
+
+	ErrLiftAlreadyAtFloor  = kindError(ErrInvalidTransition, "lift is already on the requested floor")
+	ErrLiftBusy            = kindError(ErrInvalidTransition, "lift is already moving")
+	ErrLiftOutOfService    = kindError(ErrInvalidTransition, "lift is out of service")
+	ErrLiftDoorsHeld       = kindError(ErrInvalidTransition, "lift doors are being held open")
+	ErrDeliveryNotDead     = kindError(ErrInvalidTransition, "only dead-lettered deliveries can be retried")
+	ErrMoveFinished        = kindError(ErrInvalidTransition, "move has already finished")
+	ErrHallCallClosed      = kindError(ErrInvalidTransition, "hall call is already closed")
+	ErrLiftDecommissioning = kindError(ErrInvalidTransition, "lift is being decommissioned")
+	ErrAPIKeyRevoked       = kindError(ErrInvalidTransition, "API key is already revoked")
+
+	ErrInvalidDirection  = kindError(ErrInvalidArgument, "invalid direction")
+	ErrInvalidFloor      = kindError(ErrInvalidArgument, "invalid floor number")
+	ErrInvalidStatus     = kindError(ErrInvalidArgument, "invalid lift status")
+	ErrInvalidDoorHold   = kindError(ErrInvalidArgument, "invalid door hold duration")
+	ErrInvalidCapacity   = kindError(ErrInvalidArgument, "invalid lift capacity")
+	ErrInvalidPassengers = kindError(ErrInvalidArgument, "invalid passenger count")
+
+	ErrInvalidIdempotencyKey = kindError(ErrInvalidArgument, "invalid idempotency key")
+
+	ErrIdempotencyKeyReused = kindError(ErrIdempotencyMismatch, "idempotency key was already used for a different request")
+)
+
+// domainError is a specific error that belongs to one of the error kinds
+type domainError struct {
+	kind error
+	msg  string
+}
+
+func kindError(kind error, msg string) error {
+	return &domainError{kind: kind, msg: msg}
+}
+
+func (e *domainError) Error() string { return e.msg }
+
+func (e *domainError) Unwrap() error { return e.kind }
+
+// ValidationError reports every problem found while validating an input
+// document. It is an ErrInvalidArgument.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "validation failed: " + strings.Join(e.Problems, "; ")
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalidArgument }
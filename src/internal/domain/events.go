@@ -1,5 +1,12 @@
 package domain
 
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
 type EventType int
 
 const (
@@ -8,10 +15,102 @@ const (
 	LiftAssigned
 	FloorButtonPressed
 	FloorAtCapacity
+	LiftDeparted
+	LiftPassedFloor
+	LiftDoorsOpened
+	LiftDoorsClosed
+	LiftStatusChanged
+	LiftReset
+	HallCallCleared
+	MoveFinished
+	HallCallClosed
+	HallCallEscalated
+	LiftAdded
+	LiftUpdated
+	LiftDecommissioning
+	LiftRemoved
+	SystemReconfigured
+	SystemConfigured
+	SystemReset
 )
 
+var eventTypeNames = [...]string{
+	"LiftRequested",
+	"LiftArrived",
+	"LiftAssigned",
+	"FloorButtonPressed",
+	"FloorAtCapacity",
+	"LiftDeparted",
+	"LiftPassedFloor",
+	"LiftDoorsOpened",
+	"LiftDoorsClosed",
+	"LiftStatusChanged",
+	"LiftReset",
+	"HallCallCleared",
+	"MoveFinished",
+	"HallCallClosed",
+	"HallCallEscalated",
+	"LiftAdded",
+	"LiftUpdated",
+	"LiftDecommissioning",
+	"LiftRemoved",
+	"SystemReconfigured",
+	"SystemConfigured",
+	"SystemReset",
+}
+
 func (e EventType) String() string {
-	return [...]string{"LiftRequested", "LiftArrived", "LiftAssigned", "FloorButtonPressed", "FloorAtCapacity"}[e]
+	if e < 0 || int(e) >= len(eventTypeNames) {
+		return "Unknown"
+	}
+	return eventTypeNames[e]
+}
+
+// MarshalText renders the event type by name.
+func (e EventType) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText parses an event type name.
+func (e *EventType) UnmarshalText(text []byte) error {
+	t, err := ParseEventType(string(text))
+	if err != nil {
+		return err
+	}
+	*e = t
+	return nil
+}
+
+// ParseEventType returns the EventType with the given name.
+func ParseEventType(name string) (EventType, error) {
+	for i, n := range eventTypeNames {
+		if n == name {
+			return EventType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown event type: %s", name)
+}
+
+// ParseEventTypes parses a list of event type names.
+func ParseEventTypes(names []string) ([]EventType, error) {
+	types := make([]EventType, 0, len(names))
+	for _, name := range names {
+		t, err := ParseEventType(name)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// AllEventTypes returns every known event type.
+func AllEventTypes() []EventType {
+	types := make([]EventType, len(eventTypeNames))
+	for i := range eventTypeNames {
+		types[i] = EventType(i)
+	}
+	return types
 }
 
 type Event interface {
@@ -21,6 +120,7 @@ type Event interface {
 type LiftRequestedEvent struct {
 	FloorNumber int
 	Direction   Direction
+	CallID      string `json:",omitempty"`
 }
 
 func (e LiftRequestedEvent) Type() EventType {
@@ -53,3 +153,374 @@ type FloorAtCapacityEvent struct {
 func (e FloorAtCapacityEvent) Type() EventType {
 	return FloorAtCapacity
 }
+
+// LiftDepartedEvent is published when a lift leaves a floor towards a target
+type LiftDepartedEvent struct {
+	LiftID      string
+	FromFloor   int
+	TargetFloor int
+	Direction   Direction
+}
+
+func (e LiftDepartedEvent) Type() EventType {
+	return LiftDeparted
+}
+
+// LiftPassedFloorEvent is published each time a moving lift reaches a floor
+// on its way to the target
+type LiftPassedFloorEvent struct {
+	LiftID      string
+	FloorNumber int
+	TargetFloor int
+	Direction   Direction
+}
+
+func (e LiftPassedFloorEvent) Type() EventType {
+	return LiftPassedFloor
+}
+
+type LiftDoorsOpenedEvent struct {
+	LiftID      string
+	FloorNumber int
+}
+
+func (e LiftDoorsOpenedEvent) Type() EventType {
+	return LiftDoorsOpened
+}
+
+type LiftDoorsClosedEvent struct {
+	LiftID      string
+	FloorNumber int
+}
+
+func (e LiftDoorsClosedEvent) Type() EventType {
+	return LiftDoorsClosed
+}
+
+type LiftStatusChangedEvent struct {
+	LiftID string
+	Status LiftStatus
+}
+
+func (e LiftStatusChangedEvent) Type() EventType {
+	return LiftStatusChanged
+}
+
+// LiftResetEvent is published when a lift is returned to the ground floor
+// and made available
+type LiftResetEvent struct {
+	LiftID string
+}
+
+func (e LiftResetEvent) Type() EventType {
+	return LiftReset
+}
+
+// HallCallClearedEvent is published when the call buttons of a floor are
+// switched off
+type HallCallClearedEvent struct {
+	FloorNumber int
+}
+
+func (e HallCallClearedEvent) Type() EventType {
+	return HallCallCleared
+}
+
+// MoveFinishedEvent is published when a move reaches a final state. A
+// cancelled move leaves the lift at FinalFloor.
+type MoveFinishedEvent struct {
+	MoveID      string
+	LiftID      string
+	TargetFloor int
+	FinalFloor  int
+	Status      MoveStatus
+	Error       string `json:",omitempty"`
+}
+
+func (e MoveFinishedEvent) Type() EventType {
+	return MoveFinished
+}
+
+// HallCallClosedEvent is published when a hall call is served, cancelled or
+// rejected. ButtonCleared is set when closing the call turned off the hall
+// button, because no other call was waiting in the same direction.
+type HallCallClosedEvent struct {
+	CallID        string
+	FloorNumber   int
+	Direction     Direction
+	LiftID        string `json:",omitempty"`
+	Status        HallCallStatus
+	Reason        string `json:",omitempty"`
+	ButtonCleared bool   `json:",omitempty"`
+}
+
+func (e HallCallClosedEvent) Type() EventType {
+	return HallCallClosed
+}
+
+// HallCallEscalatedEvent is published when a call has waited longer than
+// allowed for a free lift
+type HallCallEscalatedEvent struct {
+	CallID       string
+	FloorNumber  int
+	Direction    Direction
+	WaitingSince time.Time
+}
+
+func (e HallCallEscalatedEvent) Type() EventType {
+	return HallCallEscalated
+}
+
+// LiftAddedEvent is published when a lift is added to a running system
+type LiftAddedEvent struct {
+	LiftID   string
+	Name     string
+	Capacity int
+}
+
+func (e LiftAddedEvent) Type() EventType {
+	return LiftAdded
+}
+
+// LiftUpdatedEvent is published when the name or capacity of a lift changes
+type LiftUpdatedEvent struct {
+	LiftID   string
+	Name     string
+	Capacity int
+}
+
+func (e LiftUpdatedEvent) Type() EventType {
+	return LiftUpdated
+}
+
+// LiftDecommissioningEvent is published when a lift stops taking new work.
+// It is removed once its assigned calls and queued moves are done.
+type LiftDecommissioningEvent struct {
+	LiftID string
+}
+
+func (e LiftDecommissioningEvent) Type() EventType {
+	return LiftDecommissioning
+}
+
+// LiftRemovedEvent is published when a decommissioned lift has been drained
+// and removed from the system
+type LiftRemovedEvent struct {
+	LiftID string
+}
+
+func (e LiftRemovedEvent) Type() EventType {
+	return LiftRemoved
+}
+
+// SystemReconfiguredEvent is published when floors or lifts are added to or
+// removed from a running system
+type SystemReconfiguredEvent struct {
+	TotalFloors int
+	TotalLifts  int
+}
+
+func (e SystemReconfiguredEvent) Type() EventType {
+	return SystemReconfigured
+}
+
+// SystemConfiguredEvent is published when a system has been configured or
+// restored from a snapshot
+type SystemConfiguredEvent struct {
+	SystemID    string
+	TotalFloors int
+	TotalLifts  int
+}
+
+func (e SystemConfiguredEvent) Type() EventType {
+	return SystemConfigured
+}
+
+// SystemResetEvent is published when the system has been reset and must be
+// configured again
+type SystemResetEvent struct {
+	SystemID string
+}
+
+func (e SystemResetEvent) Type() EventType {
+	return SystemReset
+}
+
+// EventFilter selects events by type and by the lifts and floors they
+// concern. An empty field matches every event.
+type EventFilter struct {
+	Types   []EventType
+	LiftIDs []string
+	Floors  []int
+}
+
+// Matches reports whether the event passes every non-empty field of the filter
+func (f EventFilter) Matches(event Event) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type()) {
+		return false
+	}
+
+	liftID, floors := eventScope(event)
+	if len(f.LiftIDs) > 0 && !slices.Contains(f.LiftIDs, liftID) {
+		return false
+	}
+	if len(f.Floors) > 0 && !slices.ContainsFunc(floors, func(n int) bool { return slices.Contains(f.Floors, n) }) {
+		return false
+	}
+	return true
+}
+
+// eventScope returns the lift and the floors an event concerns. A departure
+// concerns both the floor it leaves and its target.
+func eventScope(event Event) (string, []int) {
+	switch e := event.(type) {
+	case LiftRequestedEvent:
+		return "", []int{e.FloorNumber}
+	case LiftArrivedEvent:
+		return e.LiftID, []int{e.FloorNumber}
+	case LiftAssignedEvent:
+		return e.LiftID, []int{e.FloorNumber}
+	case FloorAtCapacityEvent:
+		return "", []int{e.FloorNumber}
+	case LiftDepartedEvent:
+		return e.LiftID, []int{e.FromFloor, e.TargetFloor}
+	case LiftPassedFloorEvent:
+		return e.LiftID, []int{e.FloorNumber}
+	case LiftDoorsOpenedEvent:
+		return e.LiftID, []int{e.FloorNumber}
+	case LiftDoorsClosedEvent:
+		return e.LiftID, []int{e.FloorNumber}
+	case LiftStatusChangedEvent:
+		return e.LiftID, nil
+	case LiftResetEvent:
+		return e.LiftID, nil
+	case HallCallClearedEvent:
+		return "", []int{e.FloorNumber}
+	case MoveFinishedEvent:
+		return e.LiftID, []int{e.TargetFloor, e.FinalFloor}
+	case HallCallClosedEvent:
+		return e.LiftID, []int{e.FloorNumber}
+	case HallCallEscalatedEvent:
+		return "", []int{e.FloorNumber}
+	case LiftAddedEvent:
+		return e.LiftID, nil
+	case LiftUpdatedEvent:
+		return e.LiftID, nil
+	case LiftDecommissioningEvent:
+		return e.LiftID, nil
+	case LiftRemovedEvent:
+		return e.LiftID, nil
+	default:
+		return "", nil
+	}
+}
+
+// Envelope carries an event together with the metadata needed to follow it
+// through the system. Events published while handling another event share
+// its correlation ID and name it as their cause.
+type Envelope struct {
+	ID            string
+	OccurredAt    time.Time
+	SystemID      string
+	CausationID   string
+	CorrelationID string
+	TraceID       string
+	Event         Event
+}
+
+// StoredEvent is an event as recorded in the append-only event store
+type StoredEvent struct {
+	Sequence int64
+	Envelope
+}
+
+// MarshalJSON renders the event type by name together with its payload.
+func (e StoredEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Sequence      int64     `json:"sequence"`
+		ID            string    `json:"id"`
+		SystemID      string    `json:"system_id"`
+		OccurredAt    time.Time `json:"occurred_at"`
+		CausationID   string    `json:"causation_id,omitempty"`
+		CorrelationID string    `json:"correlation_id,omitempty"`
+		TraceID       string    `json:"trace_id,omitempty"`
+		Type          string    `json:"type"`
+		Payload       Event     `json:"payload"`
+	}{
+		Sequence:      e.Sequence,
+		ID:            e.ID,
+		SystemID:      e.SystemID,
+		OccurredAt:    e.OccurredAt,
+		CausationID:   e.CausationID,
+		CorrelationID: e.CorrelationID,
+		TraceID:       e.TraceID,
+		Type:          e.Event.Type().String(),
+		Payload:       e.Event,
+	})
+}
+
+// DecodeEvent rebuilds an event of the given type from its JSON payload.
+func DecodeEvent(eventType EventType, payload []byte) (Event, error) {
+	var event Event
+	var err error
+
+	switch eventType {
+	case LiftRequested:
+		event, err = decode[LiftRequestedEvent](payload)
+	case LiftArrived:
+		event, err = decode[LiftArrivedEvent](payload)
+	case LiftAssigned:
+		event, err = decode[LiftAssignedEvent](payload)
+	case FloorAtCapacity:
+		event, err = decode[FloorAtCapacityEvent](payload)
+	case LiftDeparted:
+		event, err = decode[LiftDepartedEvent](payload)
+	case LiftPassedFloor:
+		event, err = decode[LiftPassedFloorEvent](payload)
+	case LiftDoorsOpened:
+		event, err = decode[LiftDoorsOpenedEvent](payload)
+	case LiftDoorsClosed:
+		event, err = decode[LiftDoorsClosedEvent](payload)
+	case LiftStatusChanged:
+		event, err = decode[LiftStatusChangedEvent](payload)
+	case LiftReset:
+		event, err = decode[LiftResetEvent](payload)
+	case HallCallCleared:
+		event, err = decode[HallCallClearedEvent](payload)
+	case MoveFinished:
+		event, err = decode[MoveFinishedEvent](payload)
+	case HallCallClosed:
+		event, err = decode[HallCallClosedEvent](payload)
+	case HallCallEscalated:
+		event, err = decode[HallCallEscalatedEvent](payload)
+	case LiftAdded:
+		event, err = decode[LiftAddedEvent](payload)
+	case LiftUpdated:
+		event, err = decode[LiftUpdatedEvent](payload)
+	case LiftDecommissioning:
+		event, err = decode[LiftDecommissioningEvent](payload)
+	case LiftRemoved:
+		event, err = decode[LiftRemovedEvent](payload)
+	case SystemReconfigured:
+		event, err = decode[SystemReconfiguredEvent](payload)
+	case SystemConfigured:
+		event, err = decode[SystemConfiguredEvent](payload)
+	case SystemReset:
+		event, err = decode[SystemResetEvent](payload)
+	default:
+		return nil, fmt.Errorf("cannot decode event type: %s", eventType)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s event: %w", eventType, err)
+	}
+	return event, nil
+}
+
+func decode[T Event](payload []byte) (Event, error) {
+	var e T
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
@@ -1,14 +1,5 @@
 package domain
 
-import (
-	"errors"
-)
-
-var (
-	ErrFloorNotFound = errors.New("floor not found")
-	ErrLiftNotFound  = errors.New("lift not found")
-)
-
 // Floor represents a floor in the lift system
 type Floor struct {
 	ID               string
@@ -54,7 +45,7 @@ func (f *Floor) RequestLift(direction Direction) error {
 	case Down:
 		f.DownButtonActive = true
 	default:
-		return errors.New("invalid direction")
+		return ErrInvalidDirection
 	}
 	return nil
 }
@@ -67,7 +58,7 @@ func (f *Floor) CancelRequest(direction Direction) error {
 	case Down:
 		f.DownButtonActive = false
 	default:
-		return errors.New("invalid direction")
+		return ErrInvalidDirection
 	}
 	return nil
 }
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord remembers the first response to a request sent with an
+// idempotency key, so that a retry of the request is answered with the same
+// response instead of being carried out again. Keys belong to the principal
+// that used them.
+type IdempotencyRecord struct {
+	Principal string
+	Key       string
+	Method    string
+	Path      string
+	// RequestHash identifies the request the key was first used for
+	RequestHash string
+	// Status is zero while the first request is still being handled
+	Status      int
+	ContentType string
+	Location    string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether the response to the first request is known
+func (r *IdempotencyRecord) Completed() bool {
+	return r.Status != 0
+}
@@ -36,6 +36,16 @@ type Lift struct {
 	LastMoveTime time.Time  `json:"last_move_time"`
 }
 
+// DefaultLiftCapacity is the number of passengers a new lift can carry
+const DefaultLiftCapacity = 10
+
+// LiftChange is a change to the settings of a lift. Nil fields are left as
+// they are.
+type LiftChange struct {
+	Name     *string
+	Capacity *int
+}
+
 // NewLift creates a new Lift instance
 func NewLift(id, name string) *Lift {
 	return &Lift{
@@ -44,7 +54,7 @@ func NewLift(id, name string) *Lift {
 		CurrentFloor: 0, // Start at ground floor (0-based)
 		Direction:    Idle,
 		Status:       Available,
-		Capacity:     10,
+		Capacity:     DefaultLiftCapacity,
 		LastMoveTime: time.Now(),
 	}
 }
@@ -72,8 +82,35 @@ func (l *Lift) IsAvailable() bool {
 }
 
 func (l *Lift) MoveTo(floor int) error {
-	if floor == l.CurrentFloor {
-		return errors.New("lift is already on the requested floor")
+	if err := l.Depart(floor); err != nil {
+		return err
+	}
+
+	// Simulate movement time (2 seconds per floor)
+	for l.CurrentFloor != l.TargetFloor {
+		time.Sleep(FloorTravelTime)
+		l.Step()
+	}
+
+	l.Arrive()
+	return nil
+}
+
+// FloorTravelTime is the simulated time a lift needs to travel one floor
+const FloorTravelTime = 2 * time.Second
+
+// MaxDoorHold is the longest the doors of a lift may be held open at once
+const MaxDoorHold = time.Minute
+
+// Depart starts a journey towards the given floor
+func (l *Lift) Depart(floor int) error {
+	switch {
+	case l.Status == OutOfService:
+		return ErrLiftOutOfService
+	case l.Status == Occupied:
+		return ErrLiftBusy
+	case floor == l.CurrentFloor:
+		return ErrLiftAlreadyAtFloor
 	}
 
 	l.TargetFloor = floor
@@ -84,15 +121,27 @@ func (l *Lift) MoveTo(floor int) error {
 	}
 	l.Status = Occupied
 
-	// Simulate movement time (2 seconds per floor)
-	time.Sleep(time.Duration(abs(floor-l.CurrentFloor)) * 2 * time.Second)
+	return nil
+}
 
-	l.CurrentFloor = floor
+// Step moves the lift one floor towards its target and returns the floor it
+// has reached
+func (l *Lift) Step() int {
+	switch {
+	case l.CurrentFloor < l.TargetFloor:
+		l.CurrentFloor++
+	case l.CurrentFloor > l.TargetFloor:
+		l.CurrentFloor--
+	}
+	return l.CurrentFloor
+}
+
+// Arrive completes the current journey and makes the lift available again
+func (l *Lift) Arrive() {
+	l.CurrentFloor = l.TargetFloor
 	l.Direction = Idle
 	l.Status = Available
 	l.LastMoveTime = time.Now()
-
-	return nil
 }
 
 func (l *Lift) RemovePassengers(count int) error {
@@ -156,10 +205,3 @@ func StringToLiftStatus(status string) LiftStatus {
 		return Available // Default to Available if unknown status
 	}
 }
-
-func abs(x int) int {
-	if x < 0 {
-		return -x
-	}
-	return x
-}
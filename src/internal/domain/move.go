@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MoveStatus is the state of a lift move carried out in the background
+type MoveStatus string
+
+const (
+	// MoveQueued moves wait for the moves queued before them on the same lift
+	MoveQueued MoveStatus = "queued"
+	// MoveInProgress moves are travelling
+	MoveInProgress MoveStatus = "in_progress"
+	// MoveCompleted moves reached their target floor
+	MoveCompleted MoveStatus = "completed"
+	// MoveFailed moves could not be carried out
+	MoveFailed MoveStatus = "failed"
+	// MoveCancelled moves were cancelled before they reached the target; a
+	// lift already travelling stops at the next floor
+	MoveCancelled MoveStatus = "cancelled"
+)
+
+// ParseMoveStatus validates a move status name
+func ParseMoveStatus(s string) (MoveStatus, error) {
+	switch st := MoveStatus(s); st {
+	case MoveQueued, MoveInProgress, MoveCompleted, MoveFailed, MoveCancelled:
+		return st, nil
+	default:
+		return "", fmt.Errorf("unknown move status: %s", s)
+	}
+}
+
+// Finished reports whether the move has reached a final state
+func (s MoveStatus) Finished() bool {
+	return s == MoveCompleted || s == MoveFailed || s == MoveCancelled
+}
+
+// Move is a request to take a lift to a floor
+type Move struct {
+	ID          string      `json:"id"`
+	LiftID      string      `json:"lift_id"`
+	TargetFloor int         `json:"target_floor"`
+	Trigger     TripTrigger `json:"trigger"`
+	Status      MoveStatus  `json:"status"`
+	// Passengers board when the lift departs and get off where it stops
+	Passengers int `json:"passengers,omitempty"`
+	// CurrentFloor is the floor the lift was last seen at
+	CurrentFloor int `json:"current_floor"`
+	// ETA is the expected arrival at the target floor, while unfinished
+	ETA        *time.Time `json:"eta,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// MoveFilter narrows a move listing. Zero values leave a field unfiltered.
+type MoveFilter struct {
+	LiftID string
+	Status MoveStatus
+}
@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot document format written by
+// this build. Imports of any other version are rejected.
+const SnapshotVersion = 1
+
+// Snapshot is a versioned, self-contained copy of a building configuration
+// and its live state
+type Snapshot struct {
+	Version     int                  `json:"version"`
+	ExportedAt  time.Time            `json:"exported_at"`
+	System      SnapshotSystem       `json:"system"`
+	Floors      []SnapshotFloor      `json:"floors"`
+	Lifts       []SnapshotLift       `json:"lifts"`
+	Assignments []SnapshotAssignment `json:"assignments"`
+	ActiveCalls []SnapshotCall       `json:"active_calls"`
+}
+
+type SnapshotSystem struct {
+	ID          string `json:"id"`
+	TotalFloors int    `json:"total_floors"`
+	TotalLifts  int    `json:"total_lifts"`
+}
+
+type SnapshotFloor struct {
+	ID     string `json:"id"`
+	Number int    `json:"number"`
+}
+
+type SnapshotLift struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	CurrentFloor int    `json:"current_floor"`
+	Status       string `json:"status"`
+	Capacity     int    `json:"capacity"`
+}
+
+// SnapshotAssignment records a lift parked at a floor
+type SnapshotAssignment struct {
+	LiftID      string `json:"lift_id"`
+	FloorNumber int    `json:"floor_number"`
+}
+
+// SnapshotCall records a hall call still waiting to be served. Snapshots
+// written before calls carried a status and creation time list only the lit
+// buttons; those calls are restored as pending since the export.
+type SnapshotCall struct {
+	FloorNumber int            `json:"floor_number"`
+	Direction   Direction      `json:"direction"`
+	Status      HallCallStatus `json:"status,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// Validate checks that the snapshot is a consistent document that can be
+// imported as-is. All problems are reported together in a ValidationError.
+func (s *Snapshot) Validate() error {
+	var problems []string
+	fail := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if s.Version != SnapshotVersion {
+		fail("unsupported snapshot version %d, expected %d", s.Version, SnapshotVersion)
+	}
+
+	if s.System.ID == "" {
+		fail("system.id is required")
+	}
+	if s.System.TotalFloors < 2 {
+		fail("system.total_floors must be at least 2")
+	}
+	if s.System.TotalLifts < 1 {
+		fail("system.total_lifts must be at least 1")
+	}
+	if len(s.Floors) != s.System.TotalFloors {
+		fail("expected %d floors, got %d", s.System.TotalFloors, len(s.Floors))
+	}
+	if len(s.Lifts) != s.System.TotalLifts {
+		fail("expected %d lifts, got %d", s.System.TotalLifts, len(s.Lifts))
+	}
+
+	floorIDs := make(map[string]bool, len(s.Floors))
+	floorNums := make(map[int]bool, len(s.Floors))
+	for i, f := range s.Floors {
+		switch {
+		case f.ID == "":
+			fail("floors[%d].id is required", i)
+		case floorIDs[f.ID]:
+			fail("floors[%d].id %q is duplicated", i, f.ID)
+		}
+		if f.Number < 0 || f.Number >= s.System.TotalFloors {
+			fail("floors[%d].number %d is out of range", i, f.Number)
+		} else if floorNums[f.Number] {
+			fail("floors[%d].number %d is duplicated", i, f.Number)
+		}
+		floorIDs[f.ID] = true
+		floorNums[f.Number] = true
+	}
+
+	liftIDs := make(map[string]bool, len(s.Lifts))
+	liftNames := make(map[string]bool, len(s.Lifts))
+	for i, l := range s.Lifts {
+		switch {
+		case l.ID == "":
+			fail("lifts[%d].id is required", i)
+		case liftIDs[l.ID]:
+			fail("lifts[%d].id %q is duplicated", i, l.ID)
+		}
+		switch {
+		case l.Name == "":
+			fail("lifts[%d].name is required", i)
+		case liftNames[l.Name]:
+			fail("lifts[%d].name %q is duplicated", i, l.Name)
+		}
+		if !floorNums[l.CurrentFloor] {
+			fail("lifts[%d].current_floor %d does not exist", i, l.CurrentFloor)
+		}
+		if LiftStatusToString(StringToLiftStatus(l.Status)) != l.Status {
+			fail("lifts[%d].status %q is not a valid status", i, l.Status)
+		}
+		if l.Capacity < 1 {
+			fail("lifts[%d].capacity must be at least 1", i)
+		}
+		liftIDs[l.ID] = true
+		liftNames[l.Name] = true
+	}
+
+	for i, a := range s.Assignments {
+		if !liftIDs[a.LiftID] {
+			fail("assignments[%d].lift_id %q does not exist", i, a.LiftID)
+		}
+		if !floorNums[a.FloorNumber] {
+			fail("assignments[%d].floor_number %d does not exist", i, a.FloorNumber)
+		}
+	}
+
+	for i, c := range s.ActiveCalls {
+		if !floorNums[c.FloorNumber] {
+			fail("active_calls[%d].floor_number %d does not exist", i, c.FloorNumber)
+		}
+		if c.Direction != Up && c.Direction != Down {
+			fail("active_calls[%d].direction must be 0 (Up) or 1 (Down)", i)
+		}
+		switch c.Status {
+		case "", CallPending, CallAssigned:
+		default:
+			fail("active_calls[%d].status %q is not an open call status", i, c.Status)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -34,6 +34,22 @@ func (s *System) GetTotalLifts() int {
 	return s.TotalLifts
 }
 
+// SystemChange is a change to the building made while the system is running.
+// Nil fields are left as they are.
+type SystemChange struct {
+	Floors *int
+	Lifts  *int
+}
+
+// Reconfiguration is the outcome of a SystemChange. Lifts being drained
+// still count towards TotalLifts until they are removed.
+type Reconfiguration struct {
+	TotalFloors   int      `json:"total_floors"`
+	TotalLifts    int      `json:"total_lifts"`
+	AddedLifts    []*Lift  `json:"added_lifts"`
+	DrainingLifts []string `json:"draining_lifts"`
+}
+
 // SystemStatus represents the current status of the lift system
 type SystemStatus struct {
 	SystemID         string
@@ -53,3 +69,13 @@ func NewSystemStatus(system *System, operationalLifts, activeFloorCalls int) *Sy
 		ActiveFloorCalls: activeFloorCalls,
 	}
 }
+
+// SystemMetrics summarises the system and the state of its lifts
+type SystemMetrics struct {
+	SystemID          string `json:"systemID"`
+	TotalFloors       int    `json:"totalFloors"`
+	TotalLifts        int    `json:"totalLifts"`
+	AvailableLifts    int    `json:"availableLifts"`
+	OccupiedLifts     int    `json:"occupiedLifts"`
+	OutOfServiceLifts int    `json:"outOfServiceLifts"`
+}
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// TripTrigger describes why a lift made a trip
+type TripTrigger string
+
+const (
+	TripHallCall   TripTrigger = "hall_call"
+	TripCarCall    TripTrigger = "car_call"
+	TripManualMove TripTrigger = "manual_move"
+	TripParking    TripTrigger = "parking"
+)
+
+// ParseTripTrigger validates a trip trigger name
+func ParseTripTrigger(s string) (TripTrigger, error) {
+	switch t := TripTrigger(s); t {
+	case TripHallCall, TripCarCall, TripManualMove, TripParking:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown trip trigger: %s", s)
+	}
+}
+
+// Trip is a completed movement of a lift from one floor to another
+type Trip struct {
+	ID               string      `json:"id"`
+	LiftID           string      `json:"lift_id"`
+	OriginFloor      int         `json:"origin_floor"`
+	DestinationFloor int         `json:"destination_floor"`
+	StartedAt        time.Time   `json:"started_at"`
+	EndedAt          time.Time   `json:"ended_at"`
+	Trigger          TripTrigger `json:"trigger"`
+	Passengers       int         `json:"passengers"`
+}
+
+// FloorsTravelled returns the number of floors covered by the trip
+func (t *Trip) FloorsTravelled() int {
+	if t.DestinationFloor > t.OriginFloor {
+		return t.DestinationFloor - t.OriginFloor
+	}
+	return t.OriginFloor - t.DestinationFloor
+}
+
+// TripFilter narrows a trip history query. Zero values leave a field
+// unfiltered.
+type TripFilter struct {
+	LiftID  string
+	Floor   *int      // trips starting or ending at this floor
+	From    time.Time // trips still running at or after this time
+	To      time.Time // trips started before this time
+	Trigger TripTrigger
+	Limit   int
+	Offset  int
+}
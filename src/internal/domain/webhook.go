@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Webhook is a subscription of an external HTTP endpoint to domain events
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// EventTypes limits the events delivered to the endpoint. An empty list
+	// subscribes to every event type.
+	EventTypes []EventType `json:"event_types"`
+	// Secret is the key deliveries are signed with. It is only shown when the
+	// webhook is created.
+	Secret    string    `json:"-"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Matches reports whether events of the given type are delivered to the webhook
+func (w *Webhook) Matches(eventType EventType) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the state of a single event delivery to a webhook
+type WebhookDeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt
+	DeliveryPending WebhookDeliveryStatus = "pending"
+	// DeliveryDelivered deliveries were accepted by the endpoint
+	DeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// DeliveryDead deliveries ran out of attempts and sit in the dead-letter
+	// list until they are retried by hand
+	DeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// ParseWebhookDeliveryStatus validates a delivery status name
+func ParseWebhookDeliveryStatus(s string) (WebhookDeliveryStatus, error) {
+	switch st := WebhookDeliveryStatus(s); st {
+	case DeliveryPending, DeliveryDelivered, DeliveryDead:
+		return st, nil
+	default:
+		return "", fmt.Errorf("unknown delivery status: %s", s)
+	}
+}
+
+// WebhookDelivery is one event queued for delivery to one webhook
+type WebhookDelivery struct {
+	ID            string                `json:"id"`
+	WebhookID     string                `json:"webhook_id"`
+	EventID       string                `json:"event_id"`
+	EventType     EventType             `json:"event_type"`
+	Payload       []byte                `json:"-"`
+	Status        WebhookDeliveryStatus `json:"status"`
+	Attempts      int                   `json:"attempts"`
+	LastError     string                `json:"last_error,omitempty"`
+	NextAttemptAt time.Time             `json:"next_attempt_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+// WebhookAttempt records one HTTP request made for a delivery
+type WebhookAttempt struct {
+	DeliveryID  string    `json:"delivery_id"`
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// WebhookDeliveryFilter narrows a delivery query. Zero values leave a field
+// unfiltered.
+type WebhookDeliveryFilter struct {
+	WebhookID string
+	Status    WebhookDeliveryStatus
+	Limit     int
+}
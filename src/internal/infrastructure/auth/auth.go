@@ -0,0 +1,229 @@
+// Package auth authenticates the JWTs presented to the API and defines the
+// roles that decide what a caller may do. Tokens are signed with RS256 or
+// EdDSA keys kept as <kid>.pem files in a keys folder.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsKey is the fiber local under which authenticated claims are stored
+const ClaimsKey = "claims"
+
+// Role grants access to a set of operations. Viewer, operator, maintenance
+// and admin are ranked: each may do everything the roles below it may. The
+// caller role stands apart and is only granted where it is named.
+type Role string
+
+const (
+	// RoleViewer may read the state of the building and follow its updates
+	RoleViewer Role = "viewer"
+	// RoleOperator may also call lifts, move them and cancel calls and moves
+	RoleOperator Role = "operator"
+	// RoleMaintenance may also change the status of lifts, hold their doors,
+	// reset them and add, change or decommission lifts
+	RoleMaintenance Role = "maintenance"
+	// RoleAdmin may also configure, reconfigure and reset the system, import
+	// snapshots, replay events and manage webhooks
+	RoleAdmin Role = "admin"
+	// RoleCaller may only place, follow and cancel hall calls. It is held by
+	// calls-only API keys, such as those of building management gateways.
+	RoleCaller Role = "caller"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:      1,
+	RoleOperator:    2,
+	RoleMaintenance: 3,
+	RoleAdmin:       4,
+}
+
+// ParseRole validates a role name
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := roleRanks[role]; !ok && role != RoleCaller {
+		return "", fmt.Errorf("unknown role: %s", s)
+	}
+	return role, nil
+}
+
+// scopeRoles is the role granted by each API key scope
+var scopeRoles = map[domain.APIKeyScope]Role{
+	domain.ScopeReadOnly:  RoleViewer,
+	domain.ScopeCallsOnly: RoleCaller,
+	domain.ScopeAdmin:     RoleAdmin,
+}
+
+// apiKeySubjectPrefix starts the subject of every caller holding an API key
+const apiKeySubjectPrefix = "apikey:"
+
+// APIKeyClaims returns the claims of a caller holding an API key. The
+// subject names the key, so its actions can be told apart from a user's.
+func APIKeyClaims(key *domain.APIKey) Claims {
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: apiKeySubjectPrefix + key.ID,
+		},
+	}
+	if key.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*key.ExpiresAt)
+	}
+	for _, scope := range key.Scopes {
+		claims.Roles = append(claims.Roles, scopeRoles[scope])
+	}
+	return claims
+}
+
+// Claims are the claims of a token issued to a caller of the API
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []Role `json:"roles"`
+}
+
+// IsAPIKey reports whether the caller holds an API key rather than a token
+func (c Claims) IsAPIKey() bool {
+	return strings.HasPrefix(c.Subject, apiKeySubjectPrefix)
+}
+
+// Allows reports whether any of the caller's roles grants the role
+func (c Claims) Allows(role Role) bool {
+	required, ok := roleRanks[role]
+	if !ok {
+		return slices.Contains(c.Roles, role)
+	}
+	return slices.ContainsFunc(c.Roles, func(r Role) bool { return roleRanks[r] >= required })
+}
+
+// Config locates the signing keys and names the expected issuer
+type Config struct {
+	KeysFolder string
+	// ActiveKID is the key that signs new tokens, and the key that verifies
+	// tokens without a kid header
+	ActiveKID string
+	Issuer    string
+}
+
+// Auth verifies and issues tokens
+type Auth struct {
+	keys      map[string]*key
+	activeKID string
+	issuer    string
+	parser    *jwt.Parser
+}
+
+// New loads the keys in cfg.KeysFolder. The active key must be among them.
+// When no active key is named, the folder must hold a single private key,
+// which becomes the active key.
+func New(cfg Config) (*Auth, error) {
+	keys, err := loadKeys(cfg.KeysFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	activeKID := cfg.ActiveKID
+	if activeKID == "" {
+		activeKID, err = soleSigningKey(keys, cfg.KeysFolder)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := keys[activeKID]; !ok {
+		return nil, fmt.Errorf("active key %q not found in %s", activeKID, cfg.KeysFolder)
+	}
+
+	return &Auth{
+		keys:      keys,
+		activeKID: activeKID,
+		issuer:    cfg.Issuer,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+			jwt.WithIssuer(cfg.Issuer),
+			jwt.WithExpirationRequired(),
+		),
+	}, nil
+}
+
+// Authenticate verifies a token and returns its claims. The token must be
+// signed by a known key with the algorithm that goes with the key, come from
+// the configured issuer, not be expired and carry at least one known role.
+func (a *Auth) Authenticate(tokenString string) (Claims, error) {
+	var claims Claims
+	_, err := a.parser.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = a.activeKID
+		}
+
+		k, ok := a.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		if token.Method.Alg() != k.method.Alg() {
+			return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+		}
+		return k.public, nil
+	})
+	if err != nil {
+		return Claims{}, err
+	}
+
+	claims.Roles = slices.DeleteFunc(claims.Roles, func(r Role) bool {
+		_, err := ParseRole(string(r))
+		return err != nil
+	})
+	if len(claims.Roles) == 0 {
+		return Claims{}, errors.New("token carries no known role")
+	}
+	return claims, nil
+}
+
+// GenerateToken signs a token for subject with the active key, valid for ttl
+func (a *Auth) GenerateToken(subject string, roles []Role, ttl time.Duration) (string, error) {
+	k := a.keys[a.activeKID]
+	if k.private == nil {
+		return "", fmt.Errorf("active key %q has no private key", a.activeKID)
+	}
+
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    a.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Roles: roles,
+	}
+
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = a.activeKID
+
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, nil
+}
+
+type ctxKey int
+
+const claimsCtxKey ctxKey = iota
+
+// WithClaims returns a context carrying the caller's claims
+func WithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsCtxKey, claims)
+}
+
+// GetClaims returns the claims of the caller, if any
+func GetClaims(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsCtxKey).(Claims)
+	return claims, ok
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// key is a signing key loaded from the keys folder. Folders deployed to
+// services that only verify tokens may hold public keys alone.
+type key struct {
+	method  jwt.SigningMethod
+	public  crypto.PublicKey
+	private crypto.Signer // nil for a public key
+}
+
+// compromisedKIDs names keys whose private half has been published. They
+// are refused wherever they turn up so tokens signed with them are never
+// trusted.
+var compromisedKIDs = map[string]bool{
+	"54bb2165-71e1-41a6-af3e-7da4a0e1e2c1": true,
+}
+
+// loadKeys reads every <kid>.pem file in folder. A file holds an RSA key,
+// used with RS256, or an Ed25519 key, used with EdDSA, as a PKCS #8 or
+// PKCS #1 private key or a PKIX public key.
+func loadKeys(folder string) (map[string]*key, error) {
+	paths, err := filepath.Glob(filepath.Join(folder, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	keys := make(map[string]*key, len(paths))
+	for _, path := range paths {
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		if compromisedKIDs[kid] {
+			return nil, fmt.Errorf("key %s is compromised: delete it and generate a new one", kid)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key: %w", err)
+		}
+
+		k, err := parseKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %s: %w", filepath.Base(path), err)
+		}
+
+		keys[kid] = k
+	}
+	return keys, nil
+}
+
+// soleSigningKey names the only private key in keys
+func soleSigningKey(keys map[string]*key, folder string) (string, error) {
+	var kids []string
+	for kid, k := range keys {
+		if k.private != nil {
+			kids = append(kids, kid)
+		}
+	}
+	if len(kids) != 1 {
+		return "", fmt.Errorf("no active key named and %s holds %d private keys", folder, len(kids))
+	}
+	return kids[0], nil
+}
+
+// GenerateKey writes a new Ed25519 signing key to folder as <kid>.pem and
+// returns its kid. The file is readable by its owner only.
+func GenerateKey(folder string) (string, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if err := os.MkdirAll(folder, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create keys folder: %w", err)
+	}
+
+	kid := uuid.New().String()
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(folder, kid+".pem"), data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write key: %w", err)
+	}
+	return kid, nil
+}
+
+func parseKey(data []byte) (*key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", private)
+		}
+		k, err := newKey(signer.Public())
+		if err != nil {
+			return nil, err
+		}
+		k.private = signer
+		return k, nil
+
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &key{method: jwt.SigningMethodRS256, public: private.Public(), private: private}, nil
+
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return newKey(public)
+
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+// newKey picks the signing method that goes with a public key
+func newKey(public crypto.PublicKey) (*key, error) {
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return &key{method: jwt.SigningMethodRS256, public: public}, nil
+	case ed25519.PublicKey:
+		return &key{method: jwt.SigningMethodEdDSA, public: public}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, must be RSA or Ed25519", public)
+	}
+}
@@ -1,9 +1,27 @@
 package eventbus
 
 import (
+	"fmt"
+
 	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/config"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
 )
 
-func ProvideEventBus() events.EventBus {
-	return events.NewInMemoryEventBus()
+// ProvideEventBus builds the partitioned event bus described by the
+// configuration. The observer, if any, is told how long every handler took.
+// The caller owns the bus and must Close it on shutdown.
+func ProvideEventBus(cfg config.Config, observer events.HandlerObserver, log *logger.Logger) (*events.PartitionedEventBus, error) {
+	overflow, err := events.ParseOverflowPolicy(cfg.EventBus.Overflow)
+	if err != nil {
+		return nil, fmt.Errorf("configuring event bus: %w", err)
+	}
+
+	return events.NewPartitionedEventBus(events.PartitionedBusConfig{
+		Workers:        cfg.EventBus.Workers,
+		QueueCapacity:  cfg.EventBus.QueueCapacity,
+		Overflow:       overflow,
+		PublishTimeout: cfg.EventBus.PublishTimeout,
+		Observer:       observer,
+	}, log), nil
 }
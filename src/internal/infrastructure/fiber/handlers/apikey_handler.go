@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+// APIKeyHandler handles HTTP requests related to the API keys of machine
+// clients
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler instance
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey handles POST requests to issue an API key. The response is the
+// only place the key is returned.
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var input services.APIKeyInput
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	claims, _ := auth.GetClaims(c.UserContext())
+
+	key, secret, err := h.apiKeyService.CreateAPIKey(c.UserContext(), input, claims.Subject)
+	if err != nil {
+		return err
+	}
+
+	c.Location("/api/v1/api-keys/" + key.ID)
+	return c.Status(fiber.StatusCreated).JSON(struct {
+		*domain.APIKey
+		Key string `json:"key"`
+	}{key, secret})
+}
+
+// ListAPIKeys handles GET requests to list all API keys
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.apiKeyService.ListAPIKeys(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	if keys == nil {
+		keys = []*domain.APIKey{}
+	}
+
+	return c.JSON(keys)
+}
+
+// GetAPIKey handles GET requests to retrieve a specific API key
+func (h *APIKeyHandler) GetAPIKey(c *fiber.Ctx) error {
+	key, err := h.apiKeyService.GetAPIKey(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(key)
+}
+
+// RevokeAPIKey handles DELETE requests to revoke an API key
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	key, err := h.apiKeyService.RevokeAPIKey(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(key)
+}
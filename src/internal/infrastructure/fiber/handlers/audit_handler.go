@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultAuditPageSize = 100
+	maxAuditPageSize     = 1000
+)
+
+// AuditHandler handles HTTP requests for the audit log
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+// NewAuditHandler creates a new AuditHandler instance
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListAudit handles GET requests to read the audit log, newest first
+func (h *AuditHandler) ListAudit(c *fiber.Ctx) error {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	entries, total, err := h.auditService.ListAudit(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	if entries == nil {
+		entries = []*domain.AuditEntry{}
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": entries,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// parseAuditFilter reads the principal, subject, route, method, outcome,
+// from, to, limit and offset query parameters. Times use RFC 3339.
+func parseAuditFilter(c *fiber.Ctx) (domain.AuditFilter, error) {
+	filter := domain.AuditFilter{
+		Principal: c.Query("principal"),
+		Subject:   c.Query("subject"),
+		Route:     c.Query("route"),
+		Method:    strings.ToUpper(c.Query("method")),
+		Limit:     c.QueryInt("limit", defaultAuditPageSize),
+		Offset:    c.QueryInt("offset", 0),
+	}
+
+	if filter.Limit < 1 || filter.Limit > maxAuditPageSize {
+		return filter, fmt.Errorf("invalid limit. Must be between 1 and %d", maxAuditPageSize)
+	}
+	if filter.Offset < 0 {
+		return filter, errors.New("invalid offset")
+	}
+
+	if v := c.Query("outcome"); v != "" {
+		outcome, err := domain.ParseAuditOutcome(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.Outcome = outcome
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("invalid from time. Must be RFC 3339")
+		}
+		filter.From = from
+	}
+
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("invalid to time. Must be RFC 3339")
+		}
+		filter.To = to
+	}
+
+	return filter, nil
+}
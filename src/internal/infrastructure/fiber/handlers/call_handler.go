@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultCallPageSize = 50
+	maxCallPageSize     = 500
+)
+
+// CallHandler handles HTTP requests for hall calls
+type CallHandler struct {
+	callService *services.CallService
+}
+
+// NewCallHandler creates a new CallHandler instance
+func NewCallHandler(callService *services.CallService) *CallHandler {
+	return &CallHandler{
+		callService: callService,
+	}
+}
+
+// ListCalls handles GET requests to list hall calls, newest first
+func (h *CallHandler) ListCalls(c *fiber.Ctx) error {
+	filter, err := parseCallFilter(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	calls, total, err := h.callService.ListCalls(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	if calls == nil {
+		calls = []*domain.HallCall{}
+	}
+
+	return c.JSON(fiber.Map{
+		"calls":  calls,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// GetCall handles GET requests to follow a hall call
+func (h *CallHandler) GetCall(c *fiber.Ctx) error {
+	call, err := h.callService.GetCall(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(call)
+}
+
+// CancelCall handles DELETE requests to cancel a hall call that has not been
+// served yet
+func (h *CallHandler) CancelCall(c *fiber.Ctx) error {
+	call, err := h.callService.CancelCall(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(call)
+}
+
+// parseCallFilter reads the floor, direction, lift, status, limit and offset
+// query parameters
+func parseCallFilter(c *fiber.Ctx) (domain.HallCallFilter, error) {
+	filter := domain.HallCallFilter{
+		LiftID: c.Query("lift"),
+		Limit:  c.QueryInt("limit", defaultCallPageSize),
+		Offset: c.QueryInt("offset", 0),
+	}
+
+	if filter.Limit < 1 || filter.Limit > maxCallPageSize {
+		return filter, fmt.Errorf("invalid limit. Must be between 1 and %d", maxCallPageSize)
+	}
+	if filter.Offset < 0 {
+		return filter, errors.New("invalid offset")
+	}
+
+	if v := c.Query("floor"); v != "" {
+		floor, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid floor number")
+		}
+		filter.Floor = &floor
+	}
+
+	if v := c.Query("direction"); v != "" {
+		n, err := strconv.Atoi(v)
+		direction := domain.Direction(n)
+		if err != nil || validateDirection(direction) != nil {
+			return filter, errors.New("invalid direction. Must be 0 (Up), 1 (Down)")
+		}
+		filter.Direction = &direction
+	}
+
+	if v := c.Query("status"); v != "" {
+		status, err := domain.ParseHallCallStatus(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.Status = status
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
+	"github.com/gofiber/fiber/v2"
+)
+
+// CommandHandler carries out WebSocket command frames through the same
+// services, and with the same validation, as the REST handlers
+type CommandHandler struct {
+	liftService  *services.LiftService
+	moveService  *services.MoveService
+	floorService *services.FloorService
+}
+
+// NewCommandHandler creates a new CommandHandler instance
+func NewCommandHandler(liftService *services.LiftService, moveService *services.MoveService, floorService *services.FloorService) *CommandHandler {
+	return &CommandHandler{
+		liftService:  liftService,
+		moveService:  moveService,
+		floorService: floorService,
+	}
+}
+
+// Execute runs one command. A hall call returns the call as soon as it is
+// accepted; moves and car calls return the move as soon as it is queued.
+func (h *CommandHandler) Execute(ctx context.Context, op string, args json.RawMessage) (any, error) {
+	switch op {
+	case ws.OpCallLift:
+		var request ws.CallLiftArgs
+		if err := decodeArgs(args, &request); err != nil {
+			return nil, err
+		}
+		if err := validateDirection(request.Direction); err != nil {
+			return nil, err
+		}
+		return h.floorService.CallLift(ctx, request.Floor, request.Direction)
+
+	case ws.OpMoveLift, ws.OpCarCall:
+		var request ws.MoveLiftArgs
+		if err := decodeArgs(args, &request); err != nil {
+			return nil, err
+		}
+		trigger := domain.TripManualMove
+		if op == ws.OpCarCall {
+			trigger = domain.TripCarCall
+		}
+		return h.moveService.Submit(ctx, request.LiftID, request.TargetFloor, request.Passengers, trigger)
+
+	case ws.OpSetStatus:
+		var request ws.SetStatusArgs
+		if err := decodeArgs(args, &request); err != nil {
+			return nil, err
+		}
+		if err := h.liftService.SetLiftStatus(ctx, request.LiftID, request.Status); err != nil {
+			return nil, err
+		}
+		return h.liftService.GetLiftStatus(ctx, request.LiftID)
+
+	case ws.OpDoorHold:
+		var request ws.DoorHoldArgs
+		if err := decodeArgs(args, &request); err != nil {
+			return nil, err
+		}
+		until, err := h.liftService.HoldDoors(ctx, request.LiftID, time.Duration(request.Seconds)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		return fiber.Map{"liftId": request.LiftID, "heldUntil": until}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported command %q", op)
+	}
+}
+
+// decodeArgs parses the arguments of a command like a request body
+func decodeArgs(args json.RawMessage, v any) error {
+	if len(args) == 0 || json.Unmarshal(args, v) != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	return nil
+}
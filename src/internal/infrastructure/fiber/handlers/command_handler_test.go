@@ -0,0 +1,100 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/handlers"
+	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/metrics"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/persistence/sqlite"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+)
+
+func TestMoveCommandsQueuedPerLift(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log := logger.New(io.Discard, logger.LevelError, "TEST", nil)
+	repo, err := sqlite.NewRepository(filepath.Join(t.TempDir(), "lift.sqlite"), log)
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+
+	bus := events.NewInMemoryEventBus()
+	hub := ws.NewWebSocketHub(ws.HubConfig{}, repo, log)
+	appMetrics := metrics.New(log)
+	calls := services.NewCallService(repo, bus, appMetrics, log)
+	lifts := services.NewLiftService(repo, calls, bus, hub, appMetrics, services.DispatchConfig{}, log)
+	moves := services.NewMoveService(lifts, repo, bus, log)
+	floors := services.NewFloorService(repo, calls, bus, log, hub)
+	if err := services.NewSystemService(repo, lifts, moves, bus, log).ConfigureSystem(ctx, 3, 1); err != nil {
+		t.Fatalf("configuring system: %v", err)
+	}
+	all, err := lifts.ListLifts(ctx)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("got lifts %v and error %v, want one lift", all, err)
+	}
+	liftID := all[0].ID
+
+	commands := handlers.NewCommandHandler(lifts, moves, floors)
+
+	// Both commands arrive at once; the second must wait for the first
+	// rather than race it for the lift
+	var wg sync.WaitGroup
+	submitted := make([]*domain.Move, 2)
+	for i, cmd := range []struct {
+		op    string
+		floor int
+	}{{ws.OpMoveLift, 1}, {ws.OpCarCall, 2}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			args, _ := json.Marshal(ws.MoveLiftArgs{LiftID: liftID, TargetFloor: cmd.floor})
+			result, err := commands.Execute(ctx, cmd.op, args)
+			if err != nil {
+				t.Errorf("%s: %v", cmd.op, err)
+				return
+			}
+			move, ok := result.(*domain.Move)
+			if !ok {
+				t.Errorf("%s answered with %T, want the move", cmd.op, result)
+				return
+			}
+			submitted[i] = move
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	for _, move := range submitted {
+		for {
+			got, err := moves.GetMove(ctx, move.ID)
+			if err != nil {
+				t.Fatalf("getting move: %v", err)
+			}
+			if got.FinishedAt != nil {
+				if got.Status != domain.MoveCompleted {
+					t.Errorf("move to floor %d is %s (%s), want %s", got.TargetFloor, got.Status, got.Error, domain.MoveCompleted)
+				}
+				break
+			}
+
+			select {
+			case <-ctx.Done():
+				t.Fatalf("move to floor %d not finished", move.TargetFloor)
+			case <-time.After(50 * time.Millisecond):
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultEventPageSize = 100
+	maxEventPageSize     = 1000
+)
+
+// EventHandler handles HTTP requests related to the domain event store
+type EventHandler struct {
+	eventService *services.EventService
+}
+
+// NewEventHandler creates a new EventHandler instance
+func NewEventHandler(eventService *services.EventService) *EventHandler {
+	return &EventHandler{
+		eventService: eventService,
+	}
+}
+
+// ListEvents handles GET requests to read the event store after a sequence number
+func (h *EventHandler) ListEvents(c *fiber.Ctx) error {
+	since, err := strconv.ParseInt(c.Query("since", "0"), 10, 64)
+	if err != nil || since < 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid since sequence")
+	}
+
+	limit := c.QueryInt("limit", defaultEventPageSize)
+	if limit < 1 || limit > maxEventPageSize {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid limit. Must be between 1 and 1000")
+	}
+
+	events, err := h.eventService.ListEvents(c.UserContext(), since, limit)
+	if err != nil {
+		return err
+	}
+
+	next := since
+	if len(events) > 0 {
+		next = events[len(events)-1].Sequence
+	}
+
+	return c.JSON(fiber.Map{
+		"events": events,
+		"next":   next,
+	})
+}
+
+// ReplayEvents handles POST requests to rebuild lift and floor state from the event store
+func (h *EventHandler) ReplayEvents(c *fiber.Ctx) error {
+	result, err := h.eventService.RebuildProjections(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
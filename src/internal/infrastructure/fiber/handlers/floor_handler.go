@@ -1,8 +1,6 @@
 package handlers
 
 import (
-	"errors"
-
 	"github.com/Avyukth/lift-simulation/internal/application/services"
 	"github.com/Avyukth/lift-simulation/internal/domain"
 	"github.com/gofiber/fiber/v2"
@@ -22,11 +20,9 @@ func NewFloorHandler(floorService *services.FloorService) *FloorHandler {
 
 // ListFloors handles GET requests to list all floors
 func (h *FloorHandler) ListFloors(c *fiber.Ctx) error {
-	floors, err := h.floorService.ListFloors(c.Context())
+	floors, err := h.floorService.ListFloors(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve floors",
-		})
+		return err
 	}
 
 	return c.JSON(floors)
@@ -36,28 +32,23 @@ func (h *FloorHandler) ListFloors(c *fiber.Ctx) error {
 func (h *FloorHandler) GetFloorStatus(c *fiber.Ctx) error {
 	floorNum, err := c.ParamsInt("floorNum")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid floor number",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid floor number")
 	}
 
-	floor, err := h.floorService.GetFloorStatus(c.Context(), floorNum)
+	floor, err := h.floorService.GetFloorStatus(c.UserContext(), floorNum)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Floor not found",
-		})
+		return err
 	}
 
 	return c.JSON(floor)
 }
 
-// CallLift handles POST requests to call a lift to a specific floor
+// CallLift handles POST requests to call a lift to a specific floor. The call
+// is accepted at once and can be followed at its Location.
 func (h *FloorHandler) CallLift(c *fiber.Ctx) error {
 	floorNum, err := c.ParamsInt("floorNum")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid floor number",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid floor number")
 	}
 
 	var request struct {
@@ -65,50 +56,32 @@ func (h *FloorHandler) CallLift(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	if request.Direction < domain.Up || request.Direction > domain.Down {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid direction. Must be 0 (Up), 1 (Down)",
-		})
+	if err := validateDirection(request.Direction); err != nil {
+		return err
 	}
 
-	err = h.floorService.CallLift(c.Context(), floorNum, request.Direction)
+	call, err := h.floorService.CallLift(c.UserContext(), floorNum, request.Direction)
 	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrFloorNotFound):
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Floor not found",
-			})
-		default:
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":   "Failed to call lift",
-				"details": err.Error(),
-			})
-		}
+		return err
 	}
-	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
-		"message": "Lift call accepted. Lift is on its way",
-	})
+
+	c.Location("/api/v1/calls/" + call.ID)
+	return c.Status(fiber.StatusAccepted).JSON(call)
 }
 
 // ResetFloorButtons handles POST requests to reset the call buttons on a floor
 func (h *FloorHandler) ResetFloorButtons(c *fiber.Ctx) error {
 	floorNum, err := c.ParamsInt("floorNum")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid floor number",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid floor number")
 	}
 
-	err = h.floorService.ResetFloorButtons(c.Context(), floorNum)
+	err = h.floorService.ResetFloorButtons(c.UserContext(), floorNum)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to reset floor buttons",
-		})
+		return err
 	}
 
 	return c.SendStatus(fiber.StatusOK)
@@ -116,12 +89,18 @@ func (h *FloorHandler) ResetFloorButtons(c *fiber.Ctx) error {
 
 // GetActiveFloorCalls handles GET requests to retrieve all active floor calls
 func (h *FloorHandler) GetActiveFloorCalls(c *fiber.Ctx) error {
-	activeFloorCalls, err := h.floorService.GetActiveFloorCalls(c.Context())
+	activeFloorCalls, err := h.floorService.GetActiveFloorCalls(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve active floor calls",
-		})
+		return err
 	}
 
 	return c.JSON(activeFloorCalls)
 }
+
+// validateDirection checks the direction of a hall call
+func validateDirection(direction domain.Direction) error {
+	if direction < domain.Up || direction > domain.Down {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid direction. Must be 0 (Up), 1 (Down)")
+	}
+	return nil
+}
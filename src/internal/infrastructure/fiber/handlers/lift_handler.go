@@ -3,6 +3,9 @@ package handlers
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Avyukth/lift-simulation/internal/application/services"
 	"github.com/Avyukth/lift-simulation/internal/domain"
@@ -12,12 +15,14 @@ import (
 // LiftHandler handles HTTP requests related to lifts
 type LiftHandler struct {
 	liftService *services.LiftService
+	moveService *services.MoveService
 }
 
 // NewLiftHandler creates a new LiftHandler instance
-func NewLiftHandler(liftService *services.LiftService) *LiftHandler {
+func NewLiftHandler(liftService *services.LiftService, moveService *services.MoveService) *LiftHandler {
 	return &LiftHandler{
 		liftService: liftService,
+		moveService: moveService,
 	}
 }
 
@@ -25,11 +30,9 @@ func NewLiftHandler(liftService *services.LiftService) *LiftHandler {
 func (h *LiftHandler) GetLift(c *fiber.Ctx) error {
 	liftID := c.Params("id")
 
-	lift, err := h.liftService.GetLiftStatus(c.Context(), liftID)
+	lift, err := h.liftService.GetLiftStatus(c.UserContext(), liftID)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Lift not found",
-		})
+		return err
 	}
 
 	return c.JSON(lift)
@@ -37,39 +40,72 @@ func (h *LiftHandler) GetLift(c *fiber.Ctx) error {
 
 // ListLifts handles GET requests to list all lifts
 func (h *LiftHandler) ListLifts(c *fiber.Ctx) error {
-	lifts, err := h.liftService.ListLifts(c.Context())
+	lifts, err := h.liftService.ListLifts(c.UserContext())
 	if err != nil {
-		fmt.Println("Failed to retrieve lifts", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve lifts",
-		})
+		return err
 	}
 
 	return c.JSON(lifts)
 }
 
-// MoveLift handles POST requests to move a lift
+// MoveLift handles POST requests to move a lift. The move runs in the
+// background; the response points at the move resource to follow it.
 func (h *LiftHandler) MoveLift(c *fiber.Ctx) error {
-	liftID := c.Params("id")
+	return h.submitMove(c, domain.TripManualMove)
+}
+
+// CarCall handles POST requests from the car panel of a lift to travel to a
+// floor. Like MoveLift it answers with the move resource.
+func (h *LiftHandler) CarCall(c *fiber.Ctx) error {
+	return h.submitMove(c, domain.TripCarCall)
+}
+
+func (h *LiftHandler) submitMove(c *fiber.Ctx, trigger domain.TripTrigger) error {
+	// The move outlives the request, and fiber reuses the memory behind
+	// route parameters once the handler returns
+	liftID := strings.Clone(c.Params("id"))
 
 	var request struct {
 		TargetFloor int `json:"targetFloor"`
+		Passengers  int `json:"passengers"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	move, err := h.moveService.Submit(c.UserContext(), liftID, request.TargetFloor, request.Passengers, trigger)
+	if err != nil {
+		return err
+	}
+
+	c.Location("/api/v1/moves/" + move.ID)
+	return c.Status(fiber.StatusAccepted).JSON(move)
+}
+
+// HoldDoors handles POST requests to hold the doors of a stopped lift open
+func (h *LiftHandler) HoldDoors(c *fiber.Ctx) error {
+	// The hold is kept by lift ID after the request, and fiber reuses the
+	// memory behind route parameters once the handler returns
+	liftID := strings.Clone(c.Params("id"))
+
+	var request struct {
+		Seconds int `json:"seconds"`
 	}
 
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	err := h.liftService.MoveLift(c.Context(), liftID, request.TargetFloor)
+	until, err := h.liftService.HoldDoors(c.UserContext(), liftID, time.Duration(request.Seconds)*time.Second)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.SendStatus(fiber.StatusAccepted)
+	return c.JSON(fiber.Map{
+		"liftId":    liftID,
+		"heldUntil": until,
+	})
 }
 
 // SetLiftStatus handles PUT requests to set a lift's status
@@ -81,17 +117,12 @@ func (h *LiftHandler) SetLiftStatus(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&request); err != nil {
-		fmt.Println("Failed to parse request body", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	err := h.liftService.SetLiftStatus(c.Context(), liftID, request.Status)
+	err := h.liftService.SetLiftStatus(c.UserContext(), liftID, request.Status)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to set lift status",
-		})
+		return err
 	}
 
 	return c.SendStatus(fiber.StatusOK)
@@ -100,20 +131,9 @@ func (h *LiftHandler) SetLiftStatus(c *fiber.Ctx) error {
 func (h *LiftHandler) ResetLift(c *fiber.Ctx) error {
 	liftID := c.Params("id")
 
-	err := h.liftService.ResetLift(c.Context(), liftID)
+	err := h.liftService.ResetLift(c.UserContext(), liftID)
 	if err != nil {
-
-		// Check for specific error types and return appropriate status codes
-		switch {
-		case errors.Is(err, domain.ErrLiftNotFound):
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Lift not found",
-			})
-		default:
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to reset lift",
-			})
-		}
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -122,14 +142,107 @@ func (h *LiftHandler) ResetLift(c *fiber.Ctx) error {
 }
 
 func (h *LiftHandler) ResetLifts(c *fiber.Ctx) error {
-	err := h.liftService.ResetLifts(c.Context())
+	err := h.liftService.ResetLifts(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to reset all lifts",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "All lifts reset successfully",
 	})
 }
+
+const (
+	defaultTripPageSize = 50
+	maxTripPageSize     = 500
+)
+
+// ListLiftTrips handles GET requests to retrieve the trip history of a lift
+func (h *LiftHandler) ListLiftTrips(c *fiber.Ctx) error {
+	filter, err := parseTripFilter(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	filter.LiftID = c.Params("id")
+
+	return h.listTrips(c, filter)
+}
+
+// ListTrips handles GET requests to query the trip history of all lifts
+func (h *LiftHandler) ListTrips(c *fiber.Ctx) error {
+	filter, err := parseTripFilter(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	filter.LiftID = c.Query("lift")
+
+	return h.listTrips(c, filter)
+}
+
+func (h *LiftHandler) listTrips(c *fiber.Ctx, filter domain.TripFilter) error {
+	trips, total, err := h.liftService.ListTrips(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	if trips == nil {
+		trips = []*domain.Trip{}
+	}
+
+	return c.JSON(fiber.Map{
+		"trips":  trips,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// parseTripFilter reads the from, to, floor, trigger, limit and offset query
+// parameters. Times use RFC 3339.
+func parseTripFilter(c *fiber.Ctx) (domain.TripFilter, error) {
+	filter := domain.TripFilter{
+		Limit:  c.QueryInt("limit", defaultTripPageSize),
+		Offset: c.QueryInt("offset", 0),
+	}
+
+	if filter.Limit < 1 || filter.Limit > maxTripPageSize {
+		return filter, fmt.Errorf("invalid limit. Must be between 1 and %d", maxTripPageSize)
+	}
+	if filter.Offset < 0 {
+		return filter, errors.New("invalid offset")
+	}
+
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("invalid from time. Must be RFC 3339")
+		}
+		filter.From = from
+	}
+
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("invalid to time. Must be RFC 3339")
+		}
+		filter.To = to
+	}
+
+	if v := c.Query("floor"); v != "" {
+		floor, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid floor number")
+		}
+		filter.Floor = &floor
+	}
+
+	if v := c.Query("trigger"); v != "" {
+		trigger, err := domain.ParseTripTrigger(v)
+		if err != nil {
+			return filter, err
+		}
+		filter.Trigger = trigger
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MoveHandler handles HTTP requests for background lift moves
+type MoveHandler struct {
+	moveService *services.MoveService
+}
+
+// NewMoveHandler creates a new MoveHandler instance
+func NewMoveHandler(moveService *services.MoveService) *MoveHandler {
+	return &MoveHandler{
+		moveService: moveService,
+	}
+}
+
+// ListMoves handles GET requests to list moves, optionally narrowed by the
+// lift and status query parameters
+func (h *MoveHandler) ListMoves(c *fiber.Ctx) error {
+	filter := domain.MoveFilter{LiftID: c.Query("lift")}
+
+	if v := c.Query("status"); v != "" {
+		status, err := domain.ParseMoveStatus(v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		filter.Status = status
+	}
+
+	moves, err := h.moveService.ListMoves(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(moves)
+}
+
+// GetMove handles GET requests to follow a move
+func (h *MoveHandler) GetMove(c *fiber.Ctx) error {
+	move, err := h.moveService.GetMove(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(move)
+}
+
+// CancelMove handles DELETE requests to cancel a move. A queued move is
+// cancelled at once; a travelling lift stops at the next floor, so the
+// cancellation is only accepted.
+func (h *MoveHandler) CancelMove(c *fiber.Ctx) error {
+	move, err := h.moveService.CancelMove(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	if move.Status == domain.MoveCancelled {
+		return c.JSON(move)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(move)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamPageSize is the number of events read from the store per query while
+// catching up
+const streamPageSize = 500
+
+// streamRetry is the reconnect delay, in milliseconds, suggested to clients
+const streamRetry = 3000
+
+// StreamHandler serves the domain event log as a Server-Sent Events stream
+type StreamHandler struct {
+	eventService *services.EventService
+	feed         *events.Feed
+	keepAlive    time.Duration
+	log          *logger.Logger
+}
+
+// NewStreamHandler creates a new StreamHandler instance. The feed wakes open
+// streams when events are recorded; a comment line is sent every keepAlive
+// while nothing happens so proxies keep the connection open.
+func NewStreamHandler(eventService *services.EventService, feed *events.Feed, keepAlive time.Duration, log *logger.Logger) *StreamHandler {
+	return &StreamHandler{
+		eventService: eventService,
+		feed:         feed,
+		keepAlive:    keepAlive,
+		log:          log,
+	}
+}
+
+// Stream handles GET requests for a live event stream. Each event is sent
+// with its store sequence number as the SSE id, so a reconnecting client
+// resumes after the last event it saw via the Last-Event-ID header (or the
+// last_event_id query parameter). Without either, the stream starts with the
+// next recorded event. The lifts, floors and types query parameters take
+// comma separated values to narrow the stream.
+func (h *StreamHandler) Stream(c *fiber.Ctx) error {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx := context.WithoutCancel(c.UserContext())
+
+	since, err := h.resumePoint(c)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	wake, unsubscribe := h.feed.Subscribe()
+	conn := c.Context().Conn()
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		// The server gives the whole response one write deadline, from its
+		// WriteTimeout, which a stream soon outlives. Every flush pushes the
+		// deadline past the next keep-alive instead, so only a client that
+		// stops reading is cut off.
+		flush := func() error {
+			if err := conn.SetWriteDeadline(time.Now().Add(2 * h.keepAlive)); err != nil {
+				return err
+			}
+			return w.Flush()
+		}
+
+		h.log.Info(ctx, "Event stream opened", "since", since)
+		defer h.log.Info(ctx, "Event stream closed", "last_sequence", since)
+
+		fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+		if err := flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(h.keepAlive)
+		defer ticker.Stop()
+
+		for {
+			if since, err = h.catchUp(ctx, w, flush, since, filter); err != nil {
+				return
+			}
+
+			select {
+			case _, ok := <-wake:
+				if !ok {
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				if err := flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// resumePoint returns the sequence number after which the stream starts
+func (h *StreamHandler) resumePoint(c *fiber.Ctx) (int64, error) {
+	lastID := c.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+
+	if lastID == "" {
+		return h.eventService.LatestSequence(c.UserContext())
+	}
+
+	since, err := strconv.ParseInt(lastID, 10, 64)
+	if err != nil || since < 0 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid Last-Event-ID")
+	}
+	return since, nil
+}
+
+// catchUp writes every stored event after since that passes the filter,
+// sending each page with flush, and returns the sequence number of the last
+// event read. It returns an error once the client has gone away.
+func (h *StreamHandler) catchUp(ctx context.Context, w *bufio.Writer, flush func() error, since int64, filter domain.EventFilter) (int64, error) {
+	for {
+		page, err := h.eventService.ListEvents(ctx, since, streamPageSize)
+		if err != nil {
+			h.log.Error(ctx, "Failed to read events for stream", "since", since, "error", err)
+			return since, nil
+		}
+
+		for _, stored := range page {
+			since = stored.Sequence
+			if !filter.Matches(stored.Event) {
+				continue
+			}
+
+			data, err := json.Marshal(stored)
+			if err != nil {
+				h.log.Error(ctx, "Failed to encode streamed event", "sequence", stored.Sequence, "error", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", stored.Sequence, stored.Event.Type(), data)
+		}
+
+		if err := flush(); err != nil {
+			return since, err
+		}
+
+		if len(page) < streamPageSize {
+			return since, nil
+		}
+	}
+}
+
+// parseEventFilter reads the lifts, floors and types query parameters
+func parseEventFilter(c *fiber.Ctx) (domain.EventFilter, error) {
+	var filter domain.EventFilter
+
+	if v := c.Query("lifts"); v != "" {
+		filter.LiftIDs = strings.Split(v, ",")
+	}
+
+	if v := c.Query("floors"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			floor, err := strconv.Atoi(s)
+			if err != nil {
+				return filter, fmt.Errorf("invalid floor number: %s", s)
+			}
+			filter.Floors = append(filter.Floors, floor)
+		}
+	}
+
+	if v := c.Query("types"); v != "" {
+		types, err := domain.ParseEventTypes(strings.Split(v, ","))
+		if err != nil {
+			return filter, err
+		}
+		filter.Types = types
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/handlers"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/persistence/sqlite"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestStreamOutlivesWriteTimeout(t *testing.T) {
+	const (
+		writeTimeout = 200 * time.Millisecond
+		keepAlive    = 500 * time.Millisecond
+	)
+
+	log := logger.New(io.Discard, logger.LevelError, "TEST", nil)
+	repo, err := sqlite.NewRepository(filepath.Join(t.TempDir(), "lift.sqlite"), log)
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+
+	feed := events.NewFeed()
+	defer feed.Close()
+	stream := handlers.NewStreamHandler(services.NewEventService(repo, log), feed, keepAlive, log)
+
+	app := fiber.New(fiber.Config{WriteTimeout: writeTimeout, DisableStartupMessage: true})
+	app.Get("/stream", stream.Stream)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/stream")
+	if err != nil {
+		t.Fatalf("opening stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// Read the stream until the second keep-alive, well past the write
+	// timeout, or until the server drops the connection.
+	opened := time.Now()
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	keepAlives := 0
+	deadline := time.After(5 * keepAlive)
+	for keepAlives < 2 {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream closed after %v with %d keep-alives", time.Since(opened).Round(time.Millisecond), keepAlives)
+			}
+			if strings.HasPrefix(line, ": keep-alive") {
+				keepAlives++
+			}
+		case <-deadline:
+			t.Fatalf("got %d keep-alives in %v, want 2", keepAlives, 5*keepAlive)
+		}
+	}
+
+	if elapsed := time.Since(opened); elapsed < 2*keepAlive-writeTimeout {
+		t.Errorf("second keep-alive after %v, sooner than expected", elapsed)
+	}
+}
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
 	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -25,17 +30,12 @@ func (h *SystemHandler) ConfigureSystem(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&config); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	err := h.systemService.ConfigureSystem(c.Context(), config.Floors, config.Lifts)
+	err := h.systemService.ConfigureSystem(c.UserContext(), config.Floors, config.Lifts)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to configure system",
-			"details": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -45,28 +45,111 @@ func (h *SystemHandler) ConfigureSystem(c *fiber.Ctx) error {
 
 // GetSystemConfiguration handles GET requests to retrieve the current system configuration
 func (h *SystemHandler) GetSystemConfiguration(c *fiber.Ctx) error {
-	ctx := c.Context()
+	ctx := c.UserContext()
 
 	system, err := h.systemService.GetSystemConfiguration(ctx)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get system configuration",
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
-		"total_floors": system.TotalFloors,
-		"total_lifts":  system.TotalLifts,
+		"total_floors":   system.TotalFloors,
+		"total_lifts":    system.TotalLifts,
+		"draining_lifts": h.systemService.DrainingLifts(),
+	})
+}
+
+// ReconfigureSystem handles PATCH requests to add floors, or add or remove
+// lifts, without resetting the system. It answers 202 Accepted when lifts
+// are left draining.
+func (h *SystemHandler) ReconfigureSystem(c *fiber.Ctx) error {
+	var request struct {
+		Floors *int `json:"floors"`
+		Lifts  *int `json:"lifts"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	result, err := h.systemService.ReconfigureSystem(c.UserContext(), domain.SystemChange{
+		Floors: request.Floors,
+		Lifts:  request.Lifts,
+	})
+	if err != nil {
+		return err
+	}
+
+	status := fiber.StatusOK
+	if len(result.DrainingLifts) > 0 {
+		status = fiber.StatusAccepted
+	}
+	return c.Status(status).JSON(result)
+}
+
+// AddLift handles POST requests to add a lift to the running system
+func (h *SystemHandler) AddLift(c *fiber.Ctx) error {
+	var request struct {
+		Name     string `json:"name"`
+		Capacity int    `json:"capacity"`
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	lift, err := h.systemService.AddLift(c.UserContext(), request.Name, request.Capacity)
+	if err != nil {
+		return err
+	}
+
+	c.Location("/api/v1/lifts/" + lift.ID)
+	return c.Status(fiber.StatusCreated).JSON(lift)
+}
+
+// UpdateLift handles PATCH requests to rename a lift or change its capacity
+func (h *SystemHandler) UpdateLift(c *fiber.Ctx) error {
+	var request struct {
+		Name     *string `json:"name"`
+		Capacity *int    `json:"capacity"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	lift, err := h.systemService.UpdateLift(c.UserContext(), c.Params("id"), domain.LiftChange{
+		Name:     request.Name,
+		Capacity: request.Capacity,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(lift)
+}
+
+// DecommissionLift handles DELETE requests to remove a lift. The lift is
+// drained in the background, so the request is answered with 202 Accepted.
+func (h *SystemHandler) DecommissionLift(c *fiber.Ctx) error {
+	lift, err := h.systemService.DecommissionLift(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Lift is being decommissioned",
+		"lift":    lift,
 	})
 }
 
 // GetSystemStatus handles GET requests to retrieve the overall system status
 func (h *SystemHandler) GetSystemStatus(c *fiber.Ctx) error {
-	status, err := h.systemService.GetSystemStatus(c.Context())
+	status, err := h.systemService.GetSystemStatus(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve system status",
-		})
+		return err
 	}
 
 	return c.JSON(status)
@@ -74,12 +157,9 @@ func (h *SystemHandler) GetSystemStatus(c *fiber.Ctx) error {
 
 // ResetSystem handles POST requests to reset the entire lift system
 func (h *SystemHandler) ResetSystem(c *fiber.Ctx) error {
-	err := h.systemService.ResetSystem(c.Context())
+	err := h.systemService.ResetSystem(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to reset system",
-			"details": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -89,11 +169,9 @@ func (h *SystemHandler) ResetSystem(c *fiber.Ctx) error {
 
 // GetSystemMetrics handles GET requests to retrieve system performance metrics
 func (h *SystemHandler) GetSystemMetrics(c *fiber.Ctx) error {
-	metrics, err := h.systemService.GetSystemMetrics(c.Context())
+	metrics, err := h.systemService.GetSystemMetrics(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve system metrics",
-		})
+		return err
 	}
 
 	return c.JSON(metrics)
@@ -107,20 +185,45 @@ func (h *SystemHandler) SimulateTraffic(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&request); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	err := h.systemService.SimulateTraffic(c.Context(), request.Duration, request.Intensity)
+	err := h.systemService.SimulateTraffic(c.UserContext(), request.Duration, request.Intensity)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to simulate traffic",
-			"details": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Traffic simulation started",
 	})
 }
+
+// ExportSnapshot handles GET requests to export the whole system as a snapshot document
+func (h *SystemHandler) ExportSnapshot(c *fiber.Ctx) error {
+	snapshot, err := h.systemService.ExportSnapshot(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(snapshot)
+}
+
+// ImportSnapshot handles POST requests to replace the system with a snapshot document
+func (h *SystemHandler) ImportSnapshot(c *fiber.Ctx) error {
+	var snapshot domain.Snapshot
+
+	dec := json.NewDecoder(bytes.NewReader(c.Body()))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&snapshot); err != nil {
+		return fmt.Errorf("%w: invalid snapshot document: %v", domain.ErrInvalidArgument, err)
+	}
+
+	if err := h.systemService.ImportSnapshot(c.UserContext(), &snapshot); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":   "Snapshot imported successfully",
+		"system_id": snapshot.System.ID,
+	})
+}
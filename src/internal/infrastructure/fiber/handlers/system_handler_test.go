@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/handlers"
+	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/metrics"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/persistence/sqlite"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSnapshotRestoresOpenCalls(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log := logger.New(io.Discard, logger.LevelError, "TEST", nil)
+	repo, err := sqlite.NewRepository(filepath.Join(t.TempDir(), "lift.sqlite"), log)
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+
+	bus := events.NewInMemoryEventBus()
+	hub := ws.NewWebSocketHub(ws.HubConfig{}, repo, log)
+	appMetrics := metrics.New(log)
+	calls := services.NewCallService(repo, bus, appMetrics, log)
+	lifts := services.NewLiftService(repo, calls, bus, hub, appMetrics, services.DispatchConfig{}, log)
+	moves := services.NewMoveService(lifts, repo, bus, log)
+	floors := services.NewFloorService(repo, calls, bus, log, hub)
+	system := services.NewSystemService(repo, lifts, moves, bus, log)
+	if err := system.ConfigureSystem(ctx, 4, 1); err != nil {
+		t.Fatalf("configuring system: %v", err)
+	}
+
+	// With its only lift out of service, every call keeps waiting
+	all, err := lifts.ListLifts(ctx)
+	if err != nil || len(all) != 1 {
+		t.Fatalf("got lifts %v and error %v, want one lift", all, err)
+	}
+	if err := lifts.SetLiftStatus(ctx, all[0].ID, domain.OutOfService); err != nil {
+		t.Fatalf("taking lift out of service: %v", err)
+	}
+	exported, err := floors.CallLift(ctx, 2, domain.Down)
+	if err != nil {
+		t.Fatalf("calling lift: %v", err)
+	}
+
+	systemHandler := handlers.NewSystemHandler(system)
+	app := fiber.New()
+	app.Get("/snapshot", systemHandler.ExportSnapshot)
+	app.Post("/snapshot", systemHandler.ImportSnapshot)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/snapshot", nil))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("exporting snapshot: status %v, error %v", resp, err)
+	}
+	document, _ := io.ReadAll(resp.Body)
+
+	// A call made after the export is not part of the restored system
+	later, err := floors.CallLift(ctx, 3, domain.Up)
+	if err != nil {
+		t.Fatalf("calling lift: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/snapshot", bytes.NewReader(document))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = app.Test(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("importing snapshot: status %d, error %v: %s", resp.StatusCode, err, body)
+	}
+
+	for _, call := range []*domain.HallCall{exported, later} {
+		got, err := calls.GetCall(ctx, call.ID)
+		if err != nil {
+			t.Fatalf("getting call: %v", err)
+		}
+		if got.Status != domain.CallCancelled {
+			t.Errorf("call at floor %d made before the import is %s, want %s", call.FloorNumber, got.Status, domain.CallCancelled)
+		}
+	}
+
+	open, _, err := calls.ListCalls(ctx, domain.HallCallFilter{Status: domain.CallPending, Limit: 10})
+	if err != nil {
+		t.Fatalf("listing calls: %v", err)
+	}
+	if len(open) != 1 {
+		t.Fatalf("got %d pending calls after the import, want 1", len(open))
+	}
+	restored := open[0]
+	if restored.FloorNumber != 2 || restored.Direction != domain.Down {
+		t.Errorf("restored call is at floor %d going %v, want floor 2 going %v", restored.FloorNumber, restored.Direction, domain.Down)
+	}
+	if d := restored.CreatedAt.Sub(exported.CreatedAt).Abs(); d > time.Millisecond {
+		t.Errorf("restored call waiting since %v, want %v", restored.CreatedAt, exported.CreatedAt)
+	}
+
+	floor, err := floors.GetFloorStatus(ctx, 3)
+	if err != nil {
+		t.Fatalf("getting floor: %v", err)
+	}
+	if floor.UpButtonActive {
+		t.Error("up button of floor 3 still lit for a call made after the export")
+	}
+
+	var snapshot domain.Snapshot
+	if err := json.Unmarshal(document, &snapshot); err != nil {
+		t.Fatalf("decoding snapshot: %v", err)
+	}
+	if len(snapshot.ActiveCalls) != 1 || snapshot.ActiveCalls[0].Status != domain.CallPending {
+		t.Errorf("exported calls %+v, want the one pending call", snapshot.ActiveCalls)
+	}
+}
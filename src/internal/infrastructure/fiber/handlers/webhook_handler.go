@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultDeliveryPageSize = 100
+	maxDeliveryPageSize     = 1000
+)
+
+// WebhookHandler handles HTTP requests related to webhook subscriptions and
+// their deliveries
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler instance
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook handles POST requests to register a webhook. The response is
+// the only place the signing secret is returned.
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	var input services.WebhookInput
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	hook, err := h.webhookService.CreateWebhook(c.UserContext(), input)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(struct {
+		*domain.Webhook
+		Secret string `json:"secret"`
+	}{hook, hook.Secret})
+}
+
+// ListWebhooks handles GET requests to list all webhooks
+func (h *WebhookHandler) ListWebhooks(c *fiber.Ctx) error {
+	hooks, err := h.webhookService.ListWebhooks(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	if hooks == nil {
+		hooks = []*domain.Webhook{}
+	}
+
+	return c.JSON(hooks)
+}
+
+// GetWebhook handles GET requests to retrieve a specific webhook
+func (h *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	hook, err := h.webhookService.GetWebhook(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(hook)
+}
+
+// UpdateWebhook handles PUT requests to replace a webhook's settings
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	var input services.WebhookInput
+	if err := c.BodyParser(&input); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	hook, err := h.webhookService.UpdateWebhook(c.UserContext(), c.Params("id"), input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(hook)
+}
+
+// DeleteWebhook handles DELETE requests to remove a webhook
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	if err := h.webhookService.DeleteWebhook(c.UserContext(), c.Params("id")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListDeliveries handles GET requests to list webhook deliveries, optionally
+// narrowed to one webhook or status. status=dead lists the dead letters.
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	filter := domain.WebhookDeliveryFilter{
+		WebhookID: c.Query("webhook"),
+		Limit:     c.QueryInt("limit", defaultDeliveryPageSize),
+	}
+
+	if filter.Limit < 1 || filter.Limit > maxDeliveryPageSize {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid limit. Must be between 1 and 1000")
+	}
+
+	if v := c.Query("status"); v != "" {
+		status, err := domain.ParseWebhookDeliveryStatus(v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		filter.Status = status
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	if deliveries == nil {
+		deliveries = []*domain.WebhookDelivery{}
+	}
+
+	return c.JSON(deliveries)
+}
+
+// GetDelivery handles GET requests to retrieve a delivery and its attempt log
+func (h *WebhookHandler) GetDelivery(c *fiber.Ctx) error {
+	delivery, attempts, err := h.webhookService.GetDelivery(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	if attempts == nil {
+		attempts = []*domain.WebhookAttempt{}
+	}
+
+	return c.JSON(struct {
+		*domain.WebhookDelivery
+		Attempts []*domain.WebhookAttempt `json:"attempt_log"`
+	}{delivery, attempts})
+}
+
+// RetryDelivery handles POST requests to requeue a dead-lettered delivery
+func (h *WebhookHandler) RetryDelivery(c *fiber.Ctx) error {
+	delivery, err := h.webhookService.RetryDelivery(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(delivery)
+}
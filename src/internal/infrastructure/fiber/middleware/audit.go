@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/Avyukth/lift-simulation/pkg/web"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Auditor captures the state a call changes and records the call
+type Auditor interface {
+	State(ctx context.Context, subject domain.AuditSubject) (json.RawMessage, error)
+	Record(ctx context.Context, entry *domain.AuditEntry, before, after json.RawMessage)
+}
+
+// Audit records every mutating call that passed authentication: who made it,
+// from where, what was asked, how the state of its subject changed and how
+// the call ended. Reads are not recorded. Errors are rendered here, so the
+// status of a failed call is known when it is recorded. A call whose subject
+// cannot be read beforehand is refused, so no change goes unrecorded.
+func Audit(auditor Auditor) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		subject, hasSubject := auditSubject(c.Path())
+
+		var before json.RawMessage
+		if hasSubject {
+			state, err := auditor.State(ctx, subject)
+			if err != nil {
+				return err
+			}
+			before = state
+		}
+
+		payload := string(c.Body())
+
+		var callErr error
+		if err := c.Next(); err != nil {
+			callErr = err
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		var after json.RawMessage
+		if hasSubject {
+			state, err := auditor.State(ctx, subject)
+			if err != nil {
+				// The call is done, so it is recorded without its changes
+				before = nil
+			}
+			after = state
+		}
+
+		claims, _ := c.Locals(auth.ClaimsKey).(auth.Claims)
+
+		entry := &domain.AuditEntry{
+			Principal: claims.Subject,
+			SourceIP:  c.IP(),
+			TraceID:   web.GetTraceID(ctx),
+			Method:    c.Method(),
+			Route:     c.Route().Path,
+			Path:      c.Path(),
+			Payload:   payload,
+			Status:    c.Response().StatusCode(),
+			At:        time.Now().UTC(),
+		}
+		if hasSubject {
+			entry.Subject = subject.String()
+		}
+		if callErr != nil {
+			entry.Error = callErr.Error()
+		}
+
+		auditor.Record(ctx, entry, before, after)
+		return nil
+	}
+}
+
+// auditSubject works out which state a call under /api/v1 may change from
+// its path
+func auditSubject(path string) (domain.AuditSubject, bool) {
+	rest, ok := strings.CutPrefix(path, "/api/v1/")
+	if !ok {
+		return domain.AuditSubject{}, false
+	}
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+
+	id := ""
+	if len(parts) > 1 {
+		id = parts[1]
+	}
+
+	switch parts[0] {
+	case "lifts":
+		if id == "" || id == "reset" {
+			return domain.AuditSubject{Kind: domain.AuditSubjectLifts}, true
+		}
+		return domain.AuditSubject{Kind: domain.AuditSubjectLift, ID: id}, true
+	case "system", "events":
+		return domain.AuditSubject{Kind: domain.AuditSubjectSystem}, true
+	case "floors":
+		if id != "" {
+			return domain.AuditSubject{Kind: domain.AuditSubjectFloor, ID: id}, true
+		}
+	case "calls":
+		if id != "" {
+			return domain.AuditSubject{Kind: domain.AuditSubjectCall, ID: id}, true
+		}
+	case "webhooks":
+		if id != "" {
+			return domain.AuditSubject{Kind: domain.AuditSubjectWebhook, ID: id}, true
+		}
+	case "api-keys":
+		if id != "" {
+			return domain.AuditSubject{Kind: domain.AuditSubjectAPIKey, ID: id}, true
+		}
+	}
+	return domain.AuditSubject{}, false
+}
@@ -1,126 +1,113 @@
 package middleware
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
+// APIKeyHeader carries the API key of a machine client
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator checks the API keys presented by machine clients
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*domain.APIKey, error)
+}
+
 // Config holds the configuration for the authentication middleware
 type Config struct {
-	JWTSecret string
+	Auth    *auth.Auth
+	APIKeys APIKeyAuthenticator
 }
 
-// New creates a new instance of the authentication middleware
+// New creates a new instance of the authentication middleware. A request
+// carrying an X-API-Key header is authenticated by its API key. Otherwise
+// the JWT is taken from the Authorization header, or from the access_token
+// query parameter for clients that cannot set headers, such as browser
+// WebSocket and EventSource clients. The caller's claims are stored in the
+// request's user context and under auth.ClaimsKey.
 func New(config Config) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Extract the token from the Authorization header
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Missing authorization header",
-			})
+		if key := c.Get(APIKeyHeader); key != "" {
+			return authenticateAPIKey(c, config.APIKeys, key)
 		}
 
-		// The token should be in the format "Bearer <token>"
-		splitToken := strings.Split(authHeader, "Bearer ")
-		if len(splitToken) != 2 {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid authorization header format",
-			})
-		}
-
-		tokenString := splitToken[1]
-
-		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the algorithm
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token signing method")
-			}
-			return []byte(config.JWTSecret), nil
-		})
-
+		tokenString, err := bearerToken(c)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid or expired token",
-			})
-		}
-
-		// Check if the token is valid
-		if !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid token",
-			})
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="lift-simulation"`)
+			return err
 		}
 
-		// If we reach here, the token is valid. You can add claims to the context if needed.
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Locals("user", claims["sub"])
+		claims, err := config.Auth.Authenticate(tokenString)
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="lift-simulation", error="invalid_token"`)
+			return fmt.Errorf("%w: invalid token: %v", domain.ErrUnauthenticated, err)
 		}
 
-		return c.Next()
+		return authenticated(c, claims)
 	}
 }
 
-// OptionalAuth is a middleware that performs authentication if a token is present,
-// but allows the request to proceed even if no token is provided or if it's invalid.
-func OptionalAuth(config Config) fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
-			return c.Next()
-		}
+func authenticateAPIKey(c *fiber.Ctx, apiKeys APIKeyAuthenticator, secret string) error {
+	if apiKeys == nil {
+		return fmt.Errorf("%w: API keys are not accepted", domain.ErrUnauthenticated)
+	}
 
-		splitToken := strings.Split(authHeader, "Bearer ")
-		if len(splitToken) != 2 {
-			return c.Next()
-		}
+	key, err := apiKeys.Authenticate(c.UserContext(), secret)
+	if err != nil {
+		return err
+	}
 
-		tokenString := splitToken[1]
+	return authenticated(c, auth.APIKeyClaims(key))
+}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.NewError(fiber.StatusUnauthorized, "Invalid token signing method")
-			}
-			return []byte(config.JWTSecret), nil
-		})
+func authenticated(c *fiber.Ctx, claims auth.Claims) error {
+	c.Locals(auth.ClaimsKey, claims)
+	c.SetUserContext(auth.WithClaims(c.UserContext(), claims))
 
-		if err != nil || !token.Valid {
-			return c.Next()
-		}
+	return c.Next()
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Locals("user", claims["sub"])
+func bearerToken(c *fiber.Ctx) (string, error) {
+	authHeader := c.Get(fiber.HeaderAuthorization)
+	if authHeader == "" {
+		if token := c.Query("access_token"); token != "" {
+			return token, nil
 		}
+		return "", fmt.Errorf("%w: missing authorization header", domain.ErrUnauthenticated)
+	}
 
-		return c.Next()
+	// The token should be in the format "Bearer <token>"
+	scheme, token, ok := strings.Cut(authHeader, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", fmt.Errorf("%w: invalid authorization header format", domain.ErrUnauthenticated)
 	}
+	return token, nil
 }
 
-// RequireRole is a middleware that checks if the authenticated user has the required role
-func RequireRole(requiredRole string) fiber.Handler {
+// RequireRole is a middleware that checks that the authenticated caller has
+// one of the roles, or a role ranked above it
+func RequireRole(roles ...auth.Role) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		user := c.Locals("user")
-		if user == nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Authentication required",
-			})
+		claims, ok := c.Locals(auth.ClaimsKey).(auth.Claims)
+		if !ok {
+			return fmt.Errorf("%w: authentication required", domain.ErrUnauthenticated)
 		}
 
-		// In a real application, you would typically fetch the user's roles from a database
-		// For this example, we'll assume the role is part of the JWT claims
-		token := c.Locals("user").(*jwt.Token)
-		claims := token.Claims.(jwt.MapClaims)
-		role := claims["role"].(string)
-
-		if role != requiredRole {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Insufficient permissions",
-			})
+		for _, role := range roles {
+			if claims.Allows(role) {
+				return c.Next()
+			}
 		}
 
-		return c.Next()
+		names := make([]string, len(roles))
+		for i, role := range roles {
+			names[i] = string(role)
+		}
+		return fmt.Errorf("%w: the %s role is required", domain.ErrForbidden, strings.Join(names, " or "))
 	}
 }
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// IdempotencyKeyHeader carries the key a client picks for a request it
+	// may retry
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed for a retry
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// secretResponseKey is the fiber local set on routes whose response carries
+// a secret
+const secretResponseKey = "secretResponse"
+
+// IdempotencyStore remembers the responses to requests sent with an
+// idempotency key
+type IdempotencyStore interface {
+	Begin(ctx context.Context, principal, key, method, path string, body []byte) (*domain.IdempotencyRecord, bool, error)
+	Finish(ctx context.Context, rec *domain.IdempotencyRecord)
+	Release(ctx context.Context, rec *domain.IdempotencyRecord)
+}
+
+// Idempotency makes POST and PUT requests that carry an Idempotency-Key
+// header safe to retry. The first response to a key is stored for the
+// caller and replayed for every retry with the same key, method, path and
+// body. The query string is left out, as it may carry an access token, and
+// JSON bodies are compared regardless of layout. Server errors, rate limited
+// requests and responses carrying a secret are not stored, so such requests
+// can be retried with the same key. Errors are rendered here, so that the
+// response stored for a rejected request is the one the client saw.
+func Idempotency(store IdempotencyStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPut:
+		default:
+			return c.Next()
+		}
+
+		claims, _ := c.Locals(auth.ClaimsKey).(auth.Claims)
+		ctx := c.UserContext()
+
+		rec, replay, err := store.Begin(ctx, claims.Subject, key, c.Method(), c.Path(), c.Body())
+		if err != nil {
+			return err
+		}
+		if replay {
+			c.Set(IdempotentReplayedHeader, "true")
+			if rec.Location != "" {
+				c.Set(fiber.HeaderLocation, rec.Location)
+			}
+			c.Set(fiber.HeaderContentType, rec.ContentType)
+			return c.Status(rec.Status).Send(rec.Body)
+		}
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		resp := c.Response()
+		holdsSecret, _ := c.Locals(secretResponseKey).(bool)
+		if holdsSecret || resp.StatusCode() >= fiber.StatusInternalServerError || resp.StatusCode() == fiber.StatusTooManyRequests {
+			store.Release(ctx, rec)
+			return nil
+		}
+
+		rec.Status = resp.StatusCode()
+		rec.ContentType = string(resp.Header.ContentType())
+		rec.Location = string(resp.Header.Peek(fiber.HeaderLocation))
+		rec.Body = append([]byte(nil), resp.Body()...)
+		store.Finish(ctx, rec)
+		return nil
+	}
+}
+
+// HoldsSecret marks a route whose response carries a secret, such as a new API
+// key or a webhook signing secret. Its responses are never stored for
+// idempotent replay, so the secret is neither kept in plaintext nor handed
+// out again: the key only guards against concurrent retries, and is released
+// once the request has been answered.
+func HoldsSecret() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(secretResponseKey, true)
+		return c.Next()
+	}
+}
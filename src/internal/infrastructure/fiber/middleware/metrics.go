@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestObserver records how long requests took
+type RequestObserver interface {
+	ObserveRequest(method, route string, status int, d time.Duration)
+}
+
+// Metrics times every request. Requests are labelled with the route pattern
+// they matched rather than their path, so lift and floor IDs do not each get
+// a series of their own. Errors are rendered here, so that the status
+// recorded is the one the client saw.
+func Metrics(observer RequestObserver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// The method is backed by the request buffer, which is reused once
+		// the request is done
+		observer.ObserveRequest(strings.Clone(c.Method()), c.Route().Path, c.Response().StatusCode(), time.Since(start))
+		return nil
+	}
+}
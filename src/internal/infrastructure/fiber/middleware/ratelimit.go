@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/ratelimit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimiter decides whether another request may go ahead
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) ratelimit.Decision
+}
+
+// RateLimitClients limits how often each client may call the API. Callers
+// holding an API key are limited per key, everyone else per IP address.
+func RateLimitClients(limiter RateLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, _ := c.Locals(auth.ClaimsKey).(auth.Claims)
+
+		decision := limiter.Allow(c.UserContext(), ratelimit.ClientKey(claims, c.IP()))
+		if !decision.Allowed {
+			return tooManyRequests(c, decision, "too many requests from this client")
+		}
+		return c.Next()
+	}
+}
+
+// RateLimitFloorButtons limits how often a hall button may be pressed,
+// whoever presses it. Requests whose floor or direction cannot be read are
+// left for the handler to reject.
+func RateLimitFloorButtons(limiter RateLimiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		floorNum, err := c.ParamsInt("floorNum")
+		if err != nil {
+			return c.Next()
+		}
+
+		var request struct {
+			Direction domain.Direction `json:"direction"`
+		}
+		if err := json.Unmarshal(c.Body(), &request); err != nil {
+			return c.Next()
+		}
+
+		decision := limiter.Allow(c.UserContext(), ratelimit.FloorButtonKey(floorNum, request.Direction))
+		if !decision.Allowed {
+			return tooManyRequests(c, decision, fmt.Sprintf("the button on floor %d was pressed too often", floorNum))
+		}
+		return c.Next()
+	}
+}
+
+func tooManyRequests(c *fiber.Ctx, decision ratelimit.Decision, msg string) error {
+	retryAfter := decision.RetryAfterSeconds()
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return fmt.Errorf("%w: %s, retry in %ds", domain.ErrRateLimited, msg, retryAfter)
+}
@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
 	"github.com/Avyukth/lift-simulation/pkg/logger"
 	"github.com/gofiber/fiber/v2"
 )
@@ -26,21 +28,17 @@ func NewSystemVerificationMiddleware(repo ports.Repository, log *logger.FiberLog
 
 func (m *SystemVerificationMiddleware) VerifySystem() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx := c.Context()
+		ctx := c.UserContext()
 
 		system, err := m.repo.GetSystem(ctx)
 		if err != nil {
 			m.log.Error(ctx, "Failed to retrieve system configuration", "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify system configuration",
-			})
+			return fmt.Errorf("failed to verify system configuration: %w", err)
 		}
 
 		if system == nil || system.TotalFloors == 0 || system.TotalLifts == 0 {
 			m.log.Warn(ctx, "System not properly configured")
-			return c.Status(fiber.StatusPreconditionFailed).JSON(fiber.Map{
-				"error": "System not properly configured",
-			})
+			return domain.ErrSystemNotConfigured
 		}
 		return c.Next()
 	}
@@ -48,48 +46,38 @@ func (m *SystemVerificationMiddleware) VerifySystem() fiber.Handler {
 
 func (m *SystemVerificationMiddleware) VerifyLiftMove() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx := c.Context()
+		ctx := c.UserContext()
 		liftID := c.Params("id")
 
 		// Verify lift exists
 		lift, err := m.repo.GetLift(ctx, liftID)
 		if err != nil {
 			m.log.Error(ctx, "Failed to retrieve lift", "lift_id", liftID, "error", err)
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Lift not found",
-			})
+			return err
 		}
 
 		if lift == nil {
 			m.log.Warn(ctx, "Lift not found", "lift_id", liftID)
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Lift not found",
-			})
+			return fmt.Errorf("%w: %s", domain.ErrLiftNotFound, liftID)
 		}
 
 		// Verify and parse payload
 		var payload MoveLiftPayload
 		if err := json.Unmarshal(c.Body(), &payload); err != nil {
 			m.log.Error(ctx, "Failed to parse move lift payload", "error", err)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid payload",
-			})
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid payload")
 		}
 
 		// Verify target floor is valid
 		system, err := m.repo.GetSystem(ctx)
 		if err != nil {
 			m.log.Error(ctx, "Failed to retrieve system configuration", "error", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify system configuration",
-			})
+			return fmt.Errorf("failed to verify system configuration: %w", err)
 		}
 
 		if payload.TargetFloor < 0 || payload.TargetFloor >= system.TotalFloors {
 			m.log.Warn(ctx, "Invalid target floor", "target_floor", payload.TargetFloor, "total_floors", system.TotalFloors)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid target floor",
-			})
+			return fmt.Errorf("%w: %d", domain.ErrInvalidFloor, payload.TargetFloor)
 		}
 
 		// If everything is valid, add the parsed payload to the context for the next handler
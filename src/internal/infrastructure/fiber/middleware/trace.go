@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/Avyukth/lift-simulation/pkg/web"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TraceIDHeader carries the trace ID of a request in both directions
+const TraceIDHeader = "X-Trace-ID"
+
+// Trace assigns every request a trace ID, taken from the X-Trace-ID header when
+// the caller sends one, and stores it in the request's user context so that
+// services, events and logs can be followed back to the request.
+func Trace() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// The trace ID is kept in contexts that outlive the request, and fiber
+		// reuses the memory behind header values once the handler returns
+		traceID := strings.Clone(c.Get(TraceIDHeader))
+		if _, err := uuid.Parse(traceID); err != nil {
+			traceID = uuid.New().String()
+		}
+
+		c.SetUserContext(web.WithTraceID(c.UserContext(), traceID))
+		c.Set(TraceIDHeader, traceID)
+
+		return c.Next()
+	}
+}
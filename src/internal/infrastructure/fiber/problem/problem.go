@@ -0,0 +1,100 @@
+// Package problem renders errors as RFC 7807 problem details. It is the single
+// place where domain error kinds are mapped to HTTP status codes.
+package problem
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ContentType is the media type of a problem details document
+const ContentType = "application/problem+json"
+
+// Details is an RFC 7807 problem details document
+type Details struct {
+	Type     string   `json:"type"`
+	Title    string   `json:"title"`
+	Status   int      `json:"status"`
+	Detail   string   `json:"detail,omitempty"`
+	Instance string   `json:"instance,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// kinds maps each domain error kind to its status code and problem type
+var kinds = []struct {
+	err    error
+	status int
+	typ    string
+}{
+	{domain.ErrNotFound, fiber.StatusNotFound, "/problems/not-found"},
+	{domain.ErrConflict, fiber.StatusConflict, "/problems/conflict"},
+	{domain.ErrCapacityExceeded, fiber.StatusConflict, "/problems/capacity-exceeded"},
+	{domain.ErrInvalidTransition, fiber.StatusConflict, "/problems/invalid-state-transition"},
+	{domain.ErrSystemNotConfigured, fiber.StatusPreconditionFailed, "/problems/system-not-configured"},
+	{domain.ErrInvalidArgument, fiber.StatusBadRequest, "/problems/invalid-argument"},
+	{domain.ErrUnauthenticated, fiber.StatusUnauthorized, "/problems/unauthenticated"},
+	{domain.ErrForbidden, fiber.StatusForbidden, "/problems/forbidden"},
+	{domain.ErrIdempotencyMismatch, fiber.StatusUnprocessableEntity, "/problems/idempotency-key-reused"},
+	{domain.ErrRateLimited, fiber.StatusTooManyRequests, "/problems/rate-limited"},
+}
+
+// FromError builds the problem details for err. Errors that do not belong to
+// a known kind are reported as an internal server error without exposing the
+// underlying message.
+func FromError(err error) Details {
+	var validation *domain.ValidationError
+	if errors.As(err, &validation) {
+		return Details{
+			Type:   "/problems/validation-failed",
+			Title:  http.StatusText(fiber.StatusUnprocessableEntity),
+			Status: fiber.StatusUnprocessableEntity,
+			Detail: "The document failed validation",
+			Errors: validation.Problems,
+		}
+	}
+
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return Details{
+				Type:   k.typ,
+				Title:  http.StatusText(k.status),
+				Status: k.status,
+				Detail: err.Error(),
+			}
+		}
+	}
+
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return New(fe.Code, fe.Message)
+	}
+
+	return New(fiber.StatusInternalServerError, "")
+}
+
+// New builds a generic problem for the status code
+func New(status int, detail string) Details {
+	return Details{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Send writes the problem as the response. The instance defaults to the
+// request path without its query string, which may carry an access token.
+func Send(c *fiber.Ctx, p Details) error {
+	if p.Instance == "" {
+		p.Instance = c.Path()
+	}
+	return c.Status(p.Status).JSON(p, ContentType)
+}
+
+// Write maps err to a problem and writes it as the response
+func Write(c *fiber.Ctx, err error) error {
+	return Send(c, FromError(err))
+}
@@ -4,10 +4,10 @@ import (
 	"net/http"
 
 	"github.com/Avyukth/lift-simulation/internal/config"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
 	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
 
 	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/middleware"
-	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
@@ -21,16 +21,37 @@ func SetupRoutes(config config.RouteConfig) {
 	app := config.App
 	liftHandler := config.LiftHandler
 	floorHandler := config.FloorHandler
+	moveHandler := config.MoveHandler
+	callHandler := config.CallHandler
 	systemHandler := config.SystemHandler
+	eventHandler := config.EventHandler
+	webhookHandler := config.WebhookHandler
+	apiKeyHandler := config.APIKeyHandler
+	auditHandler := config.AuditHandler
+	streamHandler := config.StreamHandler
 	hub := config.Hub
 	fiberLog := config.FiberLog
 	repo := config.Repo
 
-	authConfig := middleware.Config{
-		JWTSecret: "your-jwt-secret",
-	}
+	authenticate := middleware.New(middleware.Config{
+		Auth:    config.Auth,
+		APIKeys: config.APIKeys,
+	})
+	viewer := middleware.RequireRole(auth.RoleViewer)
+	operator := middleware.RequireRole(auth.RoleOperator)
+	maintenance := middleware.RequireRole(auth.RoleMaintenance)
+	admin := middleware.RequireRole(auth.RoleAdmin)
+	// Hall calls are also open to calls-only API keys
+	callReader := middleware.RequireRole(auth.RoleViewer, auth.RoleCaller)
+	caller := middleware.RequireRole(auth.RoleOperator, auth.RoleCaller)
+
+	// Responses carrying a secret are never stored for idempotent replay
+	holdsSecret := middleware.HoldsSecret()
+
+	rateLimit := middleware.RateLimitClients(config.ClientLimiter)
+	floorButton := middleware.RateLimitFloorButtons(config.FloorLimiter)
+
 	systemVerification := middleware.NewSystemVerificationMiddleware(repo, fiberLog)
-	_ = middleware.New(authConfig)
 	app.Use(cors.New())
 
 	// Swagger documentation
@@ -51,80 +72,103 @@ func SetupRoutes(config config.RouteConfig) {
 		return c.SendString("OK")
 	})
 
+	// Everything below the health check needs a token and is rate limited
+	// per client. Every change is audited, and changes sent with an
+	// idempotency key are safe to retry.
+	api.Use(authenticate, rateLimit, middleware.Audit(config.Auditor), middleware.Idempotency(config.Idempotency))
+
 	system := api.Group("/system")
 
-	system.Post("/configure", systemHandler.ConfigureSystem)
+	system.Post("/configure", admin, systemHandler.ConfigureSystem)
+	system.Patch("/", admin, systemHandler.ReconfigureSystem)
 
-	system.Get("/configuration", systemHandler.GetSystemConfiguration)
-	system.Get("/status", systemHandler.GetSystemStatus)
-	system.Post("/reset", systemHandler.ResetSystem)
-	system.Get("/metrics", systemHandler.GetSystemMetrics)
-	system.Post("/simulate-traffic", systemHandler.SimulateTraffic)
+	system.Get("/configuration", viewer, systemHandler.GetSystemConfiguration)
+	system.Get("/status", viewer, systemHandler.GetSystemStatus)
+	system.Post("/reset", admin, systemHandler.ResetSystem)
+	system.Get("/metrics", viewer, systemHandler.GetSystemMetrics)
+	system.Post("/simulate-traffic", operator, systemHandler.SimulateTraffic)
+	system.Get("/snapshot", admin, systemHandler.ExportSnapshot)
+	system.Post("/snapshot", admin, systemHandler.ImportSnapshot)
 
 	// Lift routes
 	lifts := api.Group("/lifts")
-	lifts.Get("/", liftHandler.ListLifts)
-	lifts.Put("/reset", liftHandler.ResetLifts)
-	lifts.Get("/:id", liftHandler.GetLift)
-	lifts.Post("/:id/move", systemVerification.VerifyLiftMove(), liftHandler.MoveLift)
-	lifts.Put("/:id/reset", liftHandler.ResetLift)
-	lifts.Put("/:id/status", liftHandler.SetLiftStatus)
+	lifts.Get("/", viewer, liftHandler.ListLifts)
+	lifts.Post("/", maintenance, systemHandler.AddLift)
+	lifts.Put("/reset", maintenance, liftHandler.ResetLifts)
+	lifts.Get("/:id", viewer, liftHandler.GetLift)
+	lifts.Patch("/:id", maintenance, systemHandler.UpdateLift)
+	lifts.Delete("/:id", maintenance, systemHandler.DecommissionLift)
+	lifts.Post("/:id/move", operator, systemVerification.VerifyLiftMove(), liftHandler.MoveLift)
+	lifts.Post("/:id/car-call", operator, systemVerification.VerifyLiftMove(), liftHandler.CarCall)
+	lifts.Post("/:id/door-hold", maintenance, liftHandler.HoldDoors)
+	lifts.Put("/:id/reset", maintenance, liftHandler.ResetLift)
+	lifts.Put("/:id/status", maintenance, liftHandler.SetLiftStatus)
+	lifts.Get("/:id/trips", viewer, liftHandler.ListLiftTrips)
+
+	// Background move routes
+	moves := api.Group("/moves")
+	moves.Get("/", viewer, moveHandler.ListMoves)
+	moves.Get("/:id", viewer, moveHandler.GetMove)
+	moves.Delete("/:id", operator, moveHandler.CancelMove)
+
+	// Trip history routes
+	api.Get("/trips", viewer, liftHandler.ListTrips)
 
 	// Floor routes
 	floors := api.Group("/floors")
-	floors.Get("/", floorHandler.ListFloors)
-	floors.Get("/active-calls", floorHandler.GetActiveFloorCalls)
-	floors.Get("/:floorNum", floorHandler.GetFloorStatus)
-	floors.Post("/:floorNum/call", floorHandler.CallLift)
-	floors.Post("/:floorNum/reset", floorHandler.ResetFloorButtons)
-
-	// WebSocket route for real-time updates
+	floors.Get("/", viewer, floorHandler.ListFloors)
+	floors.Get("/active-calls", viewer, floorHandler.GetActiveFloorCalls)
+	floors.Get("/:floorNum", viewer, floorHandler.GetFloorStatus)
+	floors.Post("/:floorNum/call", caller, floorButton, floorHandler.CallLift)
+	floors.Post("/:floorNum/reset", operator, floorHandler.ResetFloorButtons)
+
+	// Hall call routes
+	calls := api.Group("/calls")
+	calls.Get("/", callReader, callHandler.ListCalls)
+	calls.Get("/:id", callReader, callHandler.GetCall)
+	calls.Delete("/:id", caller, callHandler.CancelCall)
+
+	// Event store routes
+	events := api.Group("/events")
+	events.Get("/", viewer, eventHandler.ListEvents)
+	events.Post("/replay", admin, eventHandler.ReplayEvents)
+
+	// Server-Sent Events stream of the event log
+	api.Get("/stream", viewer, streamHandler.Stream)
+
+	// Webhook routes. Webhooks carry their signing secrets, so they are
+	// managed by admins only.
+	webhooks := api.Group("/webhooks", admin)
+	webhooks.Post("/", holdsSecret, webhookHandler.CreateWebhook)
+	webhooks.Get("/", webhookHandler.ListWebhooks)
+	webhooks.Get("/:id", webhookHandler.GetWebhook)
+	webhooks.Put("/:id", webhookHandler.UpdateWebhook)
+	webhooks.Delete("/:id", webhookHandler.DeleteWebhook)
+
+	deliveries := api.Group("/webhook-deliveries", admin)
+	deliveries.Get("/", webhookHandler.ListDeliveries)
+	deliveries.Get("/:id", webhookHandler.GetDelivery)
+	deliveries.Post("/:id/retry", webhookHandler.RetryDelivery)
+
+	// API key routes
+	apiKeys := api.Group("/api-keys", admin)
+	apiKeys.Post("/", holdsSecret, apiKeyHandler.CreateAPIKey)
+	apiKeys.Get("/", apiKeyHandler.ListAPIKeys)
+	apiKeys.Get("/:id", apiKeyHandler.GetAPIKey)
+	apiKeys.Delete("/:id", apiKeyHandler.RevokeAPIKey)
+
+	// Audit log routes
+	api.Get("/audit", admin, auditHandler.ListAudit)
+
+	// WebSocket route for real-time updates. Viewers may subscribe; each
+	// command checks the caller's role on its own.
 	// WIP  websocket for emergency call and lift status
 	app.Get("/ws", ws.WebSocketHandler)
-	app.Get("/ws/connect", ws.WebSocketUpgradeHandler(hub))
+	commands := ws.LimitCommands(ws.AuditCommands(config.CommandHandler, config.Auditor), config.ClientLimiter, config.FloorLimiter)
+	app.Get("/ws/connect", authenticate, rateLimit, viewer, ws.WebSocketUpgradeHandler(hub, commands))
 
 	// 404 Handler
 	app.Use(func(c *fiber.Ctx) error {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Endpoint not found",
-		})
+		return fiber.NewError(fiber.StatusNotFound, "Endpoint not found")
 	})
 }
-
-// WebSocketHandler handles WebSocket connections for real-time updates
-func WebSocketHandler() fiber.Handler {
-	return websocket.New(func(c *websocket.Conn) {
-	})
-}
-
-// WebSocketConnectHandler handles the actual WebSocket connection and communication
-func WebSocketConnectHandler(hub *ws.WebSocketHub) func(*websocket.Conn) {
-	return func(c *websocket.Conn) {
-		// Create a new WebSocketClient for this connection
-		client := &ws.WebSocketClient{Conn: c}
-
-		// Register this client with the hub
-		hub.Register <- client
-
-		defer func() {
-			hub.Unregister <- client
-		}()
-
-		// Handle incoming messages
-		for {
-			messageType, message, err := c.ReadMessage()
-			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					// Log the error
-				}
-				break
-			}
-
-			if messageType == websocket.TextMessage {
-				if err := c.WriteMessage(websocket.TextMessage, message); err != nil {
-					break
-				}
-			}
-		}
-	}
-}
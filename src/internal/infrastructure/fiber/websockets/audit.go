@@ -0,0 +1,98 @@
+package websockets
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/problem"
+	"github.com/Avyukth/lift-simulation/pkg/web"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Auditor captures the state a command changes and records the command
+type Auditor interface {
+	State(ctx context.Context, subject domain.AuditSubject) (json.RawMessage, error)
+	Record(ctx context.Context, entry *domain.AuditEntry, before, after json.RawMessage)
+}
+
+type ctxKey int
+
+const sourceIPKey ctxKey = iota
+
+// withSourceIP returns a context carrying the address a connection came from
+func withSourceIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, sourceIPKey, ip)
+}
+
+// auditedCommands records every command in the audit log, like the REST
+// calls it stands in for
+type auditedCommands struct {
+	commands CommandExecutor
+	auditor  Auditor
+}
+
+// AuditCommands wraps commands so that each one is recorded by auditor
+func AuditCommands(commands CommandExecutor, auditor Auditor) CommandExecutor {
+	if commands == nil {
+		return nil
+	}
+	return &auditedCommands{commands: commands, auditor: auditor}
+}
+
+func (a *auditedCommands) Execute(ctx context.Context, op string, args json.RawMessage) (any, error) {
+	subject := commandSubject(op, args)
+
+	before, err := a.auditor.State(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	data, callErr := a.commands.Execute(ctx, op, args)
+
+	after, err := a.auditor.State(ctx, subject)
+	if err != nil {
+		// The command is done, so it is recorded without its changes
+		before = nil
+	}
+
+	claims, _ := auth.GetClaims(ctx)
+	ip, _ := ctx.Value(sourceIPKey).(string)
+
+	entry := &domain.AuditEntry{
+		Principal: claims.Subject,
+		SourceIP:  ip,
+		TraceID:   web.GetTraceID(ctx),
+		Method:    "WS",
+		Route:     "ws:" + op,
+		Path:      "/ws/connect",
+		Subject:   subject.String(),
+		Payload:   string(args),
+		Status:    fiber.StatusOK,
+		At:        time.Now().UTC(),
+	}
+	if callErr != nil {
+		entry.Status = problem.FromError(callErr).Status
+		entry.Error = callErr.Error()
+	}
+
+	a.auditor.Record(ctx, entry, before, after)
+	return data, callErr
+}
+
+// commandSubject is the lift a command acts on, or the floor of a hall call
+func commandSubject(op string, args json.RawMessage) domain.AuditSubject {
+	var target struct {
+		LiftID string `json:"liftId"`
+		Floor  int    `json:"floor"`
+	}
+	_ = json.Unmarshal(args, &target)
+
+	if op == OpCallLift {
+		return domain.AuditSubject{Kind: domain.AuditSubjectFloor, ID: strconv.Itoa(target.Floor)}
+	}
+	return domain.AuditSubject{Kind: domain.AuditSubjectLift, ID: target.LiftID}
+}
@@ -0,0 +1,171 @@
+package websockets
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+)
+
+// WebSocketClient represents a WebSocket client connection. Frames for the
+// client are queued on a bounded channel and written by the client's own
+// writer goroutine, so a slow connection never holds up the hub.
+type WebSocketClient struct {
+	Conn *websocket.Conn
+
+	queue     chan []byte
+	closeOnce sync.Once
+	done      chan struct{}
+	reason    string // close reason sent to the peer, set before done is closed
+
+	inflight chan struct{} // one token per running command
+
+	subsMu sync.RWMutex
+	subs   map[string]Subscription // keyed by topic
+}
+
+// NewWebSocketClient creates a client for the connection with no
+// subscriptions and room for queueSize outbound frames
+func NewWebSocketClient(conn *websocket.Conn, queueSize int) *WebSocketClient {
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	return &WebSocketClient{
+		Conn:     conn,
+		queue:    make(chan []byte, queueSize),
+		done:     make(chan struct{}),
+		inflight: make(chan struct{}, maxInflightCommands),
+		subs:     make(map[string]Subscription),
+	}
+}
+
+// Subscriptions returns the client's subscriptions ordered by topic
+func (c *WebSocketClient) Subscriptions() []Subscription {
+	c.subsMu.RLock()
+	defer c.subsMu.RUnlock()
+
+	subs := make([]Subscription, 0, len(c.subs))
+	for _, sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	slices.SortFunc(subs, func(a, b Subscription) int { return strings.Compare(a.Topic, b.Topic) })
+	return subs
+}
+
+// wants reports whether any subscription of the client covers the update
+func (c *WebSocketClient) wants(update StatusUpdate) bool {
+	c.subsMu.RLock()
+	defer c.subsMu.RUnlock()
+
+	for _, sub := range c.subs {
+		if sub.matches(update.Type, update.ID, update.Event) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *WebSocketClient) subscribe(topics, events []string) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	for _, topic := range topics {
+		c.subs[topic] = Subscription{Topic: topic, Events: events}
+	}
+}
+
+func (c *WebSocketClient) unsubscribe(topics []string) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	if len(topics) == 0 {
+		clear(c.subs)
+		return
+	}
+	for _, topic := range topics {
+		delete(c.subs, topic)
+	}
+}
+
+// enqueue queues an encoded frame without blocking. It reports false when
+// the queue is full or the client is closed.
+func (c *WebSocketClient) enqueue(data []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+
+	select {
+	case c.queue <- data:
+		return true
+	default:
+		return false
+	}
+}
+
+// send encodes and queues one frame
+func (c *WebSocketClient) send(msg ServerMessage) bool {
+	data, err := encodeFrame(msg)
+	if err != nil {
+		return false
+	}
+	return c.enqueue(data)
+}
+
+// close stops the writer, which closes the connection with reason
+func (c *WebSocketClient) close(reason string) {
+	c.closeOnce.Do(func() {
+		c.reason = reason
+		close(c.done)
+	})
+}
+
+// queueDepth returns the number of frames waiting to be written
+func (c *WebSocketClient) queueDepth() int {
+	return len(c.queue)
+}
+
+// writePump writes queued frames and heartbeat pings until the client is
+// closed or a write fails. Every write must finish within writeWait.
+func (c *WebSocketClient) writePump(pingInterval, writeWait time.Duration) {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		c.Conn.Close()
+	}()
+
+	for {
+		select {
+		case data := <-c.queue:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.close("write failed")
+				return
+			}
+
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close("ping failed")
+				return
+			}
+
+		case <-c.done:
+			msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, c.reason)
+			if c.reason == reasonSlowConsumer {
+				msg = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, c.reason)
+			}
+			c.Conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+func encodeFrame(msg ServerMessage) ([]byte, error) {
+	msg.Version = ProtocolVersion
+	return json.Marshal(msg)
+}
@@ -0,0 +1,116 @@
+package websockets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/problem"
+	"github.com/Avyukth/lift-simulation/pkg/web"
+	"github.com/google/uuid"
+)
+
+// maxInflightCommands is the number of commands a client may have running at
+// once. Moves take as long as the trip, so commands run beside the read loop.
+const maxInflightCommands = 8
+
+// CommandExecutor carries out command frames. Execute returns the data of the
+// result frame, or an error that is reported the way the REST API would
+// report it.
+type CommandExecutor interface {
+	Execute(ctx context.Context, op string, args json.RawMessage) (any, error)
+}
+
+// commandRoles is the role each command needs, matching the REST route that
+// does the same thing
+var commandRoles = map[string]auth.Role{
+	OpCallLift:  auth.RoleOperator,
+	OpCarCall:   auth.RoleOperator,
+	OpMoveLift:  auth.RoleOperator,
+	OpSetStatus: auth.RoleMaintenance,
+	OpDoorHold:  auth.RoleMaintenance,
+}
+
+func isCommand(op string) bool {
+	_, ok := commandRoles[op]
+	return ok
+}
+
+// authorize checks that the caller who opened the connection may run the
+// command. The token is checked again for expiry, as connections outlive it.
+func authorize(ctx context.Context, op string) error {
+	claims, ok := auth.GetClaims(ctx)
+	if !ok {
+		return fmt.Errorf("%w: authentication required", domain.ErrUnauthenticated)
+	}
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("%w: token has expired, reconnect with a new one", domain.ErrUnauthenticated)
+	}
+	if role := commandRoles[op]; !claims.Allows(role) {
+		return fmt.Errorf("%w: the %s role is required", domain.ErrForbidden, role)
+	}
+	return nil
+}
+
+// command starts a command and replies with its result or error once it has
+// finished. Each command gets its own trace ID, like a REST request.
+func (h *WebSocketHub) command(ctx context.Context, client *WebSocketClient, commands CommandExecutor, msg ClientMessage) {
+	if commands == nil {
+		h.reply(ctx, client, errorFrame(msg.ID, newProtocolError(ErrCodeUnknownOp, "commands are not available")))
+		return
+	}
+
+	if err := authorize(ctx, msg.Op); err != nil {
+		h.reply(ctx, client, commandErrorFrame(msg, err))
+		return
+	}
+
+	select {
+	case client.inflight <- struct{}{}:
+	default:
+		h.reply(ctx, client, errorFrame(msg.ID, newProtocolError(ErrCodeTooManyCommands, "at most %d commands may run at once", maxInflightCommands)))
+		return
+	}
+
+	go func() {
+		ctx := web.WithTraceID(ctx, uuid.New().String())
+		defer func() { <-client.inflight }()
+		defer func() {
+			if r := recover(); r != nil {
+				h.Log.Error(ctx, "WebSocket command panicked", "op", msg.Op, "panic", r)
+				h.reply(ctx, client, commandErrorFrame(msg, fmt.Errorf("command panicked: %v", r)))
+			}
+		}()
+
+		h.Log.Info(ctx, "Executing WebSocket command", "op", msg.Op, "request_id", msg.ID)
+		data, err := commands.Execute(ctx, msg.Op, msg.Args)
+		if err != nil {
+			h.Log.Info(ctx, "WebSocket command failed", "op", msg.Op, "request_id", msg.ID, "error", err)
+			h.reply(ctx, client, commandErrorFrame(msg, err))
+			return
+		}
+
+		h.reply(ctx, client, ServerMessage{Type: FrameResult, ID: msg.ID, Op: msg.Op, Data: data})
+	}()
+}
+
+// commandErrorFrame reports a failed command with the problem details of
+// the error
+func commandErrorFrame(msg ClientMessage, err error) ServerMessage {
+	details := problem.FromError(err)
+
+	message := details.Detail
+	if message == "" {
+		message = details.Title
+	}
+
+	return ServerMessage{
+		Type:  FrameError,
+		ID:    msg.ID,
+		Op:    msg.Op,
+		Error: &ErrorBody{Code: ErrCodeCommandFailed, Message: message, Problem: &details},
+	}
+}
@@ -0,0 +1,192 @@
+package websockets
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/problem"
+)
+
+// ProtocolVersion is the version of the WebSocket message protocol spoken by
+// this build. The protocol is described by docs/websocket-protocol.schema.json.
+const ProtocolVersion = 1
+
+// Client operations
+const (
+	OpSubscribe         = "subscribe"
+	OpUnsubscribe       = "unsubscribe"
+	OpListSubscriptions = "list_subscriptions"
+)
+
+// Command operations. Each is answered with a result or error frame once the
+// command has been carried out.
+const (
+	OpCallLift  = "call_lift"
+	OpCarCall   = "car_call"
+	OpMoveLift  = "move_lift"
+	OpSetStatus = "set_status"
+	OpDoorHold  = "door_hold"
+)
+
+// Server frame types
+const (
+	FrameAck      = "ack"
+	FrameError    = "error"
+	FrameUpdate   = "update"
+	FrameSnapshot = "snapshot"
+	FrameResult   = "result"
+)
+
+// Error codes sent in error frames
+const (
+	ErrCodeInvalidMessage     = "invalid_message"
+	ErrCodeUnsupportedVersion = "unsupported_version"
+	ErrCodeUnknownOp          = "unknown_op"
+	ErrCodeInvalidTopic       = "invalid_topic"
+	ErrCodeInvalidEvent       = "invalid_event"
+	ErrCodeTooManyCommands    = "too_many_commands"
+	// ErrCodeCommandFailed reports a command rejected by the service. The
+	// error carries the problem details the REST API would have returned.
+	ErrCodeCommandFailed = "command_failed"
+)
+
+// Topic kinds. A topic is "<kind>:<id>", where id is a lift ID or a floor
+// number, or "<kind>:*" for every lift or floor.
+const (
+	TopicLift  = "lift"
+	TopicFloor = "floor"
+
+	topicWildcard = "*"
+)
+
+// ClientMessage is a request frame sent by a client
+type ClientMessage struct {
+	Version int      `json:"v"`
+	ID      string   `json:"id,omitempty"` // echoed in the ack or error frame
+	Op      string   `json:"op"`
+	Topics  []string `json:"topics,omitempty"`
+	// Events restricts the subscribed topics to updates caused by these
+	// domain event types. Empty means every update.
+	Events []string `json:"events,omitempty"`
+	// ResumeFrom is the sequence number of the last update a reconnecting
+	// client received. Missed updates are replayed instead of a snapshot.
+	ResumeFrom *uint64 `json:"resume_from,omitempty"`
+	// Args are the arguments of a command
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// ServerMessage is a frame sent to a client
+type ServerMessage struct {
+	Version       int            `json:"v"`
+	Type          string         `json:"type"`
+	ID            string         `json:"id,omitempty"`
+	Op            string         `json:"op,omitempty"` // command answered by a result frame
+	Subscriptions []Subscription `json:"subscriptions,omitempty"`
+	Error         *ErrorBody     `json:"error,omitempty"`
+	Topic         string         `json:"topic,omitempty"`
+	// Seq numbers update frames. A snapshot carries the sequence number of
+	// the latest update it reflects.
+	Seq uint64 `json:"seq,omitempty"`
+	// Resumed is set on the ack of a subscribe whose resume_from could be
+	// served from the replay buffer
+	Resumed bool `json:"resumed,omitempty"`
+	Data    any  `json:"data,omitempty"`
+}
+
+// ErrorBody describes why a request frame was rejected
+type ErrorBody struct {
+	Code    string           `json:"code"`
+	Message string           `json:"message"`
+	Problem *problem.Details `json:"problem,omitempty"`
+}
+
+// CallLiftArgs are the arguments of call_lift, a hall call
+type CallLiftArgs struct {
+	Floor     int              `json:"floor"`
+	Direction domain.Direction `json:"direction"`
+}
+
+// MoveLiftArgs are the arguments of move_lift and car_call
+type MoveLiftArgs struct {
+	LiftID      string `json:"liftId"`
+	TargetFloor int    `json:"targetFloor"`
+	// Passengers board as the lift departs and get off where it stops
+	Passengers int `json:"passengers"`
+}
+
+// SetStatusArgs are the arguments of set_status
+type SetStatusArgs struct {
+	LiftID string            `json:"liftId"`
+	Status domain.LiftStatus `json:"status"`
+}
+
+// DoorHoldArgs are the arguments of door_hold
+type DoorHoldArgs struct {
+	LiftID  string `json:"liftId"`
+	Seconds int    `json:"seconds"`
+}
+
+// Subscription is one topic a client listens to
+type Subscription struct {
+	Topic  string   `json:"topic"`
+	Events []string `json:"events,omitempty"`
+}
+
+// matches reports whether an update of the given event type on the given
+// topic is delivered through the subscription
+func (s Subscription) matches(kind, id, event string) bool {
+	if s.Topic != kind+":"+id && s.Topic != kind+":"+topicWildcard {
+		return false
+	}
+	return len(s.Events) == 0 || slices.Contains(s.Events, event)
+}
+
+// protocolError is a request failure reported to the client in an error frame
+type protocolError struct {
+	code string
+	msg  string
+}
+
+func (e *protocolError) Error() string { return e.msg }
+
+func newProtocolError(code, format string, args ...any) *protocolError {
+	return &protocolError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// parseTopic validates a topic and returns it in canonical form
+func parseTopic(topic string) (string, error) {
+	kind, id, ok := strings.Cut(topic, ":")
+	if !ok || id == "" {
+		return "", newProtocolError(ErrCodeInvalidTopic, "topic %q must be lift:<id>, floor:<number> or a <kind>:* wildcard", topic)
+	}
+
+	switch kind {
+	case TopicLift:
+		return topic, nil
+	case TopicFloor:
+		if id == topicWildcard {
+			return topic, nil
+		}
+		n, err := strconv.Atoi(id)
+		if err != nil || n < 0 {
+			return "", newProtocolError(ErrCodeInvalidTopic, "topic %q does not name a floor number", topic)
+		}
+		return TopicFloor + ":" + strconv.Itoa(n), nil
+	default:
+		return "", newProtocolError(ErrCodeInvalidTopic, "unknown topic kind %q", kind)
+	}
+}
+
+// parseEvents validates an event type filter
+func parseEvents(names []string) ([]string, error) {
+	for _, name := range names {
+		if _, err := domain.ParseEventType(name); err != nil {
+			return nil, newProtocolError(ErrCodeInvalidEvent, "%v", err)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,53 @@
+package websockets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/ratelimit"
+)
+
+// RateLimiter decides whether another command may go ahead
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) ratelimit.Decision
+}
+
+// limitedCommands holds commands to the same limits as the REST calls they
+// stand in for
+type limitedCommands struct {
+	commands CommandExecutor
+	clients  RateLimiter
+	floors   RateLimiter
+}
+
+// LimitCommands wraps commands so that each client, and each floor button
+// pressed with call_lift, is rate limited
+func LimitCommands(commands CommandExecutor, clients, floors RateLimiter) CommandExecutor {
+	if commands == nil {
+		return nil
+	}
+	return &limitedCommands{commands: commands, clients: clients, floors: floors}
+}
+
+func (l *limitedCommands) Execute(ctx context.Context, op string, args json.RawMessage) (any, error) {
+	claims, _ := auth.GetClaims(ctx)
+	ip, _ := ctx.Value(sourceIPKey).(string)
+
+	if decision := l.clients.Allow(ctx, ratelimit.ClientKey(claims, ip)); !decision.Allowed {
+		return nil, fmt.Errorf("%w: too many commands from this client, retry in %ds", domain.ErrRateLimited, decision.RetryAfterSeconds())
+	}
+
+	if op == OpCallLift {
+		var call CallLiftArgs
+		if err := json.Unmarshal(args, &call); err == nil {
+			if decision := l.floors.Allow(ctx, ratelimit.FloorButtonKey(call.Floor, call.Direction)); !decision.Allowed {
+				return nil, fmt.Errorf("%w: the button on floor %d was pressed too often, retry in %ds", domain.ErrRateLimited, call.Floor, decision.RetryAfterSeconds())
+			}
+		}
+	}
+
+	return l.commands.Execute(ctx, op, args)
+}
@@ -0,0 +1,53 @@
+package websockets
+
+// replayEntry is a broadcast update kept for clients that reconnect
+type replayEntry struct {
+	update StatusUpdate
+	data   []byte // encoded update frame
+}
+
+// replayBuffer is a fixed size ring of the most recent updates. Sequence
+// numbers are assigned by the hub without gaps, so the buffer always holds a
+// contiguous run ending at the latest update.
+type replayBuffer struct {
+	entries []replayEntry
+	next    int
+	count   int
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{entries: make([]replayEntry, size)}
+}
+
+func (b *replayBuffer) add(entry replayEntry) {
+	if len(b.entries) == 0 {
+		return
+	}
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.count < len(b.entries) {
+		b.count++
+	}
+}
+
+// since returns the updates after seq, oldest first. It reports false when
+// some of them have already been overwritten.
+func (b *replayBuffer) since(seq, latest uint64) ([]replayEntry, bool) {
+	if seq > latest {
+		return nil, false
+	}
+	missed := latest - seq
+	if missed > uint64(b.count) {
+		return nil, false
+	}
+
+	entries := make([]replayEntry, 0, missed)
+	start := b.next - int(missed)
+	if start < 0 {
+		start += len(b.entries)
+	}
+	for i := 0; i < int(missed); i++ {
+		entries = append(entries, b.entries[(start+i)%len(b.entries)])
+	}
+	return entries, true
+}
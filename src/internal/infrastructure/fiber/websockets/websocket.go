@@ -3,9 +3,16 @@ package websockets
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
 	"github.com/Avyukth/lift-simulation/pkg/logger"
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
@@ -13,84 +20,232 @@ import (
 
 // StatusUpdate represents a status update for a floor or lift
 type StatusUpdate struct {
-	Type         string `json:"type"` // "floor" or "lift"
-	ID           string `json:"id"`   // Floor number or lift ID
+	Type         string `json:"type"`            // "floor" or "lift"
+	ID           string `json:"id"`              // Floor number or lift ID
+	Event        string `json:"event,omitempty"` // Domain event type that caused the update
 	Status       string `json:"status"`
-	CurrentFloor int    `json:"currentFloor,omitempty"` // Only for lifts
+	CurrentFloor int    `json:"currentFloor"` // Floor of the lift, or the floor itself
+
+	// Only for lifts
+	Direction   string    `json:"direction,omitempty"` // "up", "down" or "idle"
+	TargetFloor *int      `json:"targetFloor,omitempty"`
+	Position    *float64  `json:"position,omitempty"` // In floors from the ground floor
+	Load        *LiftLoad `json:"load,omitempty"`
+}
+
+// LiftLoad is the number of passengers in a lift and how many it can carry
+type LiftLoad struct {
+	Passengers int `json:"passengers"`
+	Capacity   int `json:"capacity"`
+}
+
+// NewLiftUpdate describes the state of a lift after the cause event
+func NewLiftUpdate(lift *domain.Lift, cause domain.EventType) StatusUpdate {
+	target := lift.TargetFloor
+	position := float64(lift.CurrentFloor)
+
+	return StatusUpdate{
+		Type:         TopicLift,
+		ID:           lift.ID,
+		Event:        cause.String(),
+		Status:       domain.LiftStatusToString(lift.Status),
+		CurrentFloor: lift.CurrentFloor,
+		Direction:    directionName(lift.Direction),
+		TargetFloor:  &target,
+		Position:     &position,
+		Load: &LiftLoad{
+			Passengers: lift.Passengers,
+			Capacity:   lift.Capacity,
+		},
+	}
+}
+
+func directionName(d domain.Direction) string {
+	switch d {
+	case domain.Up:
+		return "up"
+	case domain.Down:
+		return "down"
+	default:
+		return "idle"
+	}
 }
 
-// WebSocketClient represents a WebSocket client connection
-type WebSocketClient struct {
-	Conn     *websocket.Conn
-	Mu       sync.Mutex
-	FloorSub string // Subscribed floor number
-	LiftSub  string // Subscribed lift ID
+// reasonSlowConsumer is the close reason sent to evicted clients
+const reasonSlowConsumer = "slow consumer"
+
+// HubConfig tunes the delivery of frames to clients
+type HubConfig struct {
+	// SendQueue is the number of frames buffered per client. A client whose
+	// queue is full is evicted.
+	SendQueue int
+	// PingInterval is how often a ping is sent to every client
+	PingInterval time.Duration
+	// PongWait is how long a client may stay silent, including not answering
+	// pings, before it is disconnected. It must exceed PingInterval.
+	PongWait time.Duration
+	// WriteWait bounds each write to a client
+	WriteWait time.Duration
+	// ReplayBuffer is the number of recent updates kept for clients that
+	// reconnect with resume_from
+	ReplayBuffer int
 }
 
-// WebSocketHub maintains the set of active clients and broadcasts messages to the clients
+// StateReader loads the current lift and floor state sent to clients when
+// they subscribe
+type StateReader interface {
+	GetLift(ctx context.Context, id string) (*domain.Lift, error)
+	ListLifts(ctx context.Context) ([]*domain.Lift, error)
+	GetFloorByNumber(ctx context.Context, floorNum int) (*domain.Floor, error)
+	ListFloors(ctx context.Context) ([]*domain.Floor, error)
+}
+
+// HubStats is a point-in-time view of the hub
+type HubStats struct {
+	Clients    int    `json:"clients"`
+	QueueDepth int    `json:"queue_depth"`
+	Sent       uint64 `json:"sent"`
+	Dropped    uint64 `json:"dropped"`
+	Evicted    uint64 `json:"evicted"`
+	Sequence   uint64 `json:"sequence"`
+}
+
+// WebSocketHub maintains the set of active clients and broadcasts messages to
+// the clients. Broadcasting only queues frames, so it never waits on a
+// client's connection.
 type WebSocketHub struct {
-	Clients    map[*WebSocketClient]bool
-	Register   chan *WebSocketClient
-	Unregister chan *WebSocketClient
-	Broadcast  chan StatusUpdate
-	Mu         sync.Mutex
-	Log        *logger.Logger
+	cfg   HubConfig
+	state StateReader
+	Log   *logger.Logger
+
+	// seqMu orders broadcasts and keeps them from interleaving with the
+	// frames a new subscriber is caught up with
+	seqMu  sync.Mutex
+	seq    uint64
+	replay *replayBuffer
+
+	mu      sync.RWMutex
+	clients map[*WebSocketClient]struct{}
+
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	evicted atomic.Uint64
 }
 
-// NewWebSocketHub creates a new WebSocketHub
-func NewWebSocketHub(log *logger.Logger) *WebSocketHub {
+// NewWebSocketHub creates a new WebSocketHub. New subscribers are sent the
+// current state read from state.
+func NewWebSocketHub(cfg HubConfig, state StateReader, log *logger.Logger) *WebSocketHub {
+	if cfg.SendQueue < 1 {
+		cfg.SendQueue = 1
+	}
+	if cfg.PingInterval <= 0 {
+		cfg.PingInterval = 30 * time.Second
+	}
+	if cfg.PongWait <= cfg.PingInterval {
+		cfg.PongWait = cfg.PingInterval * 2
+	}
+	if cfg.WriteWait <= 0 {
+		cfg.WriteWait = 10 * time.Second
+	}
+	if cfg.ReplayBuffer < 0 {
+		cfg.ReplayBuffer = 0
+	}
+
 	return &WebSocketHub{
-		Clients:    make(map[*WebSocketClient]bool),
-		Register:   make(chan *WebSocketClient),
-		Unregister: make(chan *WebSocketClient),
-		Broadcast:  make(chan StatusUpdate),
-		Log:        log,
+		cfg:     cfg,
+		state:   state,
+		Log:     log,
+		replay:  newReplayBuffer(cfg.ReplayBuffer),
+		clients: make(map[*WebSocketClient]struct{}),
 	}
 }
 
-// Run starts the WebSocketHub
+// Run keeps the hub open until ctx is done and then disconnects every client
 func (h *WebSocketHub) Run(ctx context.Context) {
-	for {
-		select {
-		case <-ctx.Done():
-			h.Log.Info(ctx, "WebSocketHub shutting down")
-			return
-		case client := <-h.Register:
-			h.Mu.Lock()
-			h.Clients[client] = true
-			h.Log.Info(ctx, "New client registered", "total_clients", len(h.Clients))
-			h.Mu.Unlock()
-		case client := <-h.Unregister:
-			h.Mu.Lock()
-			if _, ok := h.Clients[client]; ok {
-				delete(h.Clients, client)
-				client.Conn.Close()
-				h.Log.Info(ctx, "Client unregistered", "total_clients", len(h.Clients))
-			}
-			h.Mu.Unlock()
-		case update := <-h.Broadcast:
-			h.Mu.Lock()
-			for client := range h.Clients {
-				if (update.Type == "floor" && client.FloorSub == update.ID) ||
-					(update.Type == "lift" && client.LiftSub == update.ID) {
-					client.Mu.Lock()
-					data, err := json.Marshal(update)
-					if err != nil {
-						h.Log.Error(ctx, "Error marshaling update", "error", err)
-						client.Mu.Unlock()
-						continue
-					}
-					if err := client.Conn.WriteMessage(websocket.TextMessage, data); err != nil {
-						h.Log.Error(ctx, "Error writing message to client", "error", err)
-						client.Conn.Close()
-						delete(h.Clients, client)
-					}
-					client.Mu.Unlock()
-				}
-			}
-			h.Mu.Unlock()
-		}
+	<-ctx.Done()
+	h.Log.Info(ctx, "WebSocketHub shutting down")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		client.close("server shutting down")
+		delete(h.clients, client)
+	}
+}
+
+// Stats returns the current hub counters
+func (h *WebSocketHub) Stats() HubStats {
+	h.seqMu.Lock()
+	seq := h.seq
+	h.seqMu.Unlock()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := HubStats{
+		Clients:  len(h.clients),
+		Sent:     h.sent.Load(),
+		Dropped:  h.dropped.Load(),
+		Evicted:  h.evicted.Load(),
+		Sequence: seq,
+	}
+	for client := range h.clients {
+		stats.QueueDepth += client.queueDepth()
 	}
+	return stats
+}
+
+func (h *WebSocketHub) register(ctx context.Context, client *WebSocketClient) {
+	h.mu.Lock()
+	h.clients[client] = struct{}{}
+	total := len(h.clients)
+	h.mu.Unlock()
+
+	h.Log.Info(ctx, "New client registered", "total_clients", total)
+}
+
+func (h *WebSocketHub) unregister(ctx context.Context, client *WebSocketClient) {
+	client.close("")
+
+	h.mu.Lock()
+	_, ok := h.clients[client]
+	delete(h.clients, client)
+	total := len(h.clients)
+	h.mu.Unlock()
+
+	if ok {
+		h.Log.Info(ctx, "Client unregistered", "total_clients", total)
+	}
+}
+
+// send queues a frame for one client, evicting the client when it has
+// fallen so far behind that its queue is full
+func (h *WebSocketHub) send(ctx context.Context, client *WebSocketClient, data []byte) {
+	if client.enqueue(data) {
+		h.sent.Add(1)
+		return
+	}
+
+	h.dropped.Add(1)
+	select {
+	case <-client.done:
+		return
+	default:
+	}
+
+	h.evicted.Add(1)
+	h.Log.Warn(ctx, "Evicting slow WebSocket client", "queue_depth", client.queueDepth())
+	client.close(reasonSlowConsumer)
+}
+
+// reply encodes and queues a frame for one client
+func (h *WebSocketHub) reply(ctx context.Context, client *WebSocketClient, msg ServerMessage) {
+	data, err := encodeFrame(msg)
+	if err != nil {
+		h.Log.Error(ctx, "Error encoding frame", "type", msg.Type, "error", err)
+		return
+	}
+	h.send(ctx, client, data)
 }
 
 // WebSocketHandler handles WebSocket connections for real-time updates
@@ -102,77 +257,307 @@ func WebSocketHandler(c *fiber.Ctx) error {
 	return fiber.ErrUpgradeRequired
 }
 
-// WebSocketUpgradeHandler handles the WebSocket upgrade
-func WebSocketUpgradeHandler(hub *WebSocketHub) fiber.Handler {
+// WebSocketUpgradeHandler handles the WebSocket upgrade and speaks the
+// subscription protocol with the client. Command frames are carried out by
+// commands, as the caller authenticated on the upgrade request.
+func WebSocketUpgradeHandler(hub *WebSocketHub, commands CommandExecutor) fiber.Handler {
 	return websocket.New(func(c *websocket.Conn) {
-		// Create a new client
-		client := &WebSocketClient{Conn: c}
+		ctx := context.Background()
+		if claims, ok := c.Locals(auth.ClaimsKey).(auth.Claims); ok {
+			ctx = auth.WithClaims(ctx, claims)
+		}
+		ctx = withSourceIP(ctx, c.IP())
+		client := NewWebSocketClient(c, hub.cfg.SendQueue)
 
-		// Register the client
-		hub.Register <- client
+		// The connection is recycled once this function returns, so wait
+		// for the writer to let go of it first
+		writerDone := make(chan struct{})
+		go func() {
+			defer close(writerDone)
+			client.writePump(hub.cfg.PingInterval, hub.cfg.WriteWait)
+		}()
 
-		// Ensure the client is unregistered when the function returns
+		hub.register(ctx, client)
 		defer func() {
-			hub.Unregister <- client
+			hub.unregister(ctx, client)
+			<-writerDone
 		}()
 
+		c.SetReadDeadline(time.Now().Add(hub.cfg.PongWait))
+		c.SetPongHandler(func(string) error {
+			return c.SetReadDeadline(time.Now().Add(hub.cfg.PongWait))
+		})
+
 		for {
-			// Read message from the WebSocket connection
 			_, message, err := c.ReadMessage()
 			if err != nil {
-				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					hub.Log.Error(context.Background(), "websocket_error", "Error writing message to client", "error", err)
-					c.Locals("websocket_error", err.Error())
+				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure, websocket.CloseAbnormalClosure) {
+					hub.Log.Error(ctx, "Error reading message from client", "error", err)
 				}
 				break
 			}
+			c.SetReadDeadline(time.Now().Add(hub.cfg.PongWait))
+
+			hub.handleMessage(ctx, client, commands, message)
+		}
+	})
+}
+
+// handleMessage applies one client request and replies with an ack or error
+// frame. A subscribe is also followed by the frames that bring the client up
+// to date on the new topics, and commands are answered once they finish.
+func (h *WebSocketHub) handleMessage(ctx context.Context, client *WebSocketClient, commands CommandExecutor, message []byte) {
+	msg, err := decodeClientMessage(message)
+	if err != nil {
+		h.reply(ctx, client, errorFrame(msg.ID, err))
+		return
+	}
+	if msg.ResumeFrom != nil && msg.Op != OpSubscribe {
+		h.reply(ctx, client, errorFrame(msg.ID, newProtocolError(ErrCodeInvalidMessage, "resume_from is only valid with subscribe")))
+		return
+	}
+
+	if isCommand(msg.Op) {
+		h.command(ctx, client, commands, msg)
+		return
+	}
+
+	switch msg.Op {
+	case OpSubscribe:
+		if len(msg.Topics) == 0 {
+			h.reply(ctx, client, errorFrame(msg.ID, newProtocolError(ErrCodeInvalidTopic, "subscribe needs at least one topic")))
+			return
+		}
+		topics, err := parseTopics(msg.Topics)
+		if err != nil {
+			h.reply(ctx, client, errorFrame(msg.ID, err))
+			return
+		}
+		events, err := parseEvents(msg.Events)
+		if err != nil {
+			h.reply(ctx, client, errorFrame(msg.ID, err))
+			return
+		}
+		h.subscribe(ctx, client, msg.ID, topics, events, msg.ResumeFrom)
+		return
 
-			// Parse the subscription message
-			var subscription struct {
-				Type string      `json:"type"`
-				ID   interface{} `json:"id"`
+	case OpUnsubscribe:
+		topics, err := parseTopics(msg.Topics)
+		if err != nil {
+			h.reply(ctx, client, errorFrame(msg.ID, err))
+			return
+		}
+		client.unsubscribe(topics)
+		h.Log.Info(ctx, "Client unsubscribed", "topics", topics)
+
+	case OpListSubscriptions:
+
+	default:
+		h.reply(ctx, client, errorFrame(msg.ID, newProtocolError(ErrCodeUnknownOp, "unknown op %q", msg.Op)))
+		return
+	}
+
+	h.reply(ctx, client, ServerMessage{
+		Type:          FrameAck,
+		ID:            msg.ID,
+		Subscriptions: client.Subscriptions(),
+	})
+}
+
+// subscribe adds the topics to the client and catches it up on them. When
+// resumeFrom is still covered by the replay buffer the updates the client
+// missed are replayed; otherwise it is sent a snapshot of the current state
+// followed by any update recorded while the snapshot was read.
+func (h *WebSocketHub) subscribe(ctx context.Context, client *WebSocketClient, id string, topics, events []string, resumeFrom *uint64) {
+	subs := make([]Subscription, 0, len(topics))
+	for _, topic := range topics {
+		subs = append(subs, Subscription{Topic: topic, Events: events})
+	}
+
+	h.seqMu.Lock()
+	if resumeFrom != nil {
+		if missed, ok := h.replay.since(*resumeFrom, h.seq); ok {
+			defer h.seqMu.Unlock()
+
+			client.subscribe(topics, events)
+			h.reply(ctx, client, ServerMessage{Type: FrameAck, ID: id, Seq: h.seq, Resumed: true, Subscriptions: client.Subscriptions()})
+			h.replayTo(ctx, client, subs, missed)
+			h.Log.Info(ctx, "Client resumed", "topics", topics, "events", events, "resume_from", *resumeFrom, "replayed", len(missed))
+			return
+		}
+	}
+	from := h.seq
+	h.seqMu.Unlock()
+
+	snapshot := h.snapshot(ctx, id, topics)
+
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+
+	client.subscribe(topics, events)
+	h.reply(ctx, client, ServerMessage{Type: FrameAck, ID: id, Seq: h.seq, Subscriptions: client.Subscriptions()})
+	for _, frame := range snapshot {
+		frame.Seq = from
+		h.reply(ctx, client, frame)
+	}
+	missed, _ := h.replay.since(from, h.seq)
+	h.replayTo(ctx, client, subs, missed)
+
+	h.Log.Info(ctx, "Client subscribed", "topics", topics, "events", events, "snapshots", len(snapshot))
+}
+
+// replayTo queues the replayed updates covered by subs
+func (h *WebSocketHub) replayTo(ctx context.Context, client *WebSocketClient, subs []Subscription, entries []replayEntry) {
+	for _, entry := range entries {
+		for _, sub := range subs {
+			if sub.matches(entry.update.Type, entry.update.ID, entry.update.Event) {
+				h.send(ctx, client, entry.data)
+				break
 			}
-			if err := json.Unmarshal(message, &subscription); err != nil {
-				hub.Log.Error(context.Background(), "websocket_error", "Error unmarshaling subscription: "+err.Error())
+		}
+	}
+}
+
+// snapshot reads the current state of every lift or floor named by the
+// topics. Lifts and floors that do not exist are skipped.
+func (h *WebSocketHub) snapshot(ctx context.Context, id string, topics []string) []ServerMessage {
+	var frames []ServerMessage
+	add := func(kind, key string, data any) {
+		frames = append(frames, ServerMessage{Type: FrameSnapshot, ID: id, Topic: kind + ":" + key, Data: data})
+	}
+
+	for _, topic := range topics {
+		kind, key, _ := strings.Cut(topic, ":")
+
+		switch {
+		case kind == TopicLift && key == topicWildcard:
+			lifts, err := h.state.ListLifts(ctx)
+			if err != nil {
+				h.Log.Error(ctx, "Failed to read lifts for snapshot", "error", err)
 				continue
 			}
+			for _, lift := range lifts {
+				add(TopicLift, lift.ID, lift)
+			}
 
-			// Handle the subscription
-			switch subscription.Type {
-			case "floor":
-				// Parse floor ID as an integer
-				floorID, ok := subscription.ID.(float64)
-				if !ok {
-					c.Locals("websocket_error", "Invalid floor ID format")
-					hub.Log.Error(context.Background(), "websocket_error", "Issue with FloorID ", floorID)
-
-					continue
+		case kind == TopicLift:
+			lift, err := h.state.GetLift(ctx, key)
+			if err != nil {
+				if !errors.Is(err, domain.ErrNotFound) {
+					h.Log.Error(ctx, "Failed to read lift for snapshot", "lift_id", key, "error", err)
 				}
-				client.FloorSub = strconv.Itoa(int(floorID))
-				hub.Log.Info(context.Background(), "websocket_info", "Client subscribed to floor: "+client.FloorSub)
+				continue
+			}
+			add(TopicLift, lift.ID, lift)
 
-			case "lift":
-				// Keep lift ID as a string
-				liftID, ok := subscription.ID.(string)
-				if !ok {
-					c.Locals("websocket_error", "Invalid lift ID format")
-					hub.Log.Error(context.Background(), "websocket_error", "Issue with liftID ", liftID)
+		case key == topicWildcard:
+			floors, err := h.state.ListFloors(ctx)
+			if err != nil {
+				h.Log.Error(ctx, "Failed to read floors for snapshot", "error", err)
+				continue
+			}
+			for _, floor := range floors {
+				add(TopicFloor, strconv.Itoa(floor.Number), floor)
+			}
 
-					continue
+		default:
+			floorNum, _ := strconv.Atoi(key)
+			floor, err := h.state.GetFloorByNumber(ctx, floorNum)
+			if err != nil {
+				if !errors.Is(err, domain.ErrNotFound) {
+					h.Log.Error(ctx, "Failed to read floor for snapshot", "floor", floorNum, "error", err)
 				}
-				client.LiftSub = liftID
-				c.Locals("websocket_info", "Client subscribed to lift: "+client.LiftSub)
-				hub.Log.Info(context.Background(), "websocket_info", "Client subscribed to floor: "+client.LiftSub)
-
-			default:
-				c.Locals("websocket_warning", "Unknown subscription type: "+subscription.Type)
-				hub.Log.Info(context.Background(), "websocket_warning", "Unknown subscription type:  "+subscription.Type)
+				continue
 			}
+			add(TopicFloor, key, floor)
 		}
-	})
+	}
+	return frames
+}
+
+// decodeClientMessage parses a request frame. The unversioned
+// {"type": "floor"|"lift", "id": ...} message of the original protocol is
+// still accepted as a subscription to that single topic.
+func decodeClientMessage(message []byte) (ClientMessage, error) {
+	var probe struct {
+		Version int    `json:"v"`
+		Op      string `json:"op"`
+		Type    string `json:"type"`
+		ID      any    `json:"id"`
+	}
+	if err := json.Unmarshal(message, &probe); err != nil {
+		return ClientMessage{}, newProtocolError(ErrCodeInvalidMessage, "message is not valid JSON: %v", err)
+	}
+
+	if probe.Version == 0 && probe.Op == "" && probe.Type != "" {
+		return ClientMessage{
+			Version: ProtocolVersion,
+			Op:      OpSubscribe,
+			Topics:  []string{fmt.Sprintf("%s:%v", probe.Type, probe.ID)},
+		}, nil
+	}
+
+	var msg ClientMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return msg, newProtocolError(ErrCodeInvalidMessage, "malformed %s message: %v", probe.Op, err)
+	}
+	if msg.Version != ProtocolVersion {
+		return msg, newProtocolError(ErrCodeUnsupportedVersion, "unsupported protocol version %d, expected %d", msg.Version, ProtocolVersion)
+	}
+	return msg, nil
 }
 
-// BroadcastUpdate sends an update to all relevant WebSocket clients
+func parseTopics(raw []string) ([]string, error) {
+	topics := make([]string, 0, len(raw))
+	for _, t := range raw {
+		topic, err := parseTopic(t)
+		if err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+func errorFrame(id string, err error) ServerMessage {
+	body := &ErrorBody{Code: ErrCodeInvalidMessage, Message: err.Error()}
+
+	var perr *protocolError
+	if errors.As(err, &perr) {
+		body.Code = perr.code
+	}
+
+	return ServerMessage{Type: FrameError, ID: id, Error: body}
+}
+
+// BroadcastUpdate stamps an update with the next sequence number, keeps it
+// for replay and queues it for every client subscribed to it. It returns
+// without waiting for any client to receive the update.
 func (h *WebSocketHub) BroadcastUpdate(update StatusUpdate) {
-	h.Broadcast <- update
+	ctx := context.Background()
+
+	h.seqMu.Lock()
+	defer h.seqMu.Unlock()
+
+	data, err := encodeFrame(ServerMessage{
+		Type:  FrameUpdate,
+		Topic: update.Type + ":" + update.ID,
+		Seq:   h.seq + 1,
+		Data:  update,
+	})
+	if err != nil {
+		h.Log.Error(ctx, "Error marshaling update", "error", err)
+		return
+	}
+	h.seq++
+	h.replay.add(replayEntry{update: update, data: data})
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.wants(update) {
+			h.send(ctx, client, data)
+		}
+	}
 }
@@ -0,0 +1,235 @@
+// Package metrics exposes the API's runtime, HTTP, event bus, WebSocket,
+// storage and domain metrics in the Prometheus text format. Metrics are kept
+// in the process and served by Handler, so no Prometheus server is needed to
+// read them.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/ratelimit"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the name of every metric of the API
+const namespace = "lift"
+
+// EventBus reports the counters of the event bus
+type EventBus interface {
+	Stats() events.BusStats
+}
+
+// WebSocketHub reports the counters of the WebSocket hub
+type WebSocketHub interface {
+	Stats() ws.HubStats
+}
+
+// RateLimiter reports the decisions of a rate limiter
+type RateLimiter interface {
+	Stats() ratelimit.Stats
+}
+
+// Metrics holds the metrics of the API in a registry of its own
+type Metrics struct {
+	registry *prometheus.Registry
+	log      *logger.Logger
+
+	requestDuration *prometheus.HistogramVec
+	handlerDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	queryErrors     *prometheus.CounterVec
+
+	trips           *prometheus.CounterVec
+	floorsTravelled prometheus.Counter
+	callsClosed     *prometheus.CounterVec
+	callWait        prometheus.Histogram
+}
+
+// New creates the metrics, together with the Go runtime and process metrics
+func New(log *logger.Logger) *Metrics {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	m := &Metrics{
+		registry: registry,
+		log:      log,
+
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to answer HTTP requests, by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		handlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "event_bus",
+			Name:      "handler_duration_seconds",
+			Help:      "Time taken by event handlers, by event type.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"event_type"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "query_duration_seconds",
+			Help:      "Time taken by repository operations, by operation.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 9),
+		}, []string{"operation"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "errors_total",
+			Help:      "Repository operations that failed, by operation. Lookups of missing records are not failures.",
+		}, []string{"operation"}),
+
+		trips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "trips_total",
+			Help:      "Completed lift trips, by what caused them.",
+		}, []string{"trigger"}),
+		floorsTravelled: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "floors_travelled_total",
+			Help:      "Floors travelled by all lifts.",
+		}),
+		callsClosed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "hall_calls",
+			Name:      "closed_total",
+			Help:      "Hall calls that reached a final state, by that state.",
+		}, []string{"status"}),
+		callWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "hall_calls",
+			Name:      "wait_seconds",
+			Help:      "Time from a hall button being pressed until a lift arrived.",
+			Buckets:   []float64{1, 2, 5, 10, 15, 20, 30, 45, 60, 90, 120, 300},
+		}),
+	}
+
+	registry.MustRegister(
+		m.requestDuration,
+		m.handlerDuration,
+		m.queryDuration,
+		m.queryErrors,
+		m.trips,
+		m.floorsTravelled,
+		m.callsClosed,
+		m.callWait,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records an answered HTTP request
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	m.requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(d.Seconds())
+}
+
+// ObserveHandler records an event handler having run
+func (m *Metrics) ObserveHandler(eventType domain.EventType, d time.Duration) {
+	m.handlerDuration.WithLabelValues(eventType.String()).Observe(d.Seconds())
+}
+
+// TripRecorded counts a completed trip and the floors it covered
+func (m *Metrics) TripRecorded(trip *domain.Trip) {
+	m.trips.WithLabelValues(string(trip.Trigger)).Inc()
+	m.floorsTravelled.Add(float64(trip.FloorsTravelled()))
+}
+
+// HallCallClosed counts a closed call and, when a lift arrived, how long the
+// caller waited for it
+func (m *Metrics) HallCallClosed(call *domain.HallCall) {
+	m.callsClosed.WithLabelValues(string(call.Status)).Inc()
+	if call.Status == domain.CallServed && call.ClosedAt != nil {
+		m.callWait.Observe(call.ClosedAt.Sub(call.CreatedAt).Seconds())
+	}
+}
+
+// RegisterEventBus exposes the counters and queue depth of the event bus
+func (m *Metrics) RegisterEventBus(bus EventBus) {
+	counter := func(name, help string, value func(events.BusStats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "event_bus",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(bus.Stats())) })
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "event_bus",
+			Name:      "queue_depth",
+			Help:      "Events queued for the bus workers.",
+		}, func() float64 { return float64(bus.Stats().QueueDepth) }),
+		counter("events_published_total", "Events published on the bus.", func(s events.BusStats) uint64 { return s.Published }),
+		counter("events_delivered_total", "Events delivered to handlers.", func(s events.BusStats) uint64 { return s.Delivered }),
+		counter("events_dropped_total", "Events dropped because a queue was full or the bus was closed.", func(s events.BusStats) uint64 { return s.Dropped }),
+		counter("handler_panics_total", "Event handlers that panicked.", func(s events.BusStats) uint64 { return s.Panics }),
+	)
+}
+
+// RegisterWebSocketHub exposes the client count and counters of the hub
+func (m *Metrics) RegisterWebSocketHub(hub WebSocketHub) {
+	gauge := func(name, help string, value func(ws.HubStats) int) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "websocket",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(hub.Stats())) })
+	}
+	counter := func(name, help string, value func(ws.HubStats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "websocket",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(hub.Stats())) })
+	}
+
+	m.registry.MustRegister(
+		gauge("clients", "Connected WebSocket clients.", func(s ws.HubStats) int { return s.Clients }),
+		gauge("queued_frames", "Frames queued for connected clients.", func(s ws.HubStats) int { return s.QueueDepth }),
+		counter("frames_sent_total", "Frames written to clients.", func(s ws.HubStats) uint64 { return s.Sent }),
+		counter("frames_dropped_total", "Frames dropped for clients too slow to keep up.", func(s ws.HubStats) uint64 { return s.Dropped }),
+		counter("clients_evicted_total", "Clients disconnected for falling behind.", func(s ws.HubStats) uint64 { return s.Evicted }),
+	)
+}
+
+// RegisterRateLimiter exposes the decisions of the limiter named name
+func (m *Metrics) RegisterRateLimiter(name string, limiter RateLimiter) {
+	decisions := func(decision string, value func(ratelimit.Stats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   "rate_limit",
+			Name:        "decisions_total",
+			Help:        "Rate limit decisions, by limiter and outcome. Errors are requests let through because the store could not be reached.",
+			ConstLabels: prometheus.Labels{"limiter": name, "decision": decision},
+		}, func() float64 { return float64(value(limiter.Stats())) })
+	}
+
+	m.registry.MustRegister(
+		decisions("allowed", func(s ratelimit.Stats) uint64 { return s.Allowed }),
+		decisions("limited", func(s ratelimit.Stats) uint64 { return s.Limited }),
+		decisions("error", func(s ratelimit.Stats) uint64 { return s.Errors }),
+	)
+}
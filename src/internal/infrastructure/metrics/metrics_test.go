@@ -0,0 +1,237 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	ws "github.com/Avyukth/lift-simulation/internal/infrastructure/fiber/websockets"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/metrics"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/ratelimit"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+type fakeBus events.BusStats
+
+func (b fakeBus) Stats() events.BusStats { return events.BusStats(b) }
+
+type fakeHub ws.HubStats
+
+func (h fakeHub) Stats() ws.HubStats { return ws.HubStats(h) }
+
+type fakeLimiter ratelimit.Stats
+
+func (l fakeLimiter) Stats() ratelimit.Stats { return ratelimit.Stats(l) }
+
+// fakeState holds the lifts and the number of hall calls in each status, or
+// fails every read with err
+type fakeState struct {
+	lifts []*domain.Lift
+	calls map[domain.HallCallStatus]int
+	err   error
+}
+
+func (s fakeState) ListLifts(ctx context.Context) ([]*domain.Lift, error) {
+	return s.lifts, s.err
+}
+
+func (s fakeState) ListHallCalls(ctx context.Context, filter domain.HallCallFilter) ([]*domain.HallCall, int, error) {
+	return nil, s.calls[filter.Status], s.err
+}
+
+func newMetrics() *metrics.Metrics {
+	return metrics.New(logger.New(io.Discard, logger.LevelError, "TEST", nil))
+}
+
+func TestHandler(t *testing.T) {
+	m := newMetrics()
+
+	m.RegisterEventBus(fakeBus{Published: 10, Delivered: 9, Dropped: 1, QueueDepth: 3})
+	m.RegisterWebSocketHub(fakeHub{Clients: 2, QueueDepth: 5, Sent: 40, Dropped: 4, Evicted: 1})
+	m.RegisterRateLimiter("client", fakeLimiter{Allowed: 7, Limited: 2, Errors: 1})
+
+	occupied := domain.NewLift("lift-2", "L2")
+	occupied.SetStatus(domain.Occupied)
+	m.RegisterState(fakeState{
+		lifts: []*domain.Lift{domain.NewLift("lift-1", "L1"), occupied, domain.NewLift("lift-3", "L3")},
+		calls: map[domain.HallCallStatus]int{domain.CallPending: 4, domain.CallAssigned: 1},
+	})
+
+	m.ObserveRequest("GET", "/api/v1/lifts/:id", http.StatusOK, 30*time.Millisecond)
+	m.ObserveRequest("GET", "/api/v1/lifts/:id", http.StatusOK, 10*time.Millisecond)
+	m.ObserveRequest("POST", "/api/v1/floors/:floorNum/call", http.StatusTooManyRequests, time.Millisecond)
+	m.ObserveHandler(domain.LiftArrived, 2*time.Millisecond)
+
+	m.TripRecorded(&domain.Trip{OriginFloor: 0, DestinationFloor: 5, Trigger: domain.TripHallCall})
+	m.TripRecorded(&domain.Trip{OriginFloor: 5, DestinationFloor: 2, Trigger: domain.TripCarCall})
+
+	pressed := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	arrived, cancelled := pressed.Add(12*time.Second), pressed.Add(3*time.Second)
+	m.HallCallClosed(&domain.HallCall{Status: domain.CallServed, CreatedAt: pressed, ClosedAt: &arrived})
+	m.HallCallClosed(&domain.HallCall{Status: domain.CallCancelled, CreatedAt: pressed, ClosedAt: &cancelled})
+
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+
+	expected := `
+# HELP lift_event_bus_events_dropped_total Events dropped because a queue was full or the bus was closed.
+# TYPE lift_event_bus_events_dropped_total counter
+lift_event_bus_events_dropped_total 1
+# HELP lift_event_bus_events_published_total Events published on the bus.
+# TYPE lift_event_bus_events_published_total counter
+lift_event_bus_events_published_total 10
+# HELP lift_event_bus_queue_depth Events queued for the bus workers.
+# TYPE lift_event_bus_queue_depth gauge
+lift_event_bus_queue_depth 3
+# HELP lift_websocket_clients Connected WebSocket clients.
+# TYPE lift_websocket_clients gauge
+lift_websocket_clients 2
+# HELP lift_websocket_clients_evicted_total Clients disconnected for falling behind.
+# TYPE lift_websocket_clients_evicted_total counter
+lift_websocket_clients_evicted_total 1
+# HELP lift_websocket_frames_sent_total Frames written to clients.
+# TYPE lift_websocket_frames_sent_total counter
+lift_websocket_frames_sent_total 40
+# HELP lift_rate_limit_decisions_total Rate limit decisions, by limiter and outcome. Errors are requests let through because the store could not be reached.
+# TYPE lift_rate_limit_decisions_total counter
+lift_rate_limit_decisions_total{decision="allowed",limiter="client"} 7
+lift_rate_limit_decisions_total{decision="error",limiter="client"} 1
+lift_rate_limit_decisions_total{decision="limited",limiter="client"} 2
+# HELP lift_lifts Lifts in the system, by status.
+# TYPE lift_lifts gauge
+lift_lifts{status="Available"} 2
+lift_lifts{status="Occupied"} 1
+lift_lifts{status="OutOfService"} 0
+# HELP lift_hall_calls_active Hall calls waiting for a lift, by status.
+# TYPE lift_hall_calls_active gauge
+lift_hall_calls_active{status="assigned"} 1
+lift_hall_calls_active{status="pending"} 4
+# HELP lift_hall_calls_closed_total Hall calls that reached a final state, by that state.
+# TYPE lift_hall_calls_closed_total counter
+lift_hall_calls_closed_total{status="cancelled"} 1
+lift_hall_calls_closed_total{status="served"} 1
+# HELP lift_trips_total Completed lift trips, by what caused them.
+# TYPE lift_trips_total counter
+lift_trips_total{trigger="car_call"} 1
+lift_trips_total{trigger="hall_call"} 1
+# HELP lift_floors_travelled_total Floors travelled by all lifts.
+# TYPE lift_floors_travelled_total counter
+lift_floors_travelled_total 8
+`
+	err := testutil.ScrapeAndCompare(srv.URL, strings.NewReader(expected),
+		"lift_event_bus_events_dropped_total",
+		"lift_event_bus_events_published_total",
+		"lift_event_bus_queue_depth",
+		"lift_websocket_clients",
+		"lift_websocket_clients_evicted_total",
+		"lift_websocket_frames_sent_total",
+		"lift_rate_limit_decisions_total",
+		"lift_lifts",
+		"lift_hall_calls_active",
+		"lift_hall_calls_closed_total",
+		"lift_trips_total",
+		"lift_floors_travelled_total",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+
+	families := scrape(t, srv.URL)
+
+	assertHistogram(t, families, "lift_http_request_duration_seconds",
+		map[string]string{"method": "GET", "route": "/api/v1/lifts/:id", "status": "200"}, 2, 0.04)
+	assertHistogram(t, families, "lift_http_request_duration_seconds",
+		map[string]string{"method": "POST", "route": "/api/v1/floors/:floorNum/call", "status": "429"}, 1, 0.001)
+	assertHistogram(t, families, "lift_event_bus_handler_duration_seconds",
+		map[string]string{"event_type": "LiftArrived"}, 1, 0.002)
+	// Only the served call waited for a lift.
+	assertHistogram(t, families, "lift_hall_calls_wait_seconds", nil, 1, 12)
+
+	for _, name := range []string{"go_goroutines", "process_start_time_seconds"} {
+		if _, ok := families[name]; !ok {
+			t.Errorf("%s missing from the scrape", name)
+		}
+	}
+}
+
+func TestHandlerWithoutSystem(t *testing.T) {
+	m := newMetrics()
+	m.RegisterState(fakeState{err: domain.ErrSystemNotConfigured})
+
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+
+	families := scrape(t, srv.URL)
+	for _, name := range []string{"lift_lifts", "lift_hall_calls_active"} {
+		if _, ok := families[name]; ok {
+			t.Errorf("%s reported for a system that is not configured", name)
+		}
+	}
+}
+
+// scrape reads every metric family served at url
+func scrape(t *testing.T, url string) map[string]*dto.MetricFamily {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("scraping: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("scrape answered %s", resp.Status)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		t.Fatalf("parsing scrape: %v", err)
+	}
+	return families
+}
+
+// assertHistogram checks the sample count and sum of the series of the
+// histogram name carrying exactly the given labels
+func assertHistogram(t *testing.T, families map[string]*dto.MetricFamily, name string, labels map[string]string, count uint64, sum float64) {
+	t.Helper()
+
+	family, ok := families[name]
+	if !ok {
+		t.Errorf("%s missing from the scrape", name)
+		return
+	}
+	for _, metric := range family.GetMetric() {
+		if !hasLabels(metric, labels) {
+			continue
+		}
+		h := metric.GetHistogram()
+		if h.GetSampleCount() != count || math.Abs(h.GetSampleSum()-sum) > 1e-9 {
+			t.Errorf("%s%v: got %d samples summing to %g, want %d summing to %g",
+				name, labels, h.GetSampleCount(), h.GetSampleSum(), count, sum)
+		}
+		return
+	}
+	t.Errorf("%s%v missing from the scrape", name, labels)
+}
+
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	if len(metric.GetLabel()) != len(labels) {
+		return false
+	}
+	for _, label := range metric.GetLabel() {
+		if labels[label.GetName()] != label.GetValue() {
+			return false
+		}
+	}
+	return true
+}
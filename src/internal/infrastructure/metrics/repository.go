@@ -0,0 +1,322 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+)
+
+// Repository times every operation of the repository it wraps
+type Repository struct {
+	next ports.Repository
+	m    *Metrics
+}
+
+var _ ports.TransactionalRepository = (*Repository)(nil)
+
+// InstrumentRepository wraps repo so that its operations are timed
+func (m *Metrics) InstrumentRepository(repo ports.Repository) *Repository {
+	return &Repository{next: repo, m: m}
+}
+
+// observe records an operation that started at start. Lookups of missing
+// records and of a system that is not configured are expected answers, not
+// failures.
+func (r *Repository) observe(operation string, start time.Time, err *error) {
+	r.m.queryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if *err != nil && !errors.Is(*err, domain.ErrNotFound) && !errors.Is(*err, domain.ErrSystemNotConfigured) {
+		r.m.queryErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (r *Repository) AppendAudit(ctx context.Context, entry *domain.AuditEntry) (_ int64, err error) {
+	defer r.observe("AppendAudit", time.Now(), &err)
+	return r.next.AppendAudit(ctx, entry)
+}
+
+func (r *Repository) AppendEvent(ctx context.Context, env domain.Envelope) (_ int64, err error) {
+	defer r.observe("AppendEvent", time.Now(), &err)
+	return r.next.AppendEvent(ctx, env)
+}
+
+func (r *Repository) AppendWebhookAttempt(ctx context.Context, attempt *domain.WebhookAttempt) (err error) {
+	defer r.observe("AppendWebhookAttempt", time.Now(), &err)
+	return r.next.AppendWebhookAttempt(ctx, attempt)
+}
+
+func (r *Repository) AssignLiftToFloor(ctx context.Context, liftID string, floorID string, floorNumber int) (err error) {
+	defer r.observe("AssignLiftToFloor", time.Now(), &err)
+	return r.next.AssignLiftToFloor(ctx, liftID, floorID, floorNumber)
+}
+
+func (r *Repository) AssignLiftToFloorWithLimit(ctx context.Context, liftID string, floorID string, floorNumber int, maxLifts int) (err error) {
+	defer r.observe("AssignLiftToFloorWithLimit", time.Now(), &err)
+	return r.next.AssignLiftToFloorWithLimit(ctx, liftID, floorID, floorNumber, maxLifts)
+}
+
+// BeginTx starts a transaction of the wrapped repository, if it supports them
+func (r *Repository) BeginTx(ctx context.Context) (_ ports.Transaction, err error) {
+	defer r.observe("BeginTx", time.Now(), &err)
+	next, ok := r.next.(ports.TransactionalRepository)
+	if !ok {
+		return nil, errors.New("repository does not support transactions")
+	}
+	return next.BeginTx(ctx)
+}
+
+func (r *Repository) CompleteIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) (err error) {
+	defer r.observe("CompleteIdempotencyRecord", time.Now(), &err)
+	return r.next.CompleteIdempotencyRecord(ctx, rec)
+}
+
+func (r *Repository) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (_ int64, err error) {
+	defer r.observe("DeleteExpiredIdempotencyRecords", time.Now(), &err)
+	return r.next.DeleteExpiredIdempotencyRecords(ctx, now)
+}
+
+func (r *Repository) DeleteLift(ctx context.Context, id string) (err error) {
+	defer r.observe("DeleteLift", time.Now(), &err)
+	return r.next.DeleteLift(ctx, id)
+}
+
+func (r *Repository) DeleteWebhook(ctx context.Context, id string) (err error) {
+	defer r.observe("DeleteWebhook", time.Now(), &err)
+	return r.next.DeleteWebhook(ctx, id)
+}
+
+func (r *Repository) GetAPIKey(ctx context.Context, id string) (_ *domain.APIKey, err error) {
+	defer r.observe("GetAPIKey", time.Now(), &err)
+	return r.next.GetAPIKey(ctx, id)
+}
+
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, hash string) (_ *domain.APIKey, err error) {
+	defer r.observe("GetAPIKeyByHash", time.Now(), &err)
+	return r.next.GetAPIKeyByHash(ctx, hash)
+}
+
+func (r *Repository) GetAllFloors(ctx context.Context) (_ []*domain.Floor, err error) {
+	defer r.observe("GetAllFloors", time.Now(), &err)
+	return r.next.GetAllFloors(ctx)
+}
+
+func (r *Repository) GetAllLifts(ctx context.Context) (_ []*domain.Lift, err error) {
+	defer r.observe("GetAllLifts", time.Now(), &err)
+	return r.next.GetAllLifts(ctx)
+}
+
+func (r *Repository) GetAssignedLiftsForFloor(ctx context.Context, floorID string) (_ []*domain.Lift, err error) {
+	defer r.observe("GetAssignedLiftsForFloor", time.Now(), &err)
+	return r.next.GetAssignedLiftsForFloor(ctx, floorID)
+}
+
+func (r *Repository) GetFloor(ctx context.Context, id string) (_ *domain.Floor, err error) {
+	defer r.observe("GetFloor", time.Now(), &err)
+	return r.next.GetFloor(ctx, id)
+}
+
+func (r *Repository) GetFloorByNumber(ctx context.Context, floorNum int) (_ *domain.Floor, err error) {
+	defer r.observe("GetFloorByNumber", time.Now(), &err)
+	return r.next.GetFloorByNumber(ctx, floorNum)
+}
+
+func (r *Repository) GetHallCall(ctx context.Context, id string) (_ *domain.HallCall, err error) {
+	defer r.observe("GetHallCall", time.Now(), &err)
+	return r.next.GetHallCall(ctx, id)
+}
+
+func (r *Repository) GetIdempotencyRecord(ctx context.Context, principal string, key string) (_ *domain.IdempotencyRecord, err error) {
+	defer r.observe("GetIdempotencyRecord", time.Now(), &err)
+	return r.next.GetIdempotencyRecord(ctx, principal, key)
+}
+
+func (r *Repository) GetLift(ctx context.Context, id string) (_ *domain.Lift, err error) {
+	defer r.observe("GetLift", time.Now(), &err)
+	return r.next.GetLift(ctx, id)
+}
+
+func (r *Repository) GetSystem(ctx context.Context) (_ *domain.System, err error) {
+	defer r.observe("GetSystem", time.Now(), &err)
+	return r.next.GetSystem(ctx)
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, id string) (_ *domain.Webhook, err error) {
+	defer r.observe("GetWebhook", time.Now(), &err)
+	return r.next.GetWebhook(ctx, id)
+}
+
+func (r *Repository) GetWebhookDelivery(ctx context.Context, id string) (_ *domain.WebhookDelivery, err error) {
+	defer r.observe("GetWebhookDelivery", time.Now(), &err)
+	return r.next.GetWebhookDelivery(ctx, id)
+}
+
+func (r *Repository) LatestEventSequence(ctx context.Context) (_ int64, err error) {
+	defer r.observe("LatestEventSequence", time.Now(), &err)
+	return r.next.LatestEventSequence(ctx)
+}
+
+func (r *Repository) ListAPIKeys(ctx context.Context) (_ []*domain.APIKey, err error) {
+	defer r.observe("ListAPIKeys", time.Now(), &err)
+	return r.next.ListAPIKeys(ctx)
+}
+
+func (r *Repository) ListAudit(ctx context.Context, filter domain.AuditFilter) (_ []*domain.AuditEntry, _ int, err error) {
+	defer r.observe("ListAudit", time.Now(), &err)
+	return r.next.ListAudit(ctx, filter)
+}
+
+func (r *Repository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) (_ []*domain.WebhookDelivery, err error) {
+	defer r.observe("ListDueWebhookDeliveries", time.Now(), &err)
+	return r.next.ListDueWebhookDeliveries(ctx, now, limit)
+}
+
+func (r *Repository) ListEvents(ctx context.Context, since int64, limit int) (_ []*domain.StoredEvent, err error) {
+	defer r.observe("ListEvents", time.Now(), &err)
+	return r.next.ListEvents(ctx, since, limit)
+}
+
+func (r *Repository) ListFloors(ctx context.Context) (_ []*domain.Floor, err error) {
+	defer r.observe("ListFloors", time.Now(), &err)
+	return r.next.ListFloors(ctx)
+}
+
+func (r *Repository) ListHallCalls(ctx context.Context, filter domain.HallCallFilter) (_ []*domain.HallCall, _ int, err error) {
+	defer r.observe("ListHallCalls", time.Now(), &err)
+	return r.next.ListHallCalls(ctx, filter)
+}
+
+func (r *Repository) ListLifts(ctx context.Context) (_ []*domain.Lift, err error) {
+	defer r.observe("ListLifts", time.Now(), &err)
+	return r.next.ListLifts(ctx)
+}
+
+func (r *Repository) ListTrips(ctx context.Context, filter domain.TripFilter) (_ []*domain.Trip, _ int, err error) {
+	defer r.observe("ListTrips", time.Now(), &err)
+	return r.next.ListTrips(ctx, filter)
+}
+
+func (r *Repository) ListWebhookAttempts(ctx context.Context, deliveryID string) (_ []*domain.WebhookAttempt, err error) {
+	defer r.observe("ListWebhookAttempts", time.Now(), &err)
+	return r.next.ListWebhookAttempts(ctx, deliveryID)
+}
+
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) (_ []*domain.WebhookDelivery, err error) {
+	defer r.observe("ListWebhookDeliveries", time.Now(), &err)
+	return r.next.ListWebhookDeliveries(ctx, filter)
+}
+
+func (r *Repository) ListWebhooks(ctx context.Context) (_ []*domain.Webhook, err error) {
+	defer r.observe("ListWebhooks", time.Now(), &err)
+	return r.next.ListWebhooks(ctx)
+}
+
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, principal string, key string) (err error) {
+	defer r.observe("ReleaseIdempotencyKey", time.Now(), &err)
+	return r.next.ReleaseIdempotencyKey(ctx, principal, key)
+}
+
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, rec *domain.IdempotencyRecord, abandonedBefore time.Time) (_ bool, err error) {
+	defer r.observe("ReserveIdempotencyKey", time.Now(), &err)
+	return r.next.ReserveIdempotencyKey(ctx, rec, abandonedBefore)
+}
+
+func (r *Repository) ResetSystem(ctx context.Context, systemID string) (err error) {
+	defer r.observe("ResetSystem", time.Now(), &err)
+	return r.next.ResetSystem(ctx, systemID)
+}
+
+func (r *Repository) SaveAPIKey(ctx context.Context, key *domain.APIKey) (err error) {
+	defer r.observe("SaveAPIKey", time.Now(), &err)
+	return r.next.SaveAPIKey(ctx, key)
+}
+
+func (r *Repository) SaveFloor(ctx context.Context, floor *domain.Floor, systemID string) (err error) {
+	defer r.observe("SaveFloor", time.Now(), &err)
+	return r.next.SaveFloor(ctx, floor, systemID)
+}
+
+func (r *Repository) SaveHallCall(ctx context.Context, call *domain.HallCall) (err error) {
+	defer r.observe("SaveHallCall", time.Now(), &err)
+	return r.next.SaveHallCall(ctx, call)
+}
+
+func (r *Repository) SaveLift(ctx context.Context, lift *domain.Lift, systemID string) (err error) {
+	defer r.observe("SaveLift", time.Now(), &err)
+	return r.next.SaveLift(ctx, lift, systemID)
+}
+
+func (r *Repository) SaveSystem(ctx context.Context, system *domain.System) (err error) {
+	defer r.observe("SaveSystem", time.Now(), &err)
+	return r.next.SaveSystem(ctx, system)
+}
+
+func (r *Repository) SaveTrip(ctx context.Context, trip *domain.Trip) (err error) {
+	defer r.observe("SaveTrip", time.Now(), &err)
+	return r.next.SaveTrip(ctx, trip)
+}
+
+func (r *Repository) SaveWebhook(ctx context.Context, hook *domain.Webhook) (err error) {
+	defer r.observe("SaveWebhook", time.Now(), &err)
+	return r.next.SaveWebhook(ctx, hook)
+}
+
+func (r *Repository) SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (err error) {
+	defer r.observe("SaveWebhookDelivery", time.Now(), &err)
+	return r.next.SaveWebhookDelivery(ctx, delivery)
+}
+
+func (r *Repository) UnassignBulk(ctx context.Context) (err error) {
+	defer r.observe("UnassignBulk", time.Now(), &err)
+	return r.next.UnassignBulk(ctx)
+}
+
+func (r *Repository) UnassignLiftFromFloor(ctx context.Context, liftID string, floorID string) (err error) {
+	defer r.observe("UnassignLiftFromFloor", time.Now(), &err)
+	return r.next.UnassignLiftFromFloor(ctx, liftID, floorID)
+}
+
+func (r *Repository) UpdateAPIKey(ctx context.Context, key *domain.APIKey) (err error) {
+	defer r.observe("UpdateAPIKey", time.Now(), &err)
+	return r.next.UpdateAPIKey(ctx, key)
+}
+
+func (r *Repository) UpdateFloor(ctx context.Context, floor *domain.Floor) (err error) {
+	defer r.observe("UpdateFloor", time.Now(), &err)
+	return r.next.UpdateFloor(ctx, floor)
+}
+
+func (r *Repository) UpdateHallCall(ctx context.Context, call *domain.HallCall) (err error) {
+	defer r.observe("UpdateHallCall", time.Now(), &err)
+	return r.next.UpdateHallCall(ctx, call)
+}
+
+func (r *Repository) UpdateLift(ctx context.Context, lift *domain.Lift) (err error) {
+	defer r.observe("UpdateLift", time.Now(), &err)
+	return r.next.UpdateLift(ctx, lift)
+}
+
+func (r *Repository) UpdateSystem(ctx context.Context, system *domain.System) (err error) {
+	defer r.observe("UpdateSystem", time.Now(), &err)
+	return r.next.UpdateSystem(ctx, system)
+}
+
+func (r *Repository) UpdateWebhook(ctx context.Context, hook *domain.Webhook) (err error) {
+	defer r.observe("UpdateWebhook", time.Now(), &err)
+	return r.next.UpdateWebhook(ctx, hook)
+}
+
+func (r *Repository) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) (err error) {
+	defer r.observe("UpdateWebhookDelivery", time.Now(), &err)
+	return r.next.UpdateWebhookDelivery(ctx, delivery)
+}
+
+// WithTx returns the wrapped repository bound to tx, still timed
+func (r *Repository) WithTx(tx ports.Transaction) ports.Repository {
+	next, ok := r.next.(ports.TransactionalRepository)
+	if !ok {
+		return r
+	}
+	return &Repository{next: next.WithTx(tx), m: r.m}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// stateTimeout bounds the repository reads made for one scrape
+const stateTimeout = 5 * time.Second
+
+// StateReader reads the state of the lifts and hall calls
+type StateReader interface {
+	ListLifts(ctx context.Context) ([]*domain.Lift, error)
+	ListHallCalls(ctx context.Context, filter domain.HallCallFilter) ([]*domain.HallCall, int, error)
+}
+
+// stateCollector reads the lifts and open hall calls when the metrics are
+// scraped, so the gauges always match the repository
+type stateCollector struct {
+	state StateReader
+	m     *Metrics
+
+	lifts       *prometheus.Desc
+	activeCalls *prometheus.Desc
+}
+
+// RegisterState exposes the number of lifts in each status and of the hall
+// calls still waiting for a lift
+func (m *Metrics) RegisterState(state StateReader) {
+	m.registry.MustRegister(&stateCollector{
+		state: state,
+		m:     m,
+		lifts: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "lifts"),
+			"Lifts in the system, by status.",
+			[]string{"status"}, nil),
+		activeCalls: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "hall_calls", "active"),
+			"Hall calls waiting for a lift, by status.",
+			[]string{"status"}, nil),
+	})
+}
+
+func (c *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lifts
+	ch <- c.activeCalls
+}
+
+func (c *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), stateTimeout)
+	defer cancel()
+
+	if lifts, err := c.state.ListLifts(ctx); c.ok(ctx, err, "lifts") {
+		counts := map[domain.LiftStatus]int{
+			domain.Available:    0,
+			domain.Occupied:     0,
+			domain.OutOfService: 0,
+		}
+		for _, lift := range lifts {
+			counts[lift.Status]++
+		}
+		for status, n := range counts {
+			ch <- prometheus.MustNewConstMetric(c.lifts, prometheus.GaugeValue, float64(n), domain.LiftStatusToString(status))
+		}
+	}
+
+	for _, status := range []domain.HallCallStatus{domain.CallPending, domain.CallAssigned} {
+		_, total, err := c.state.ListHallCalls(ctx, domain.HallCallFilter{Status: status, Limit: 1})
+		if c.ok(ctx, err, "hall calls") {
+			ch <- prometheus.MustNewConstMetric(c.activeCalls, prometheus.GaugeValue, float64(total), string(status))
+		}
+	}
+}
+
+// ok reports whether a read succeeded. A system that is not configured yet
+// has nothing to report; other failures are logged and the gauges left out
+// of the scrape.
+func (c *stateCollector) ok(ctx context.Context, err error, what string) bool {
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, domain.ErrSystemNotConfigured) {
+		c.m.log.Warn(ctx, "Failed to read state for metrics", "state", what, "error", err)
+	}
+	return false
+}
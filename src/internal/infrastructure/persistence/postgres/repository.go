@@ -0,0 +1,1787 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/ports"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	_ "github.com/lib/pq"
+)
+
+// Config holds the connection settings for the PostgreSQL repository
+type Config struct {
+	URL          string
+	MaxOpenConns int
+	MaxIdleConns int
+	MaxLifetime  time.Duration
+}
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx so that every query can run
+// either directly against the pool or inside a transaction.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Repository implements the Repository interface using PostgreSQL
+type Repository struct {
+	db  *sql.DB
+	q   dbtx
+	log *logger.Logger
+}
+
+// NewRepository creates a new instance of the PostgreSQL repository
+func NewRepository(cfg Config, log *logger.Logger) (*Repository, error) {
+	db, err := sql.Open("postgres", cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.MaxLifetime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	if err := createTables(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return &Repository{db: db, q: db, log: log}, nil
+}
+
+// createTables creates the necessary tables if they don't exist
+func createTables(ctx context.Context, db *sql.DB) error {
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS system (
+			id TEXT PRIMARY KEY,
+			total_floors INTEGER NOT NULL,
+			total_lifts INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS floors (
+			id TEXT PRIMARY KEY,
+			floor_number INTEGER UNIQUE NOT NULL,
+			up_button_active BOOLEAN NOT NULL DEFAULT FALSE,
+			down_button_active BOOLEAN NOT NULL DEFAULT FALSE,
+			system_id TEXT REFERENCES system(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS lifts (
+			id TEXT PRIMARY KEY,
+			name TEXT UNIQUE NOT NULL,
+			current_floor INTEGER NOT NULL DEFAULT 0,
+			status TEXT NOT NULL,
+			capacity INTEGER NOT NULL,
+			system_id TEXT REFERENCES system(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS floor_lift_assignments (
+			floor_id TEXT REFERENCES floors(id) ON DELETE CASCADE,
+			lift_id TEXT REFERENCES lifts(id) ON DELETE CASCADE,
+			floor_number INTEGER NOT NULL,
+			PRIMARY KEY (floor_id, lift_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS events (
+			sequence BIGSERIAL PRIMARY KEY,
+			event_id TEXT NOT NULL,
+			system_id TEXT,
+			event_type TEXT NOT NULL,
+			occurred_at TIMESTAMPTZ NOT NULL,
+			causation_id TEXT,
+			correlation_id TEXT,
+			trace_id TEXT,
+			payload JSONB NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS trips (
+			id TEXT PRIMARY KEY,
+			lift_id TEXT NOT NULL,
+			origin_floor INTEGER NOT NULL,
+			destination_floor INTEGER NOT NULL,
+			started_at TIMESTAMPTZ NOT NULL,
+			ended_at TIMESTAMPTZ NOT NULL,
+			trigger_type TEXT NOT NULL,
+			passengers INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE INDEX IF NOT EXISTS trips_lift_started_idx ON trips (lift_id, started_at)`,
+		`CREATE TABLE IF NOT EXISTS hall_calls (
+			id TEXT PRIMARY KEY,
+			floor_number INTEGER NOT NULL,
+			direction INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			lift_id TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			eta TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL,
+			assigned_at TIMESTAMPTZ,
+			escalated_at TIMESTAMPTZ,
+			closed_at TIMESTAMPTZ,
+			updated_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS hall_calls_created_idx ON hall_calls (created_at)`,
+		`CREATE INDEX IF NOT EXISTS hall_calls_status_idx ON hall_calls (status, created_at)`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			event_types TEXT NOT NULL DEFAULT '',
+			secret TEXT NOT NULL,
+			active BOOLEAN NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			event_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload JSONB NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (status, next_attempt_at)`,
+		`CREATE TABLE IF NOT EXISTS webhook_attempts (
+			id BIGSERIAL PRIMARY KEY,
+			delivery_id TEXT NOT NULL,
+			attempt INTEGER NOT NULL,
+			status_code INTEGER,
+			error TEXT,
+			duration_ms BIGINT NOT NULL,
+			attempted_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS webhook_attempts_delivery_idx ON webhook_attempts (delivery_id)`,
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			hash TEXT NOT NULL UNIQUE,
+			scopes TEXT NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ,
+			last_used_at TIMESTAMPTZ,
+			revoked_at TIMESTAMPTZ
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			sequence BIGSERIAL PRIMARY KEY,
+			principal TEXT NOT NULL,
+			source_ip TEXT NOT NULL,
+			trace_id TEXT NOT NULL,
+			method TEXT NOT NULL,
+			route TEXT NOT NULL,
+			path TEXT NOT NULL,
+			subject TEXT NOT NULL DEFAULT '',
+			payload TEXT NOT NULL DEFAULT '',
+			changes TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			outcome TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_at_idx ON audit_log (at)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_principal_idx ON audit_log (principal, at)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_subject_idx ON audit_log (subject, at)`,
+		// The audit log is append-only
+		`CREATE OR REPLACE RULE audit_log_no_update AS ON UPDATE TO audit_log DO INSTEAD NOTHING`,
+		`CREATE OR REPLACE RULE audit_log_no_delete AS ON DELETE TO audit_log DO INSTEAD NOTHING`,
+		`CREATE TABLE IF NOT EXISTS idempotency_keys (
+			principal TEXT NOT NULL,
+			idempotency_key TEXT NOT NULL,
+			method TEXT NOT NULL,
+			path TEXT NOT NULL,
+			request_hash TEXT NOT NULL,
+			status INTEGER NOT NULL DEFAULT 0,
+			content_type TEXT NOT NULL DEFAULT '',
+			location TEXT NOT NULL DEFAULT '',
+			body BYTEA,
+			created_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (principal, idempotency_key)
+		)`,
+		// Tables created before the times carried a zone hold UTC wall-clock
+		// times; convert them once so comparisons do not depend on the
+		// session time zone.
+		`DO $$
+		BEGIN
+			IF EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema()
+				AND table_name = 'idempotency_keys'
+				AND column_name = 'created_at'
+				AND data_type = 'timestamp without time zone'
+			) THEN
+				ALTER TABLE idempotency_keys
+					ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+					ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC';
+			END IF;
+		END $$`,
+		`CREATE INDEX IF NOT EXISTS idempotency_keys_expires_idx ON idempotency_keys (expires_at)`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Transaction Methods
+
+// BeginTx starts a new database transaction
+func (r *Repository) BeginTx(ctx context.Context) (ports.Transaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// WithTx returns a repository whose queries run inside the given transaction
+func (r *Repository) WithTx(tx ports.Transaction) ports.Repository {
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return r
+	}
+	return &Repository{db: r.db, q: sqlTx, log: r.log}
+}
+
+// inTx runs fn inside a transaction. When the repository is already bound to
+// a transaction fn joins it and the caller stays in charge of committing.
+func (r *Repository) inTx(ctx context.Context, fn func(q dbtx) error) error {
+	if _, ok := r.q.(*sql.Tx); ok {
+		return fn(r.q)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Lift Repository Methods
+
+func (r *Repository) GetLift(ctx context.Context, id string) (*domain.Lift, error) {
+	r.log.Info(ctx, "Getting lift", "lift_id", id)
+
+	query := `SELECT id, name, current_floor, status, capacity FROM lifts WHERE id = $1`
+	var liftID, name, statusStr string
+	var currentFloor, capacity int
+
+	err := r.q.QueryRowContext(ctx, query, id).Scan(&liftID, &name, &currentFloor, &statusStr, &capacity)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.log.Error(ctx, "Lift not found", "lift_id", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrLiftNotFound, id)
+	}
+	if err != nil {
+		r.log.Error(ctx, "Failed to get lift", "lift_id", id, "error", err)
+		return nil, fmt.Errorf("failed to get lift: %w", err)
+	}
+
+	lift := domain.NewLift(liftID, name)
+	lift.SetCurrentFloor(currentFloor)
+	lift.SetStatus(domain.StringToLiftStatus(statusStr))
+	lift.SetCapacity(capacity)
+
+	return lift, nil
+}
+
+func (r *Repository) ListLifts(ctx context.Context) ([]*domain.Lift, error) {
+	r.log.Info(ctx, "Listing all lifts")
+
+	query := `SELECT id, name, current_floor, status, capacity FROM lifts ORDER BY name`
+	rows, err := r.q.QueryContext(ctx, query)
+	if err != nil {
+		r.log.Error(ctx, "Failed to query lifts", "error", err)
+		return nil, fmt.Errorf("failed to list lifts: %w", err)
+	}
+	defer rows.Close()
+
+	lifts, err := scanLifts(rows)
+	if err != nil {
+		r.log.Error(ctx, "Failed to scan lifts", "error", err)
+		return nil, err
+	}
+
+	r.log.Info(ctx, "Successfully listed all lifts", "count", len(lifts))
+	return lifts, nil
+}
+
+func (r *Repository) SaveLift(ctx context.Context, lift *domain.Lift, systemID string) error {
+	query := `
+		INSERT INTO lifts (id, name, current_floor, status, capacity, system_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			current_floor = EXCLUDED.current_floor,
+			status = EXCLUDED.status,
+			capacity = EXCLUDED.capacity,
+			system_id = EXCLUDED.system_id
+	`
+
+	statusStr := domain.LiftStatusToString(lift.Status)
+
+	r.log.Debug(ctx, "Executing SQL",
+		"lift_id", lift.ID,
+		"name", lift.Name,
+		"current_floor", lift.CurrentFloor,
+		"status", statusStr,
+		"capacity", lift.Capacity)
+
+	_, err := r.q.ExecContext(ctx, query,
+		lift.ID,
+		lift.Name,
+		lift.CurrentFloor,
+		statusStr,
+		lift.Capacity,
+		systemID)
+	if err != nil {
+		r.log.Error(ctx, "Failed to save lift", "error", err)
+		return fmt.Errorf("failed to save lift: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateLift(ctx context.Context, lift *domain.Lift) error {
+	r.log.Info(ctx, "Updating lift", "lift_id", lift.ID)
+
+	query := `
+		UPDATE lifts
+		SET name = $1, current_floor = $2, status = $3, capacity = $4
+		WHERE id = $5
+	`
+
+	statusStr := domain.LiftStatusToString(lift.Status)
+
+	result, err := r.q.ExecContext(ctx, query,
+		lift.Name,
+		lift.CurrentFloor,
+		statusStr,
+		lift.Capacity,
+		lift.ID)
+	if err != nil {
+		r.log.Error(ctx, "Failed to update lift", "error", err)
+		return fmt.Errorf("failed to update lift: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		r.log.Warn(ctx, "No lift updated", "lift_id", lift.ID)
+		return fmt.Errorf("%w: %s", domain.ErrLiftNotFound, lift.ID)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteLift(ctx context.Context, id string) error {
+	query := `DELETE FROM lifts WHERE id = $1`
+	if _, err := r.q.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("failed to delete lift: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetAllLifts(ctx context.Context) ([]*domain.Lift, error) {
+	return r.ListLifts(ctx)
+}
+
+// Floor Repository Methods
+
+func (r *Repository) GetFloor(ctx context.Context, id string) (*domain.Floor, error) {
+	query := `SELECT id, floor_number, up_button_active, down_button_active FROM floors WHERE id = $1`
+
+	floor, err := scanFloor(r.q.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrFloorNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get floor: %w", err)
+	}
+	return floor, nil
+}
+
+func (r *Repository) GetFloorByNumber(ctx context.Context, floorNum int) (*domain.Floor, error) {
+	query := `SELECT id, floor_number, up_button_active, down_button_active FROM floors WHERE floor_number = $1`
+
+	floor, err := scanFloor(r.q.QueryRowContext(ctx, query, floorNum))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %d", domain.ErrFloorNotFound, floorNum)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get floor: %w", err)
+	}
+	return floor, nil
+}
+
+func (r *Repository) ListFloors(ctx context.Context) ([]*domain.Floor, error) {
+	query := `SELECT id, floor_number, up_button_active, down_button_active FROM floors ORDER BY floor_number`
+	rows, err := r.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list floors: %w", err)
+	}
+	defer rows.Close()
+
+	var floors []*domain.Floor
+	for rows.Next() {
+		floor, err := scanFloor(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan floor: %w", err)
+		}
+		floors = append(floors, floor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning floors: %w", err)
+	}
+
+	return floors, nil
+}
+
+func (r *Repository) SaveFloor(ctx context.Context, floor *domain.Floor, systemID string) error {
+	query := `
+		INSERT INTO floors (id, floor_number, up_button_active, down_button_active, system_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (id) DO UPDATE SET
+			floor_number = EXCLUDED.floor_number,
+			up_button_active = EXCLUDED.up_button_active,
+			down_button_active = EXCLUDED.down_button_active,
+			system_id = EXCLUDED.system_id
+	`
+
+	_, err := r.q.ExecContext(ctx, query,
+		floor.ID,
+		floor.Number,
+		floor.GetUpButtonActive(),
+		floor.GetDownButtonActive(),
+		systemID)
+	if err != nil {
+		r.log.Error(ctx, "Failed to save floor", "error", err)
+		return fmt.Errorf("failed to save floor: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) UpdateFloor(ctx context.Context, floor *domain.Floor) error {
+	query := `UPDATE floors SET floor_number = $1, up_button_active = $2, down_button_active = $3 WHERE id = $4`
+
+	result, err := r.q.ExecContext(ctx, query,
+		floor.Number,
+		floor.GetUpButtonActive(),
+		floor.GetDownButtonActive(),
+		floor.ID)
+	if err != nil {
+		r.log.Error(ctx, "Failed to update floor", "error", err)
+		return fmt.Errorf("failed to update floor: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrFloorNotFound, floor.ID)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetAllFloors(ctx context.Context) ([]*domain.Floor, error) {
+	return r.ListFloors(ctx)
+}
+
+// System Repository Methods
+
+func (r *Repository) GetSystem(ctx context.Context) (*domain.System, error) {
+	query := `SELECT id, total_floors, total_lifts FROM system LIMIT 1`
+	var systemID string
+	var totalFloors, totalLifts int
+
+	err := r.q.QueryRowContext(ctx, query).Scan(&systemID, &totalFloors, &totalLifts)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.log.Error(ctx, "System configuration not found")
+		return nil, domain.ErrSystemNotConfigured
+	}
+	if err != nil {
+		r.log.Error(ctx, "Failed to get system configuration", "error", err)
+		return nil, fmt.Errorf("failed to get system configuration: %w", err)
+	}
+
+	system, err := domain.NewSystem(systemID, totalFloors, totalLifts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new system from configuration: %w", err)
+	}
+	return system, nil
+}
+
+func (r *Repository) SaveSystem(ctx context.Context, system *domain.System) error {
+	query := `
+		INSERT INTO system (id, total_floors, total_lifts)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET
+			total_floors = EXCLUDED.total_floors,
+			total_lifts = EXCLUDED.total_lifts
+	`
+	if _, err := r.q.ExecContext(ctx, query, system.ID, system.TotalFloors, system.TotalLifts); err != nil {
+		return fmt.Errorf("failed to save system configuration: %w", err)
+	}
+	return nil
+}
+
+// UpdateSystem changes the floor and lift counts of an existing system
+func (r *Repository) UpdateSystem(ctx context.Context, system *domain.System) error {
+	query := `UPDATE system SET total_floors = $1, total_lifts = $2 WHERE id = $3`
+	result, err := r.q.ExecContext(ctx, query, system.TotalFloors, system.TotalLifts, system.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update system configuration: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no system with ID %s", domain.ErrSystemNotConfigured, system.ID)
+	}
+	return nil
+}
+
+func (r *Repository) ResetSystem(ctx context.Context, systemID string) error {
+	query := `DELETE FROM system WHERE id = $1`
+	result, err := r.q.ExecContext(ctx, query, systemID)
+	if err != nil {
+		return fmt.Errorf("failed to delete system record: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no system with ID %s", domain.ErrSystemNotConfigured, systemID)
+	}
+
+	return nil
+}
+
+// Lift Floor Assignment Methods
+
+func (r *Repository) AssignLiftToFloor(ctx context.Context, liftID, floorID string, floorNumber int) error {
+	query := `INSERT INTO floor_lift_assignments (floor_id, lift_id, floor_number) VALUES ($1, $2, $3)`
+
+	result, err := r.q.ExecContext(ctx, query, floorID, liftID, floorNumber)
+	if err != nil {
+		return fmt.Errorf("failed to assign lift to floor: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("failed to assign lift to floor no update happened")
+	}
+
+	return nil
+}
+
+func (r *Repository) AssignLiftToFloorWithLimit(ctx context.Context, liftID, floorID string, floorNumber int, maxLifts int) error {
+	return r.inTx(ctx, func(q dbtx) error {
+		// Lock the floor row so concurrent writers on other replicas queue up
+		// behind this count-and-insert.
+		var id string
+		err := q.QueryRowContext(ctx, `SELECT id FROM floors WHERE id = $1 FOR UPDATE`, floorID).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", domain.ErrFloorNotFound, floorID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock floor: %w", err)
+		}
+
+		var assigned int
+		err = q.QueryRowContext(ctx, `SELECT COUNT(*) FROM floor_lift_assignments WHERE floor_id = $1`, floorID).Scan(&assigned)
+		if err != nil {
+			return fmt.Errorf("failed to count assigned lifts: %w", err)
+		}
+		if assigned >= maxLifts {
+			return domain.ErrFloorAtCapacity
+		}
+
+		query := `INSERT INTO floor_lift_assignments (floor_id, lift_id, floor_number) VALUES ($1, $2, $3)`
+		if _, err := q.ExecContext(ctx, query, floorID, liftID, floorNumber); err != nil {
+			return fmt.Errorf("failed to assign lift to floor: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *Repository) UnassignLiftFromFloor(ctx context.Context, liftID string, floorID string) error {
+	query := `DELETE FROM floor_lift_assignments WHERE floor_id = $1 AND lift_id = $2`
+	if _, err := r.q.ExecContext(ctx, query, floorID, liftID); err != nil {
+		return fmt.Errorf("failed to unassign lift from floor: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) UnassignBulk(ctx context.Context) error {
+	query := `DELETE FROM floor_lift_assignments`
+	if _, err := r.q.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to delete all records from floor_lift_assignments: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetAssignedLiftsForFloor(ctx context.Context, floorID string) ([]*domain.Lift, error) {
+	query := `
+		SELECT l.id, l.name, l.current_floor, l.status, l.capacity
+		FROM lifts l
+		JOIN floor_lift_assignments fla ON l.id = fla.lift_id
+		WHERE fla.floor_id = $1
+	`
+	rows, err := r.q.QueryContext(ctx, query, floorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assigned lifts: %w", err)
+	}
+	defer rows.Close()
+
+	return scanLifts(rows)
+}
+
+// Event Store Methods
+
+func (r *Repository) AppendEvent(ctx context.Context, env domain.Envelope) (int64, error) {
+	payload, err := json.Marshal(env.Event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	query := `
+		INSERT INTO events (event_id, system_id, event_type, occurred_at, causation_id, correlation_id, trace_id, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING sequence
+	`
+	var seq int64
+	err = r.inTx(ctx, func(q dbtx) error {
+		// Sequences are handed out before commit, so two concurrent appends
+		// could become visible out of order and a follower reading past the
+		// later one would never see the earlier. The lock conflicts only with
+		// other writers of the table, making appends commit in sequence order
+		// while reads go on.
+		if _, err := q.ExecContext(ctx, `LOCK TABLE events IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return fmt.Errorf("failed to lock events: %w", err)
+		}
+
+		err := q.QueryRowContext(ctx, query,
+			env.ID,
+			env.SystemID,
+			env.Event.Type().String(),
+			env.OccurredAt.UTC(),
+			env.CausationID,
+			env.CorrelationID,
+			env.TraceID,
+			payload).Scan(&seq)
+		if err != nil {
+			return fmt.Errorf("failed to append event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return seq, nil
+}
+
+func (r *Repository) ListEvents(ctx context.Context, since int64, limit int) ([]*domain.StoredEvent, error) {
+	query := `
+		SELECT sequence, event_id, system_id, event_type, occurred_at, causation_id, correlation_id, trace_id, payload
+		FROM events
+		WHERE sequence > $1
+		ORDER BY sequence
+		LIMIT $2
+	`
+	rows, err := r.q.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.StoredEvent
+	for rows.Next() {
+		var stored domain.StoredEvent
+		var systemID, causationID, correlationID, traceID sql.NullString
+		var typeName string
+		var payload []byte
+
+		if err := rows.Scan(&stored.Sequence, &stored.ID, &systemID, &typeName, &stored.OccurredAt,
+			&causationID, &correlationID, &traceID, &payload); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		stored.SystemID = systemID.String
+		stored.CausationID = causationID.String
+		stored.CorrelationID = correlationID.String
+		stored.TraceID = traceID.String
+		eventType, err := domain.ParseEventType(typeName)
+		if err != nil {
+			return nil, err
+		}
+		stored.Event, err = domain.DecodeEvent(eventType, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &stored)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *Repository) LatestEventSequence(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := r.q.QueryRowContext(ctx, `SELECT COALESCE(MAX(sequence), 0) FROM events`).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to get latest event sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// Trip Repository Methods
+
+func (r *Repository) SaveTrip(ctx context.Context, trip *domain.Trip) error {
+	query := `
+		INSERT INTO trips (id, lift_id, origin_floor, destination_floor, started_at, ended_at, trigger_type, passengers)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		trip.ID,
+		trip.LiftID,
+		trip.OriginFloor,
+		trip.DestinationFloor,
+		trip.StartedAt.UTC(),
+		trip.EndedAt.UTC(),
+		string(trip.Trigger),
+		trip.Passengers)
+	if err != nil {
+		return fmt.Errorf("failed to save trip: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ListTrips(ctx context.Context, filter domain.TripFilter) ([]*domain.Trip, int, error) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.LiftID != "" {
+		add("lift_id = $%d", filter.LiftID)
+	}
+	if filter.Floor != nil {
+		add("(origin_floor = $%[1]d OR destination_floor = $%[1]d)", *filter.Floor)
+	}
+	if !filter.From.IsZero() {
+		add("ended_at >= $%d", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		add("started_at < $%d", filter.To.UTC())
+	}
+	if filter.Trigger != "" {
+		add("trigger_type = $%d", string(filter.Trigger))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM trips"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count trips: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT id, lift_id, origin_floor, destination_floor, started_at, ended_at, trigger_type, passengers FROM trips` +
+		where + " ORDER BY started_at DESC" + fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list trips: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []*domain.Trip
+	for rows.Next() {
+		var trip domain.Trip
+		var trigger string
+		err := rows.Scan(&trip.ID, &trip.LiftID, &trip.OriginFloor, &trip.DestinationFloor,
+			&trip.StartedAt, &trip.EndedAt, &trigger, &trip.Passengers)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan trip: %w", err)
+		}
+		trip.Trigger = domain.TripTrigger(trigger)
+		trips = append(trips, &trip)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error after scanning trips: %w", err)
+	}
+
+	return trips, total, nil
+}
+
+// Hall Call Repository Methods
+
+func (r *Repository) SaveHallCall(ctx context.Context, call *domain.HallCall) error {
+	query := `
+		INSERT INTO hall_calls (id, floor_number, direction, status, lift_id, reason, eta, created_at, assigned_at, escalated_at, closed_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		call.ID,
+		call.FloorNumber,
+		int(call.Direction),
+		string(call.Status),
+		call.LiftID,
+		call.Reason,
+		nullTime(call.ETA),
+		call.CreatedAt.UTC(),
+		nullTime(call.AssignedAt),
+		nullTime(call.EscalatedAt),
+		nullTime(call.ClosedAt),
+		call.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save hall call: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetHallCall(ctx context.Context, id string) (*domain.HallCall, error) {
+	query := `SELECT ` + hallCallColumns + ` FROM hall_calls WHERE id = $1`
+
+	call, err := scanHallCall(r.q.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrHallCallNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hall call: %w", err)
+	}
+	return call, nil
+}
+
+func (r *Repository) UpdateHallCall(ctx context.Context, call *domain.HallCall) error {
+	query := `
+		UPDATE hall_calls
+		SET status = $1, lift_id = $2, reason = $3, eta = $4, assigned_at = $5, escalated_at = $6, closed_at = $7, updated_at = $8
+		WHERE id = $9
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		string(call.Status),
+		call.LiftID,
+		call.Reason,
+		nullTime(call.ETA),
+		nullTime(call.AssignedAt),
+		nullTime(call.EscalatedAt),
+		nullTime(call.ClosedAt),
+		call.UpdatedAt.UTC(),
+		call.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update hall call: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrHallCallNotFound, call.ID)
+	}
+
+	return nil
+}
+
+func (r *Repository) ListHallCalls(ctx context.Context, filter domain.HallCallFilter) ([]*domain.HallCall, int, error) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Floor != nil {
+		add("floor_number = $%d", *filter.Floor)
+	}
+	if filter.Direction != nil {
+		add("direction = $%d", int(*filter.Direction))
+	}
+	if filter.LiftID != "" {
+		add("lift_id = $%d", filter.LiftID)
+	}
+	if filter.Status != "" {
+		add("status = $%d", string(filter.Status))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM hall_calls"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count hall calls: %w", err)
+	}
+
+	order := " ORDER BY created_at DESC"
+	if filter.OldestFirst {
+		order = " ORDER BY created_at"
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT ` + hallCallColumns + ` FROM hall_calls` + where + order + fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list hall calls: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []*domain.HallCall
+	for rows.Next() {
+		call, err := scanHallCall(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan hall call: %w", err)
+		}
+		calls = append(calls, call)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error after scanning hall calls: %w", err)
+	}
+
+	return calls, total, nil
+}
+
+// Webhook Repository Methods
+
+func (r *Repository) SaveWebhook(ctx context.Context, hook *domain.Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, url, event_types, secret, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		hook.ID,
+		hook.URL,
+		joinEventTypes(hook.EventTypes),
+		hook.Secret,
+		hook.Active,
+		hook.CreatedAt.UTC(),
+		hook.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, id string) (*domain.Webhook, error) {
+	query := `SELECT id, url, event_types, secret, active, created_at, updated_at FROM webhooks WHERE id = $1`
+
+	hook, err := scanWebhook(r.q.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return hook, nil
+}
+
+func (r *Repository) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	query := `SELECT id, url, event_types, secret, active, created_at, updated_at FROM webhooks ORDER BY created_at`
+
+	rows, err := r.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []*domain.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+func (r *Repository) UpdateWebhook(ctx context.Context, hook *domain.Webhook) error {
+	query := `UPDATE webhooks SET url = $1, event_types = $2, secret = $3, active = $4, updated_at = $5 WHERE id = $6`
+	result, err := r.q.ExecContext(ctx, query,
+		hook.URL,
+		joinEventTypes(hook.EventTypes),
+		hook.Secret,
+		hook.Active,
+		hook.UpdatedAt.UTC(),
+		hook.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, hook.ID)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteWebhook(ctx context.Context, id string) error {
+	return r.inTx(ctx, func(q dbtx) error {
+		result, err := q.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, id)
+		}
+
+		query := `DELETE FROM webhook_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE webhook_id = $1)`
+		if _, err := q.ExecContext(ctx, query, id); err != nil {
+			return fmt.Errorf("failed to delete webhook attempts: %w", err)
+		}
+		if _, err := q.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = $1`, id); err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *Repository) SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		delivery.ID,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType.String(),
+		delivery.Payload,
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.NextAttemptAt.UTC(),
+		delivery.CreatedAt.UTC(),
+		delivery.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetWebhookDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	delivery, err := scanWebhookDelivery(r.q.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWebhookDeliveryNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+func (r *Repository) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, updated_at = $5
+		WHERE id = $6
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.NextAttemptAt.UTC(),
+		delivery.UpdatedAt.UTC(),
+		delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrWebhookDeliveryNotFound, delivery.ID)
+	}
+
+	return nil
+}
+
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.WebhookID != "" {
+		add("webhook_id = $%d", filter.WebhookID)
+	}
+	if filter.Status != "" {
+		add("status = $%d", string(filter.Status))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit)
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries` + where + " ORDER BY created_at DESC" + fmt.Sprintf(" LIMIT $%d", len(args))
+
+	return r.queryWebhookDeliveries(ctx, query, args...)
+}
+
+func (r *Repository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at
+		LIMIT $3`
+
+	return r.queryWebhookDeliveries(ctx, query, string(domain.DeliveryPending), now.UTC(), limit)
+}
+
+func (r *Repository) queryWebhookDeliveries(ctx context.Context, query string, args ...any) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *Repository) AppendWebhookAttempt(ctx context.Context, attempt *domain.WebhookAttempt) error {
+	query := `
+		INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		attempt.DeliveryID,
+		attempt.Attempt,
+		attempt.StatusCode,
+		attempt.Error,
+		attempt.DurationMS,
+		attempt.AttemptedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to append webhook attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ListWebhookAttempts(ctx context.Context, deliveryID string) ([]*domain.WebhookAttempt, error) {
+	query := `
+		SELECT delivery_id, attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_attempts
+		WHERE delivery_id = $1
+		ORDER BY id
+	`
+	rows, err := r.q.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*domain.WebhookAttempt
+	for rows.Next() {
+		var attempt domain.WebhookAttempt
+		var statusCode sql.NullInt64
+		var errMsg sql.NullString
+
+		if err := rows.Scan(&attempt.DeliveryID, &attempt.Attempt, &statusCode, &errMsg,
+			&attempt.DurationMS, &attempt.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt: %w", err)
+		}
+		attempt.StatusCode = int(statusCode.Int64)
+		attempt.Error = errMsg.String
+		attempts = append(attempts, &attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning webhook attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// API Key Repository Methods
+
+func (r *Repository) SaveAPIKey(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, name, prefix, hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		joinScopes(key.Scopes),
+		key.CreatedBy,
+		key.CreatedAt.UTC(),
+		nullTime(key.ExpiresAt),
+		nullTime(key.LastUsedAt),
+		nullTime(key.RevokedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetAPIKey(ctx context.Context, id string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	key, err := scanAPIKey(r.q.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE hash = $1`
+
+	key, err := scanAPIKey(r.q.QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at`
+
+	rows, err := r.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *Repository) UpdateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	query := `UPDATE api_keys SET name = $1, scopes = $2, expires_at = $3, last_used_at = $4, revoked_at = $5 WHERE id = $6`
+	result, err := r.q.ExecContext(ctx, query,
+		key.Name,
+		joinScopes(key.Scopes),
+		nullTime(key.ExpiresAt),
+		nullTime(key.LastUsedAt),
+		nullTime(key.RevokedAt),
+		key.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, key.ID)
+	}
+
+	return nil
+}
+
+// Audit Repository Methods
+
+func (r *Repository) AppendAudit(ctx context.Context, entry *domain.AuditEntry) (int64, error) {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (principal, source_ip, trace_id, method, route, path, subject, payload, changes, status, outcome, error, at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING sequence
+	`
+	var seq int64
+	err = r.q.QueryRowContext(ctx, query,
+		entry.Principal,
+		entry.SourceIP,
+		entry.TraceID,
+		entry.Method,
+		entry.Route,
+		entry.Path,
+		entry.Subject,
+		entry.Payload,
+		string(changes),
+		entry.Status,
+		string(entry.Outcome),
+		entry.Error,
+		entry.At.UTC()).Scan(&seq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return seq, nil
+}
+
+func (r *Repository) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, int, error) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Principal != "" {
+		add("principal = $%d", filter.Principal)
+	}
+	if filter.Subject != "" {
+		add("subject = $%d", filter.Subject)
+	}
+	if filter.Route != "" {
+		add("route = $%d", filter.Route)
+	}
+	if filter.Method != "" {
+		add("method = $%d", filter.Method)
+	}
+	if filter.Outcome != "" {
+		add("outcome = $%d", string(filter.Outcome))
+	}
+	if !filter.From.IsZero() {
+		add("at >= $%d", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		add("at < $%d", filter.To.UTC())
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT sequence, principal, source_ip, trace_id, method, route, path, subject, payload, changes, status, outcome, error, at FROM audit_log` +
+		where + " ORDER BY sequence DESC" + fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error after scanning audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// Idempotency Repository Methods
+
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, rec *domain.IdempotencyRecord, abandonedBefore time.Time) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (principal, idempotency_key, method, path, request_hash, status, content_type, location, body, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, 0, '', '', NULL, $6, $7)
+		ON CONFLICT (principal, idempotency_key) DO UPDATE SET
+			method = excluded.method,
+			path = excluded.path,
+			request_hash = excluded.request_hash,
+			status = 0,
+			content_type = '',
+			location = '',
+			body = NULL,
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= excluded.created_at
+		OR (idempotency_keys.status = 0 AND idempotency_keys.created_at < $8)
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		rec.Principal,
+		rec.Key,
+		rec.Method,
+		rec.Path,
+		rec.RequestHash,
+		rec.CreatedAt.UTC(),
+		rec.ExpiresAt.UTC(),
+		abandonedBefore.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *Repository) GetIdempotencyRecord(ctx context.Context, principal, key string) (*domain.IdempotencyRecord, error) {
+	query := `
+		SELECT principal, idempotency_key, method, path, request_hash, status, content_type, location, body, created_at, expires_at
+		FROM idempotency_keys WHERE principal = $1 AND idempotency_key = $2
+	`
+	var rec domain.IdempotencyRecord
+	err := r.q.QueryRowContext(ctx, query, principal, key).Scan(&rec.Principal, &rec.Key, &rec.Method, &rec.Path,
+		&rec.RequestHash, &rec.Status, &rec.ContentType, &rec.Location, &rec.Body, &rec.CreatedAt, &rec.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrIdempotencyKeyNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	return &rec, nil
+}
+
+func (r *Repository) CompleteIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys SET status = $1, content_type = $2, location = $3, body = $4
+		WHERE principal = $5 AND idempotency_key = $6 AND request_hash = $7
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		rec.Status,
+		rec.ContentType,
+		rec.Location,
+		rec.Body,
+		rec.Principal,
+		rec.Key,
+		rec.RequestHash)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrIdempotencyKeyNotFound, rec.Key)
+	}
+
+	return nil
+}
+
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, principal, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE principal = $1 AND idempotency_key = $2 AND status = 0`
+	if _, err := r.q.ExecContext(ctx, query, principal, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.q.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency records: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+const hallCallColumns = `id, floor_number, direction, status, lift_id, reason, eta, created_at, assigned_at, escalated_at, closed_at, updated_at`
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at`
+
+func scanWebhook(s scanner) (*domain.Webhook, error) {
+	var hook domain.Webhook
+	var eventTypes string
+
+	if err := s.Scan(&hook.ID, &hook.URL, &eventTypes, &hook.Secret, &hook.Active,
+		&hook.CreatedAt, &hook.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	types, err := splitEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	hook.EventTypes = types
+	return &hook, nil
+}
+
+func scanHallCall(s scanner) (*domain.HallCall, error) {
+	var call domain.HallCall
+	var direction int
+	var status string
+	var eta, assignedAt, escalatedAt, closedAt sql.NullTime
+
+	if err := s.Scan(&call.ID, &call.FloorNumber, &direction, &status, &call.LiftID, &call.Reason,
+		&eta, &call.CreatedAt, &assignedAt, &escalatedAt, &closedAt, &call.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	call.Direction = domain.Direction(direction)
+	call.Status = domain.HallCallStatus(status)
+	call.ETA = timePtr(eta)
+	call.AssignedAt = timePtr(assignedAt)
+	call.EscalatedAt = timePtr(escalatedAt)
+	call.ClosedAt = timePtr(closedAt)
+	return &call, nil
+}
+
+// nullTime stores an optional timestamp, leaving the column NULL when unset
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func scanWebhookDelivery(s scanner) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var eventType, status string
+	var payload []byte
+	var lastError sql.NullString
+
+	if err := s.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &eventType, &payload, &status,
+		&delivery.Attempts, &lastError, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	t, err := domain.ParseEventType(eventType)
+	if err != nil {
+		return nil, err
+	}
+	delivery.EventType = t
+	delivery.Payload = payload
+	delivery.Status = domain.WebhookDeliveryStatus(status)
+	delivery.LastError = lastError.String
+	return &delivery, nil
+}
+
+// joinEventTypes stores an event type filter as a comma separated list of names
+func joinEventTypes(types []domain.EventType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ",")
+}
+
+func splitEventTypes(s string) ([]domain.EventType, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return domain.ParseEventTypes(strings.Split(s, ","))
+}
+
+const apiKeyColumns = `id, name, prefix, hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+func scanAPIKey(s scanner) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	if err := s.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedBy,
+		&key.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	for _, name := range strings.Split(scopes, ",") {
+		scope, err := domain.ParseAPIKeyScope(name)
+		if err != nil {
+			return nil, err
+		}
+		key.Scopes = append(key.Scopes, scope)
+	}
+	key.ExpiresAt = timePtr(expiresAt)
+	key.LastUsedAt = timePtr(lastUsedAt)
+	key.RevokedAt = timePtr(revokedAt)
+	return &key, nil
+}
+
+func scanAuditEntry(s scanner) (*domain.AuditEntry, error) {
+	var entry domain.AuditEntry
+	var changes, outcome string
+
+	if err := s.Scan(&entry.Sequence, &entry.Principal, &entry.SourceIP, &entry.TraceID, &entry.Method,
+		&entry.Route, &entry.Path, &entry.Subject, &entry.Payload, &changes, &entry.Status, &outcome,
+		&entry.Error, &entry.At); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+		return nil, err
+	}
+	entry.Outcome = domain.AuditOutcome(outcome)
+	return &entry, nil
+}
+
+// joinScopes stores the scopes of an API key as a comma separated list
+func joinScopes(scopes []domain.APIKeyScope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ",")
+}
+
+// Close closes the database connection
+func (r *Repository) Close() error {
+	return r.db.Close()
+}
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFloor(s scanner) (*domain.Floor, error) {
+	var floorID string
+	var number int
+	var upButtonActive, downButtonActive bool
+
+	if err := s.Scan(&floorID, &number, &upButtonActive, &downButtonActive); err != nil {
+		return nil, err
+	}
+
+	floor := domain.NewFloor(floorID, number)
+	floor.SetUpButtonActive(upButtonActive)
+	floor.SetDownButtonActive(downButtonActive)
+	return floor, nil
+}
+
+func scanLifts(rows *sql.Rows) ([]*domain.Lift, error) {
+	var lifts []*domain.Lift
+	for rows.Next() {
+		var id, name, statusStr string
+		var currentFloor, capacity int
+
+		if err := rows.Scan(&id, &name, &currentFloor, &statusStr, &capacity); err != nil {
+			return nil, fmt.Errorf("failed to scan lift: %w", err)
+		}
+
+		lift := domain.NewLift(id, name)
+		lift.SetCurrentFloor(currentFloor)
+		lift.SetStatus(domain.StringToLiftStatus(statusStr))
+		lift.SetCapacity(capacity)
+		lifts = append(lifts, lift)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning lifts: %w", err)
+	}
+
+	return lifts, nil
+}
+
+// Ensure Repository implements ports.TransactionalRepository interface
+var _ ports.TransactionalRepository = (*Repository)(nil)
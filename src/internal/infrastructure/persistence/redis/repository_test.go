@@ -0,0 +1,302 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/persistence/sqlite"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const systemID = "system-1"
+
+// newTestRepository returns a Redis repository backed by miniredis in front
+// of a SQLite store holding a system with two floors and two lifts.
+func newTestRepository(t *testing.T) (*Repository, *sqlite.Repository, *miniredis.Miniredis) {
+	t.Helper()
+	ctx := context.Background()
+	log := logger.New(io.Discard, logger.LevelError, "TEST", nil)
+
+	durable, err := sqlite.NewRepository(filepath.Join(t.TempDir(), "lift.sqlite"), log)
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+
+	mr := miniredis.RunT(t)
+	client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	repo := NewRepository(client, durable, log)
+
+	system, err := domain.NewSystem(systemID, 2, 2)
+	if err != nil {
+		t.Fatalf("creating system: %v", err)
+	}
+	if err := repo.SaveSystem(ctx, system); err != nil {
+		t.Fatalf("saving system: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := repo.SaveFloor(ctx, domain.NewFloor(fmt.Sprintf("floor-%d", i), i), systemID); err != nil {
+			t.Fatalf("saving floor %d: %v", i, err)
+		}
+	}
+	for i := 1; i <= 2; i++ {
+		if err := repo.SaveLift(ctx, domain.NewLift(fmt.Sprintf("lift-%d", i), fmt.Sprintf("L%d", i)), systemID); err != nil {
+			t.Fatalf("saving lift %d: %v", i, err)
+		}
+	}
+
+	return repo, durable, mr
+}
+
+func TestLiftRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	repo, _, _ := newTestRepository(t)
+
+	lift, err := repo.GetLift(ctx, "lift-1")
+	if err != nil {
+		t.Fatalf("getting lift: %v", err)
+	}
+	moved := time.Date(2024, 1, 1, 9, 30, 0, 123, time.UTC)
+	lift.SetCurrentFloor(1)
+	lift.TargetFloor = 0
+	lift.Direction = domain.Down
+	lift.SetStatus(domain.Occupied)
+	lift.SetCapacity(6)
+	lift.Passengers = 3
+	lift.LastMoveTime = moved
+	if err := repo.UpdateLift(ctx, lift); err != nil {
+		t.Fatalf("updating lift: %v", err)
+	}
+
+	got, err := repo.GetLift(ctx, "lift-1")
+	if err != nil {
+		t.Fatalf("getting lift: %v", err)
+	}
+	if *got != *lift {
+		t.Errorf("got lift %+v, want %+v", *got, *lift)
+	}
+}
+
+func TestUpdateLiftWritesThrough(t *testing.T) {
+	ctx := context.Background()
+	repo, durable, _ := newTestRepository(t)
+
+	lift, err := repo.GetLift(ctx, "lift-2")
+	if err != nil {
+		t.Fatalf("getting lift: %v", err)
+	}
+	lift.SetCurrentFloor(1)
+	lift.SetStatus(domain.OutOfService)
+	if err := repo.UpdateLift(ctx, lift); err != nil {
+		t.Fatalf("updating lift: %v", err)
+	}
+
+	stored, err := durable.GetLift(ctx, "lift-2")
+	if err != nil {
+		t.Fatalf("getting durable lift: %v", err)
+	}
+	if stored.CurrentFloor != 1 || stored.Status != domain.OutOfService {
+		t.Errorf("durable lift at floor %d with status %s, want floor 1 and OutOfService",
+			stored.CurrentFloor, domain.LiftStatusToString(stored.Status))
+	}
+}
+
+func TestUpdateLiftUnknown(t *testing.T) {
+	ctx := context.Background()
+	repo, _, mr := newTestRepository(t)
+
+	err := repo.UpdateLift(ctx, domain.NewLift("missing", "L9"))
+	if !errors.Is(err, domain.ErrLiftNotFound) {
+		t.Fatalf("got error %v, want %v", err, domain.ErrLiftNotFound)
+	}
+	if mr.Exists(fmt.Sprintf(liftKeyFmt, "missing")) {
+		t.Error("unknown lift was cached")
+	}
+}
+
+func TestUpdateFloorWritesThrough(t *testing.T) {
+	ctx := context.Background()
+	repo, durable, _ := newTestRepository(t)
+
+	floor, err := repo.GetFloorByNumber(ctx, 1)
+	if err != nil {
+		t.Fatalf("getting floor: %v", err)
+	}
+	floor.SetDownButtonActive(true)
+	if err := repo.UpdateFloor(ctx, floor); err != nil {
+		t.Fatalf("updating floor: %v", err)
+	}
+
+	for name, r := range map[string]interface {
+		GetFloor(ctx context.Context, id string) (*domain.Floor, error)
+	}{"redis": repo, "durable": durable} {
+		got, err := r.GetFloor(ctx, floor.ID)
+		if err != nil {
+			t.Fatalf("getting %s floor: %v", name, err)
+		}
+		if !got.GetDownButtonActive() || got.GetUpButtonActive() {
+			t.Errorf("%s floor has buttons up=%v down=%v, want only down", name, got.GetUpButtonActive(), got.GetDownButtonActive())
+		}
+	}
+}
+
+func TestAssignLiftToFloorWithLimit(t *testing.T) {
+	ctx := context.Background()
+	repo, durable, _ := newTestRepository(t)
+
+	if err := repo.AssignLiftToFloorWithLimit(ctx, "lift-1", "floor-1", 1, 1); err != nil {
+		t.Fatalf("assigning first lift: %v", err)
+	}
+	if err := repo.AssignLiftToFloorWithLimit(ctx, "lift-2", "floor-1", 1, 1); !errors.Is(err, domain.ErrFloorAtCapacity) {
+		t.Fatalf("assigning second lift: got %v, want %v", err, domain.ErrFloorAtCapacity)
+	}
+
+	lifts, err := durable.GetAssignedLiftsForFloor(ctx, "floor-1")
+	if err != nil {
+		t.Fatalf("getting durable assignments: %v", err)
+	}
+	if len(lifts) != 1 || lifts[0].ID != "lift-1" {
+		t.Errorf("durable assignments %v, want only lift-1", liftIDs(lifts))
+	}
+
+	if err := repo.UnassignLiftFromFloor(ctx, "lift-1", "floor-1"); err != nil {
+		t.Fatalf("unassigning lift: %v", err)
+	}
+	for name, r := range map[string]interface {
+		GetAssignedLiftsForFloor(ctx context.Context, floorID string) ([]*domain.Lift, error)
+	}{"redis": repo, "durable": durable} {
+		lifts, err := r.GetAssignedLiftsForFloor(ctx, "floor-1")
+		if err != nil {
+			t.Fatalf("getting %s assignments: %v", name, err)
+		}
+		if len(lifts) != 0 {
+			t.Errorf("%s assignments %v after unassigning, want none", name, liftIDs(lifts))
+		}
+	}
+}
+
+func TestWarm(t *testing.T) {
+	ctx := context.Background()
+	repo, _, mr := newTestRepository(t)
+
+	if err := repo.AssignLiftToFloor(ctx, "lift-2", "floor-0", 0); err != nil {
+		t.Fatalf("assigning lift: %v", err)
+	}
+	floor, err := repo.GetFloorByNumber(ctx, 0)
+	if err != nil {
+		t.Fatalf("getting floor: %v", err)
+	}
+	floor.SetUpButtonActive(true)
+	if err := repo.UpdateFloor(ctx, floor); err != nil {
+		t.Fatalf("updating floor: %v", err)
+	}
+
+	// A restarted Redis comes back empty.
+	mr.FlushAll()
+	if err := repo.Warm(ctx); err != nil {
+		t.Fatalf("warming: %v", err)
+	}
+
+	members, err := mr.Members(liftsKey)
+	if err != nil || len(members) != 2 {
+		t.Errorf("warmed lifts %v (%v), want 2", members, err)
+	}
+	members, err = mr.Members(fmt.Sprintf(assignKeyFmt, "floor-0"))
+	if err != nil || len(members) != 1 || members[0] != "lift-2" {
+		t.Errorf("warmed assignments of floor 0 %v (%v), want [lift-2]", members, err)
+	}
+	if up := mr.HGet(fmt.Sprintf(floorKeyFmt, "floor-0"), "up_button_active"); up != "1" {
+		t.Errorf("warmed up button %q, want 1", up)
+	}
+}
+
+func TestWarmKeepsLiveState(t *testing.T) {
+	ctx := context.Background()
+	repo, _, mr := newTestRepository(t)
+
+	mr.HSet(fmt.Sprintf(liftKeyFmt, "lift-1"), "current_floor", "1")
+	if err := repo.Warm(ctx); err != nil {
+		t.Fatalf("warming: %v", err)
+	}
+	if floor := mr.HGet(fmt.Sprintf(liftKeyFmt, "lift-1"), "current_floor"); floor != "1" {
+		t.Errorf("live current_floor overwritten with %q", floor)
+	}
+}
+
+func TestResetSystemFlushesLiveState(t *testing.T) {
+	ctx := context.Background()
+	repo, _, mr := newTestRepository(t)
+
+	if err := repo.ResetSystem(ctx, systemID); err != nil {
+		t.Fatalf("resetting: %v", err)
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Errorf("keys left after reset: %v", keys)
+	}
+}
+
+func TestTransactionReloadsLiveStateOnCommit(t *testing.T) {
+	ctx := context.Background()
+	repo, durable, mr := newTestRepository(t)
+
+	update := func(commit bool, floor int) {
+		t.Helper()
+		tx, err := repo.BeginTx(ctx)
+		if err != nil {
+			t.Fatalf("beginning transaction: %v", err)
+		}
+		inTx := repo.WithTx(tx)
+		lift, err := inTx.GetLift(ctx, "lift-1")
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("getting lift: %v", err)
+		}
+		lift.SetCurrentFloor(floor)
+		if err := inTx.UpdateLift(ctx, lift); err != nil {
+			tx.Rollback()
+			t.Fatalf("updating lift: %v", err)
+		}
+		if commit {
+			err = tx.Commit()
+		} else {
+			err = tx.Rollback()
+		}
+		if err != nil {
+			t.Fatalf("ending transaction: %v", err)
+		}
+	}
+
+	update(false, 1)
+	if floor := mr.HGet(fmt.Sprintf(liftKeyFmt, "lift-1"), "current_floor"); floor != "0" {
+		t.Errorf("live current_floor is %q after a rollback, want 0", floor)
+	}
+
+	update(true, 1)
+	if floor := mr.HGet(fmt.Sprintf(liftKeyFmt, "lift-1"), "current_floor"); floor != "1" {
+		t.Errorf("live current_floor is %q after a commit, want 1", floor)
+	}
+	stored, err := durable.GetLift(ctx, "lift-1")
+	if err != nil {
+		t.Fatalf("getting durable lift: %v", err)
+	}
+	if stored.CurrentFloor != 1 {
+		t.Errorf("durable lift at floor %d after a commit, want 1", stored.CurrentFloor)
+	}
+}
+
+func liftIDs(lifts []*domain.Lift) []string {
+	ids := make([]string, len(lifts))
+	for i, lift := range lifts {
+		ids[i] = lift.ID
+	}
+	return ids
+}
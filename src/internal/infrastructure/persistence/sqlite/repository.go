@@ -3,7 +3,10 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Avyukth/lift-simulation/internal/application/ports"
 	"github.com/Avyukth/lift-simulation/internal/domain"
@@ -11,15 +14,31 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// dbtx is satisfied by both *sql.DB and *sql.Tx so that every query can run
+// either directly against the database or inside a transaction.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 // Repository implements the Repository interface using SQLite
 type Repository struct {
 	db  *sql.DB
+	q   dbtx
 	log *logger.Logger
 }
 
 // NewRepository creates a new instance of the SQLite repository
 func NewRepository(dbPath string, log *logger.Logger) (*Repository, error) {
-	db, err := sql.Open("sqlite3", dbPath)
+	// Writers wait for a transaction in progress, such as a snapshot import,
+	// instead of failing at once with "database is locked"
+	dsn := dbPath + "?_busy_timeout=5000"
+	if strings.Contains(dbPath, "?") {
+		dsn = dbPath + "&_busy_timeout=5000"
+	}
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -32,7 +51,7 @@ func NewRepository(dbPath string, log *logger.Logger) (*Repository, error) {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
 
-	return &Repository{db: db, log: log}, nil
+	return &Repository{db: db, q: db, log: log}, nil
 }
 
 // createTables creates the necessary tables if they don't exist
@@ -68,6 +87,134 @@ func createTables(db *sql.DB) error {
 			FOREIGN KEY (floor_id) REFERENCES floors(id) ON DELETE CASCADE,
 			FOREIGN KEY (lift_id) REFERENCES lifts(id) ON DELETE CASCADE
 		)`,
+		`CREATE TABLE IF NOT EXISTS events (
+			sequence INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_id TEXT NOT NULL,
+			system_id TEXT,
+			event_type TEXT NOT NULL,
+			occurred_at TIMESTAMP NOT NULL,
+			causation_id TEXT,
+			correlation_id TEXT,
+			trace_id TEXT,
+			payload TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS trips (
+			id TEXT PRIMARY KEY,
+			lift_id TEXT NOT NULL,
+			origin_floor INTEGER NOT NULL,
+			destination_floor INTEGER NOT NULL,
+			started_at TIMESTAMP NOT NULL,
+			ended_at TIMESTAMP NOT NULL,
+			trigger_type TEXT NOT NULL,
+			passengers INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE INDEX IF NOT EXISTS trips_lift_started_idx ON trips (lift_id, started_at)`,
+		`CREATE TABLE IF NOT EXISTS hall_calls (
+			id TEXT PRIMARY KEY,
+			floor_number INTEGER NOT NULL,
+			direction INTEGER NOT NULL,
+			status TEXT NOT NULL,
+			lift_id TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			eta TIMESTAMP,
+			created_at TIMESTAMP NOT NULL,
+			assigned_at TIMESTAMP,
+			escalated_at TIMESTAMP,
+			closed_at TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS hall_calls_created_idx ON hall_calls (created_at)`,
+		`CREATE INDEX IF NOT EXISTS hall_calls_status_idx ON hall_calls (status, created_at)`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			event_types TEXT NOT NULL DEFAULT '',
+			secret TEXT NOT NULL,
+			active BOOLEAN NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			event_id TEXT NOT NULL,
+			event_type TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			next_attempt_at TIMESTAMP NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (status, next_attempt_at)`,
+		`CREATE TABLE IF NOT EXISTS webhook_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			delivery_id TEXT NOT NULL,
+			attempt INTEGER NOT NULL,
+			status_code INTEGER,
+			error TEXT,
+			duration_ms INTEGER NOT NULL,
+			attempted_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS webhook_attempts_delivery_idx ON webhook_attempts (delivery_id)`,
+		`CREATE TABLE IF NOT EXISTS api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			prefix TEXT NOT NULL,
+			hash TEXT NOT NULL UNIQUE,
+			scopes TEXT NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP,
+			last_used_at TIMESTAMP,
+			revoked_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			sequence INTEGER PRIMARY KEY AUTOINCREMENT,
+			principal TEXT NOT NULL,
+			source_ip TEXT NOT NULL,
+			trace_id TEXT NOT NULL,
+			method TEXT NOT NULL,
+			route TEXT NOT NULL,
+			path TEXT NOT NULL,
+			subject TEXT NOT NULL DEFAULT '',
+			payload TEXT NOT NULL DEFAULT '',
+			changes TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			outcome TEXT NOT NULL,
+			error TEXT NOT NULL DEFAULT '',
+			at TIMESTAMP NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_at_idx ON audit_log (at)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_principal_idx ON audit_log (principal, at)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_subject_idx ON audit_log (subject, at)`,
+		// The audit log is append-only
+		`CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+		BEFORE UPDATE ON audit_log
+		BEGIN
+			SELECT RAISE(ABORT, 'audit log is append-only');
+		END;`,
+		`CREATE TRIGGER IF NOT EXISTS audit_log_no_delete
+		BEFORE DELETE ON audit_log
+		BEGIN
+			SELECT RAISE(ABORT, 'audit log is append-only');
+		END;`,
+		`CREATE TABLE IF NOT EXISTS idempotency_keys (
+			principal TEXT NOT NULL,
+			idempotency_key TEXT NOT NULL,
+			method TEXT NOT NULL,
+			path TEXT NOT NULL,
+			request_hash TEXT NOT NULL,
+			status INTEGER NOT NULL DEFAULT 0,
+			content_type TEXT NOT NULL DEFAULT '',
+			location TEXT NOT NULL DEFAULT '',
+			body BLOB,
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (principal, idempotency_key)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idempotency_keys_expires_idx ON idempotency_keys (expires_at)`,
 		`CREATE TRIGGER IF NOT EXISTS delete_system_cascade
 		AFTER DELETE ON system
 		FOR EACH ROW
@@ -102,10 +249,10 @@ func (r *Repository) GetLift(ctx context.Context, id string) (*domain.Lift, erro
 	var statusStr string
 	var capacity int
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&liftID, &name, &currentFloor, &statusStr, &capacity)
+	err := r.q.QueryRowContext(ctx, query, id).Scan(&liftID, &name, &currentFloor, &statusStr, &capacity)
 	if err == sql.ErrNoRows {
 		r.log.Error(ctx, "Lift not found", "lift_id", id)
-		return nil, fmt.Errorf("lift not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrLiftNotFound, id)
 	}
 	if err != nil {
 		r.log.Error(ctx, "Failed to get lift", "lift_id", id, "error", err)
@@ -125,7 +272,7 @@ func (r *Repository) ListLifts(ctx context.Context) ([]*domain.Lift, error) {
 	r.log.Info(ctx, "Listing all lifts")
 
 	query := `SELECT id, name, current_floor, status, capacity FROM lifts`
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.q.QueryContext(ctx, query)
 	if err != nil {
 		r.log.Error(ctx, "Failed to query lifts", "error", err)
 		return nil, fmt.Errorf("failed to list lifts: %w", err)
@@ -164,9 +311,15 @@ func (r *Repository) ListLifts(ctx context.Context) ([]*domain.Lift, error) {
 }
 
 func (r *Repository) SaveLift(ctx context.Context, lift *domain.Lift, systemID string) error {
-	stmt, err := r.db.PrepareContext(ctx, `
-		INSERT OR REPLACE INTO lifts (id, name, current_floor, status, capacity, system_id)
-		VALUES (?, ?, ?, ?, ?,?)
+	stmt, err := r.q.PrepareContext(ctx, `
+		INSERT INTO lifts (id, name, current_floor, status, capacity, system_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			name = excluded.name,
+			current_floor = excluded.current_floor,
+			status = excluded.status,
+			capacity = excluded.capacity,
+			system_id = excluded.system_id
 	`)
 	if err != nil {
 		r.log.Error(ctx, "Failed to prepare statement", "error", err)
@@ -200,7 +353,7 @@ func (r *Repository) SaveLift(ctx context.Context, lift *domain.Lift, systemID s
 
 func (r *Repository) DeleteLift(ctx context.Context, id string) error {
 	query := `DELETE FROM lifts WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := r.q.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete lift: %w", err)
 	}
@@ -215,9 +368,9 @@ func (r *Repository) GetFloor(ctx context.Context, id string) (*domain.Floor, er
 	var number int
 	var upButtonActive bool
 	var downButtonActive bool
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&floorID, &number, &upButtonActive, &downButtonActive)
+	err := r.q.QueryRowContext(ctx, query, id).Scan(&floorID, &number, &upButtonActive, &downButtonActive)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("floor not found: %s", id)
+		return nil, fmt.Errorf("%w: %s", domain.ErrFloorNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get floor: %w", err)
@@ -230,7 +383,7 @@ func (r *Repository) GetFloor(ctx context.Context, id string) (*domain.Floor, er
 
 func (r *Repository) ListFloors(ctx context.Context) ([]*domain.Floor, error) {
 	query := `SELECT id, floor_number, up_button_active, down_button_active FROM floors`
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.q.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list floors: %w", err)
 	}
@@ -254,7 +407,7 @@ func (r *Repository) ListFloors(ctx context.Context) ([]*domain.Floor, error) {
 }
 
 func (r *Repository) SaveFloor(ctx context.Context, floor *domain.Floor, systemID string) error {
-	stmt, err := r.db.PrepareContext(ctx, `
+	stmt, err := r.q.PrepareContext(ctx, `
 		INSERT OR REPLACE INTO floors (id, floor_number, up_button_active, down_button_active, system_id)
 		VALUES (?, ?, ?, ?,?)
 	`)
@@ -293,7 +446,7 @@ func (r *Repository) UpdateFloor(ctx context.Context, floor *domain.Floor) error
 		"up_button", floor.GetUpButtonActive(),
 		"down_button", floor.GetDownButtonActive())
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.q.ExecContext(ctx, query,
 		floor.Number,
 		floor.GetUpButtonActive(),
 		floor.GetDownButtonActive(),
@@ -311,7 +464,7 @@ func (r *Repository) UpdateFloor(ctx context.Context, floor *domain.Floor) error
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no rows affected, floor not found or no changes made")
+		return fmt.Errorf("%w: %s", domain.ErrFloorNotFound, floor.ID)
 	}
 
 	r.log.Info(ctx, "Successfully updated floor", "floor_id", floor.ID)
@@ -326,10 +479,10 @@ func (r *Repository) GetSystem(ctx context.Context) (*domain.System, error) {
 	query := `SELECT id, total_floors, total_lifts FROM system LIMIT 1`
 	var systemID string
 	var totalFloors, totalLifts int
-	err := r.db.QueryRowContext(ctx, query).Scan(&systemID, &totalFloors, &totalLifts)
+	err := r.q.QueryRowContext(ctx, query).Scan(&systemID, &totalFloors, &totalLifts)
 	if err == sql.ErrNoRows {
 		r.log.Error(ctx, "System configuration not found")
-		return nil, fmt.Errorf("system configuration not found")
+		return nil, domain.ErrSystemNotConfigured
 	}
 	if err != nil {
 		r.log.Error(ctx, "Failed to get system configuration", "error", err)
@@ -353,15 +506,30 @@ func (r *Repository) SaveSystem(ctx context.Context, system *domain.System) erro
 		INSERT OR REPLACE INTO system (id, total_floors, total_lifts)
 		VALUES (?, ?, ?)
 	`
-	_, err := r.db.ExecContext(ctx, query, system.ID, system.TotalFloors, system.TotalLifts)
+	_, err := r.q.ExecContext(ctx, query, system.ID, system.TotalFloors, system.TotalLifts)
 	if err != nil {
 		return fmt.Errorf("failed to save system configuration: %w", err)
 	}
 	return nil
 }
 
+// UpdateSystem changes the floor and lift counts in place. Saving the system
+// again would replace the row and cascade to its floors and lifts.
 func (r *Repository) UpdateSystem(ctx context.Context, system *domain.System) error {
-	return r.SaveSystem(ctx, system)
+	query := `UPDATE system SET total_floors = ?, total_lifts = ? WHERE id = ?`
+	result, err := r.q.ExecContext(ctx, query, system.TotalFloors, system.TotalLifts, system.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update system configuration: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no system with ID %s", domain.ErrSystemNotConfigured, system.ID)
+	}
+	return nil
 }
 
 func (r *Repository) GetAllLifts(ctx context.Context) ([]*domain.Lift, error) {
@@ -377,6 +545,50 @@ func (r *Repository) Close() error {
 	return r.db.Close()
 }
 
+// Transaction Methods
+
+// BeginTx starts a new database transaction
+func (r *Repository) BeginTx(ctx context.Context) (ports.Transaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// WithTx returns a repository whose queries run inside the given transaction
+func (r *Repository) WithTx(tx ports.Transaction) ports.Repository {
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return r
+	}
+	return &Repository{db: r.db, q: sqlTx, log: r.log}
+}
+
+// inTx runs fn inside a transaction. When the repository is already bound to
+// a transaction fn joins it and the caller stays in charge of committing.
+func (r *Repository) inTx(ctx context.Context, fn func(q dbtx) error) error {
+	if _, ok := r.q.(*sql.Tx); ok {
+		return fn(r.q)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (r *Repository) UpdateLift(ctx context.Context, lift *domain.Lift) error {
 	r.log.Info(ctx, "Updating lift", "lift_id", lift.ID)
 
@@ -395,7 +607,7 @@ func (r *Repository) UpdateLift(ctx context.Context, lift *domain.Lift) error {
 		"status", statusStr,
 		"capacity", lift.Capacity)
 
-	result, err := r.db.ExecContext(ctx, query,
+	result, err := r.q.ExecContext(ctx, query,
 		lift.Name,
 		lift.CurrentFloor,
 		statusStr,
@@ -414,29 +626,33 @@ func (r *Repository) UpdateLift(ctx context.Context, lift *domain.Lift) error {
 
 	if rowsAffected == 0 {
 		r.log.Warn(ctx, "No lift updated", "lift_id", lift.ID)
-		return fmt.Errorf("lift not found: %s", lift.ID)
+		return fmt.Errorf("%w: %s", domain.ErrLiftNotFound, lift.ID)
 	}
 
 	r.log.Info(ctx, "Lift updated successfully", "lift_id", lift.ID)
 	return nil
 }
 func (r *Repository) GetFloorByNumber(ctx context.Context, floorNum int) (*domain.Floor, error) {
-	query := `SELECT id FROM floors WHERE floor_number = ?`
+	query := `SELECT id, up_button_active, down_button_active FROM floors WHERE floor_number = ?`
 	var floorID string
-	err := r.db.QueryRowContext(ctx, query, floorNum).Scan(&floorID)
+	var upButtonActive, downButtonActive bool
+	err := r.q.QueryRowContext(ctx, query, floorNum).Scan(&floorID, &upButtonActive, &downButtonActive)
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("floor not found: %d", floorNum)
+		return nil, fmt.Errorf("%w: %d", domain.ErrFloorNotFound, floorNum)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get floor: %w", err)
 	}
-	return domain.NewFloor(floorID, floorNum), nil
+	floor := domain.NewFloor(floorID, floorNum)
+	floor.SetUpButtonActive(upButtonActive)
+	floor.SetDownButtonActive(downButtonActive)
+	return floor, nil
 }
 
 func (r *Repository) AssignLiftToFloor(ctx context.Context, liftID, floorID string, floorNumber int) error {
 	query := `INSERT INTO floor_lift_assignments (floor_id, lift_id, floor_number) VALUES (?, ?, ?)`
 
-	result, err := r.db.ExecContext(ctx, query, floorID, liftID, floorNumber)
+	result, err := r.q.ExecContext(ctx, query, floorID, liftID, floorNumber)
 	if err != nil {
 		return fmt.Errorf("failed to assign lift to floor: %w", err)
 	}
@@ -452,9 +668,34 @@ func (r *Repository) AssignLiftToFloor(ctx context.Context, liftID, floorID stri
 	return nil
 }
 
+func (r *Repository) AssignLiftToFloorWithLimit(ctx context.Context, liftID, floorID string, floorNumber int, maxLifts int) error {
+	// SQLite serialises writers, so the count and the insert in a single
+	// statement cannot interleave with another assignment.
+	query := `
+		INSERT INTO floor_lift_assignments (floor_id, lift_id, floor_number)
+		SELECT ?, ?, ?
+		WHERE (SELECT COUNT(*) FROM floor_lift_assignments WHERE floor_id = ?) < ?
+	`
+
+	result, err := r.q.ExecContext(ctx, query, floorID, liftID, floorNumber, floorID, maxLifts)
+	if err != nil {
+		return fmt.Errorf("failed to assign lift to floor: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrFloorAtCapacity
+	}
+
+	return nil
+}
+
 func (r *Repository) UnassignLiftFromFloor(ctx context.Context, liftID string, floorID string) error {
 	query := `DELETE FROM floor_lift_assignments WHERE floor_id = ? AND lift_id = ?`
-	_, err := r.db.ExecContext(ctx, query, floorID, liftID)
+	_, err := r.q.ExecContext(ctx, query, floorID, liftID)
 	if err != nil {
 		return fmt.Errorf("failed to unassign lift from floor: %w", err)
 	}
@@ -468,7 +709,7 @@ func (r *Repository) GetAssignedLiftsForFloor(ctx context.Context, floorID strin
         JOIN floor_lift_assignments fla ON l.id = fla.lift_id
         WHERE fla.floor_id = ?
     `
-	rows, err := r.db.QueryContext(ctx, query, floorID)
+	rows, err := r.q.QueryContext(ctx, query, floorID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get assigned lifts: %w", err)
 	}
@@ -495,7 +736,7 @@ func (r *Repository) GetAssignedLiftsForFloor(ctx context.Context, floorID strin
 
 func (r *Repository) UnassignBulk(ctx context.Context) error {
 	query := `DELETE FROM floor_lift_assignments`
-	_, err := r.db.ExecContext(ctx, query)
+	_, err := r.q.ExecContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to delete all records from floor_lift_assignments: %w", err)
 	}
@@ -505,7 +746,7 @@ func (r *Repository) UnassignBulk(ctx context.Context) error {
 
 func (r *Repository) ResetSystem(ctx context.Context, systemID string) error {
 	query := `DELETE FROM system WHERE id = ?`
-	result, err := r.db.ExecContext(ctx, query, systemID)
+	result, err := r.q.ExecContext(ctx, query, systemID)
 	if err != nil {
 		return fmt.Errorf("failed to delete system record: %w", err)
 	}
@@ -516,11 +757,1056 @@ func (r *Repository) ResetSystem(ctx context.Context, systemID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("no system found with ID: %s", systemID)
+		return fmt.Errorf("%w: no system with ID %s", domain.ErrSystemNotConfigured, systemID)
+	}
+
+	return nil
+}
+
+// Event Store Methods
+
+func (r *Repository) AppendEvent(ctx context.Context, env domain.Envelope) (int64, error) {
+	payload, err := json.Marshal(env.Event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	query := `
+		INSERT INTO events (event_id, system_id, event_type, occurred_at, causation_id, correlation_id, trace_id, payload)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		env.ID,
+		env.SystemID,
+		env.Event.Type().String(),
+		env.OccurredAt.UTC(),
+		env.CausationID,
+		env.CorrelationID,
+		env.TraceID,
+		string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to append event: %w", err)
+	}
+
+	seq, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get event sequence: %w", err)
+	}
+
+	return seq, nil
+}
+
+func (r *Repository) ListEvents(ctx context.Context, since int64, limit int) ([]*domain.StoredEvent, error) {
+	query := `
+		SELECT sequence, event_id, system_id, event_type, occurred_at, causation_id, correlation_id, trace_id, payload
+		FROM events
+		WHERE sequence > ?
+		ORDER BY sequence
+		LIMIT ?
+	`
+	rows, err := r.q.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*domain.StoredEvent
+	for rows.Next() {
+		var stored domain.StoredEvent
+		var systemID, causationID, correlationID, traceID sql.NullString
+		var typeName, payload string
+
+		if err := rows.Scan(&stored.Sequence, &stored.ID, &systemID, &typeName, &stored.OccurredAt,
+			&causationID, &correlationID, &traceID, &payload); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		stored.SystemID = systemID.String
+		stored.CausationID = causationID.String
+		stored.CorrelationID = correlationID.String
+		stored.TraceID = traceID.String
+		eventType, err := domain.ParseEventType(typeName)
+		if err != nil {
+			return nil, err
+		}
+		stored.Event, err = domain.DecodeEvent(eventType, []byte(payload))
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, &stored)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *Repository) LatestEventSequence(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := r.q.QueryRowContext(ctx, `SELECT COALESCE(MAX(sequence), 0) FROM events`).Scan(&seq); err != nil {
+		return 0, fmt.Errorf("failed to get latest event sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// Trip Repository Methods
+
+func (r *Repository) SaveTrip(ctx context.Context, trip *domain.Trip) error {
+	query := `
+		INSERT INTO trips (id, lift_id, origin_floor, destination_floor, started_at, ended_at, trigger_type, passengers)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		trip.ID,
+		trip.LiftID,
+		trip.OriginFloor,
+		trip.DestinationFloor,
+		trip.StartedAt.UTC(),
+		trip.EndedAt.UTC(),
+		string(trip.Trigger),
+		trip.Passengers)
+	if err != nil {
+		return fmt.Errorf("failed to save trip: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ListTrips(ctx context.Context, filter domain.TripFilter) ([]*domain.Trip, int, error) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		conditions = append(conditions, cond)
+		args = append(args, arg)
+	}
+
+	if filter.LiftID != "" {
+		add("lift_id = ?", filter.LiftID)
+	}
+	if filter.Floor != nil {
+		conditions = append(conditions, "(origin_floor = ? OR destination_floor = ?)")
+		args = append(args, *filter.Floor, *filter.Floor)
+	}
+	if !filter.From.IsZero() {
+		add("ended_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		add("started_at < ?", filter.To.UTC())
+	}
+	if filter.Trigger != "" {
+		add("trigger_type = ?", string(filter.Trigger))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM trips"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count trips: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT id, lift_id, origin_floor, destination_floor, started_at, ended_at, trigger_type, passengers FROM trips` +
+		where + " ORDER BY started_at DESC" + " LIMIT ? OFFSET ?"
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list trips: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []*domain.Trip
+	for rows.Next() {
+		var trip domain.Trip
+		var trigger string
+		err := rows.Scan(&trip.ID, &trip.LiftID, &trip.OriginFloor, &trip.DestinationFloor,
+			&trip.StartedAt, &trip.EndedAt, &trigger, &trip.Passengers)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan trip: %w", err)
+		}
+		trip.Trigger = domain.TripTrigger(trigger)
+		trips = append(trips, &trip)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error after scanning trips: %w", err)
+	}
+
+	return trips, total, nil
+}
+
+// Hall Call Repository Methods
+
+func (r *Repository) SaveHallCall(ctx context.Context, call *domain.HallCall) error {
+	query := `
+		INSERT INTO hall_calls (id, floor_number, direction, status, lift_id, reason, eta, created_at, assigned_at, escalated_at, closed_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		call.ID,
+		call.FloorNumber,
+		int(call.Direction),
+		string(call.Status),
+		call.LiftID,
+		call.Reason,
+		nullTime(call.ETA),
+		call.CreatedAt.UTC(),
+		nullTime(call.AssignedAt),
+		nullTime(call.EscalatedAt),
+		nullTime(call.ClosedAt),
+		call.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save hall call: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetHallCall(ctx context.Context, id string) (*domain.HallCall, error) {
+	query := `SELECT ` + hallCallColumns + ` FROM hall_calls WHERE id = ?`
+
+	call, err := scanHallCall(r.q.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrHallCallNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hall call: %w", err)
+	}
+	return call, nil
+}
+
+func (r *Repository) UpdateHallCall(ctx context.Context, call *domain.HallCall) error {
+	query := `
+		UPDATE hall_calls
+		SET status = ?, lift_id = ?, reason = ?, eta = ?, assigned_at = ?, escalated_at = ?, closed_at = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		string(call.Status),
+		call.LiftID,
+		call.Reason,
+		nullTime(call.ETA),
+		nullTime(call.AssignedAt),
+		nullTime(call.EscalatedAt),
+		nullTime(call.ClosedAt),
+		call.UpdatedAt.UTC(),
+		call.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update hall call: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrHallCallNotFound, call.ID)
+	}
+
+	return nil
+}
+
+func (r *Repository) ListHallCalls(ctx context.Context, filter domain.HallCallFilter) ([]*domain.HallCall, int, error) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		conditions = append(conditions, cond)
+		args = append(args, arg)
+	}
+
+	if filter.Floor != nil {
+		add("floor_number = ?", *filter.Floor)
+	}
+	if filter.Direction != nil {
+		add("direction = ?", int(*filter.Direction))
+	}
+	if filter.LiftID != "" {
+		add("lift_id = ?", filter.LiftID)
+	}
+	if filter.Status != "" {
+		add("status = ?", string(filter.Status))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM hall_calls"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count hall calls: %w", err)
+	}
+
+	order := " ORDER BY created_at DESC"
+	if filter.OldestFirst {
+		order = " ORDER BY created_at"
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT ` + hallCallColumns + ` FROM hall_calls` + where + order + " LIMIT ? OFFSET ?"
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list hall calls: %w", err)
+	}
+	defer rows.Close()
+
+	var calls []*domain.HallCall
+	for rows.Next() {
+		call, err := scanHallCall(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan hall call: %w", err)
+		}
+		calls = append(calls, call)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error after scanning hall calls: %w", err)
+	}
+
+	return calls, total, nil
+}
+
+// Webhook Repository Methods
+
+func (r *Repository) SaveWebhook(ctx context.Context, hook *domain.Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, url, event_types, secret, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		hook.ID,
+		hook.URL,
+		joinEventTypes(hook.EventTypes),
+		hook.Secret,
+		hook.Active,
+		hook.CreatedAt.UTC(),
+		hook.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetWebhook(ctx context.Context, id string) (*domain.Webhook, error) {
+	query := `SELECT id, url, event_types, secret, active, created_at, updated_at FROM webhooks WHERE id = ?`
+
+	hook, err := scanWebhook(r.q.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return hook, nil
+}
+
+func (r *Repository) ListWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	query := `SELECT id, url, event_types, secret, active, created_at, updated_at FROM webhooks ORDER BY created_at`
+
+	rows, err := r.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []*domain.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning webhooks: %w", err)
+	}
+
+	return hooks, nil
+}
+
+func (r *Repository) UpdateWebhook(ctx context.Context, hook *domain.Webhook) error {
+	query := `UPDATE webhooks SET url = ?, event_types = ?, secret = ?, active = ?, updated_at = ? WHERE id = ?`
+	result, err := r.q.ExecContext(ctx, query,
+		hook.URL,
+		joinEventTypes(hook.EventTypes),
+		hook.Secret,
+		hook.Active,
+		hook.UpdatedAt.UTC(),
+		hook.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, hook.ID)
 	}
 
 	return nil
 }
 
-// Ensure Repository implements ports.Repository interface
-var _ ports.Repository = (*Repository)(nil)
+func (r *Repository) DeleteWebhook(ctx context.Context, id string) error {
+	return r.inTx(ctx, func(q dbtx) error {
+		result, err := q.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: %s", domain.ErrWebhookNotFound, id)
+		}
+
+		query := `DELETE FROM webhook_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE webhook_id = ?)`
+		if _, err := q.ExecContext(ctx, query, id); err != nil {
+			return fmt.Errorf("failed to delete webhook attempts: %w", err)
+		}
+		if _, err := q.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE webhook_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *Repository) SaveWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		delivery.ID,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType.String(),
+		string(delivery.Payload),
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.NextAttemptAt.UTC(),
+		delivery.CreatedAt.UTC(),
+		delivery.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to save webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetWebhookDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = ?`
+
+	delivery, err := scanWebhookDelivery(r.q.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrWebhookDeliveryNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+func (r *Repository) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.NextAttemptAt.UTC(),
+		delivery.UpdatedAt.UTC(),
+		delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrWebhookDeliveryNotFound, delivery.ID)
+	}
+
+	return nil
+}
+
+func (r *Repository) ListWebhookDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	var conditions []string
+	var args []any
+
+	if filter.WebhookID != "" {
+		conditions = append(conditions, "webhook_id = ?")
+		args = append(args, filter.WebhookID)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, string(filter.Status))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit)
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries` + where + " ORDER BY created_at DESC LIMIT ?"
+
+	return r.queryWebhookDeliveries(ctx, query, args...)
+}
+
+func (r *Repository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at
+		LIMIT ?`
+
+	return r.queryWebhookDeliveries(ctx, query, string(domain.DeliveryPending), now.UTC(), limit)
+}
+
+func (r *Repository) queryWebhookDeliveries(ctx context.Context, query string, args ...any) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *Repository) AppendWebhookAttempt(ctx context.Context, attempt *domain.WebhookAttempt) error {
+	query := `
+		INSERT INTO webhook_attempts (delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		attempt.DeliveryID,
+		attempt.Attempt,
+		attempt.StatusCode,
+		attempt.Error,
+		attempt.DurationMS,
+		attempt.AttemptedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to append webhook attempt: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) ListWebhookAttempts(ctx context.Context, deliveryID string) ([]*domain.WebhookAttempt, error) {
+	query := `
+		SELECT delivery_id, attempt, status_code, error, duration_ms, attempted_at
+		FROM webhook_attempts
+		WHERE delivery_id = ?
+		ORDER BY id
+	`
+	rows, err := r.q.QueryContext(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []*domain.WebhookAttempt
+	for rows.Next() {
+		var attempt domain.WebhookAttempt
+		var statusCode sql.NullInt64
+		var errMsg sql.NullString
+
+		if err := rows.Scan(&attempt.DeliveryID, &attempt.Attempt, &statusCode, &errMsg,
+			&attempt.DurationMS, &attempt.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt: %w", err)
+		}
+		attempt.StatusCode = int(statusCode.Int64)
+		attempt.Error = errMsg.String
+		attempts = append(attempts, &attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning webhook attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// API Key Repository Methods
+
+func (r *Repository) SaveAPIKey(ctx context.Context, key *domain.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, name, prefix, hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.q.ExecContext(ctx, query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		joinScopes(key.Scopes),
+		key.CreatedBy,
+		key.CreatedAt.UTC(),
+		nullTime(key.ExpiresAt),
+		nullTime(key.LastUsedAt),
+		nullTime(key.RevokedAt))
+	if err != nil {
+		return fmt.Errorf("failed to save API key: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) GetAPIKey(ctx context.Context, id string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+
+	key, err := scanAPIKey(r.q.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *Repository) GetAPIKeyByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE hash = ?`
+
+	key, err := scanAPIKey(r.q.QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *Repository) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at`
+
+	rows, err := r.q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after scanning API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (r *Repository) UpdateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	query := `UPDATE api_keys SET name = ?, scopes = ?, expires_at = ?, last_used_at = ?, revoked_at = ? WHERE id = ?`
+	result, err := r.q.ExecContext(ctx, query,
+		key.Name,
+		joinScopes(key.Scopes),
+		nullTime(key.ExpiresAt),
+		nullTime(key.LastUsedAt),
+		nullTime(key.RevokedAt),
+		key.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrAPIKeyNotFound, key.ID)
+	}
+
+	return nil
+}
+
+// Audit Repository Methods
+
+func (r *Repository) AppendAudit(ctx context.Context, entry *domain.AuditEntry) (int64, error) {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO audit_log (principal, source_ip, trace_id, method, route, path, subject, payload, changes, status, outcome, error, at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		entry.Principal,
+		entry.SourceIP,
+		entry.TraceID,
+		entry.Method,
+		entry.Route,
+		entry.Path,
+		entry.Subject,
+		entry.Payload,
+		string(changes),
+		entry.Status,
+		string(entry.Outcome),
+		entry.Error,
+		entry.At.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	seq, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get audit sequence: %w", err)
+	}
+	return seq, nil
+}
+
+func (r *Repository) ListAudit(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, int, error) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		conditions = append(conditions, cond)
+		args = append(args, arg)
+	}
+
+	if filter.Principal != "" {
+		add("principal = ?", filter.Principal)
+	}
+	if filter.Subject != "" {
+		add("subject = ?", filter.Subject)
+	}
+	if filter.Route != "" {
+		add("route = ?", filter.Route)
+	}
+	if filter.Method != "" {
+		add("method = ?", filter.Method)
+	}
+	if filter.Outcome != "" {
+		add("outcome = ?", string(filter.Outcome))
+	}
+	if !filter.From.IsZero() {
+		add("at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		add("at < ?", filter.To.UTC())
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.q.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := `SELECT sequence, principal, source_ip, trace_id, method, route, path, subject, payload, changes, status, outcome, error, at FROM audit_log` +
+		where + " ORDER BY sequence DESC" + " LIMIT ? OFFSET ?"
+
+	rows, err := r.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error after scanning audit entries: %w", err)
+	}
+
+	return entries, total, nil
+}
+
+// Idempotency Repository Methods
+
+func (r *Repository) ReserveIdempotencyKey(ctx context.Context, rec *domain.IdempotencyRecord, abandonedBefore time.Time) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (principal, idempotency_key, method, path, request_hash, status, content_type, location, body, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, 0, '', '', NULL, ?, ?)
+		ON CONFLICT (principal, idempotency_key) DO UPDATE SET
+			method = excluded.method,
+			path = excluded.path,
+			request_hash = excluded.request_hash,
+			status = 0,
+			content_type = '',
+			location = '',
+			body = NULL,
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= excluded.created_at
+		OR (idempotency_keys.status = 0 AND idempotency_keys.created_at < ?)
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		rec.Principal,
+		rec.Key,
+		rec.Method,
+		rec.Path,
+		rec.RequestHash,
+		rec.CreatedAt.UTC(),
+		rec.ExpiresAt.UTC(),
+		abandonedBefore.UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+func (r *Repository) GetIdempotencyRecord(ctx context.Context, principal, key string) (*domain.IdempotencyRecord, error) {
+	query := `
+		SELECT principal, idempotency_key, method, path, request_hash, status, content_type, location, body, created_at, expires_at
+		FROM idempotency_keys WHERE principal = ? AND idempotency_key = ?
+	`
+	var rec domain.IdempotencyRecord
+	err := r.q.QueryRowContext(ctx, query, principal, key).Scan(&rec.Principal, &rec.Key, &rec.Method, &rec.Path,
+		&rec.RequestHash, &rec.Status, &rec.ContentType, &rec.Location, &rec.Body, &rec.CreatedAt, &rec.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", domain.ErrIdempotencyKeyNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+	}
+	return &rec, nil
+}
+
+func (r *Repository) CompleteIdempotencyRecord(ctx context.Context, rec *domain.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys SET status = ?, content_type = ?, location = ?, body = ?
+		WHERE principal = ? AND idempotency_key = ? AND request_hash = ?
+	`
+	result, err := r.q.ExecContext(ctx, query,
+		rec.Status,
+		rec.ContentType,
+		rec.Location,
+		rec.Body,
+		rec.Principal,
+		rec.Key,
+		rec.RequestHash)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency record: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: %s", domain.ErrIdempotencyKeyNotFound, rec.Key)
+	}
+
+	return nil
+}
+
+func (r *Repository) ReleaseIdempotencyKey(ctx context.Context, principal, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE principal = ? AND idempotency_key = ? AND status = 0`
+	if _, err := r.q.ExecContext(ctx, query, principal, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) DeleteExpiredIdempotencyRecords(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.q.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency records: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return deleted, nil
+}
+
+const hallCallColumns = `id, floor_number, direction, status, lift_id, reason, eta, created_at, assigned_at, escalated_at, closed_at, updated_at`
+
+const webhookDeliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, last_error, next_attempt_at, created_at, updated_at`
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhook(s scanner) (*domain.Webhook, error) {
+	var hook domain.Webhook
+	var eventTypes string
+
+	if err := s.Scan(&hook.ID, &hook.URL, &eventTypes, &hook.Secret, &hook.Active,
+		&hook.CreatedAt, &hook.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	types, err := splitEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	hook.EventTypes = types
+	return &hook, nil
+}
+
+func scanHallCall(s scanner) (*domain.HallCall, error) {
+	var call domain.HallCall
+	var direction int
+	var status string
+	var eta, assignedAt, escalatedAt, closedAt sql.NullTime
+
+	if err := s.Scan(&call.ID, &call.FloorNumber, &direction, &status, &call.LiftID, &call.Reason,
+		&eta, &call.CreatedAt, &assignedAt, &escalatedAt, &closedAt, &call.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	call.Direction = domain.Direction(direction)
+	call.Status = domain.HallCallStatus(status)
+	call.ETA = timePtr(eta)
+	call.AssignedAt = timePtr(assignedAt)
+	call.EscalatedAt = timePtr(escalatedAt)
+	call.ClosedAt = timePtr(closedAt)
+	return &call, nil
+}
+
+// nullTime stores an optional timestamp, leaving the column NULL when unset
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func scanWebhookDelivery(s scanner) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	var eventType, payload, status string
+	var lastError sql.NullString
+
+	if err := s.Scan(&delivery.ID, &delivery.WebhookID, &delivery.EventID, &eventType, &payload, &status,
+		&delivery.Attempts, &lastError, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	t, err := domain.ParseEventType(eventType)
+	if err != nil {
+		return nil, err
+	}
+	delivery.EventType = t
+	delivery.Payload = []byte(payload)
+	delivery.Status = domain.WebhookDeliveryStatus(status)
+	delivery.LastError = lastError.String
+	return &delivery, nil
+}
+
+// joinEventTypes stores an event type filter as a comma separated list of names
+func joinEventTypes(types []domain.EventType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ",")
+}
+
+func splitEventTypes(s string) ([]domain.EventType, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return domain.ParseEventTypes(strings.Split(s, ","))
+}
+
+const apiKeyColumns = `id, name, prefix, hash, scopes, created_by, created_at, expires_at, last_used_at, revoked_at`
+
+func scanAPIKey(s scanner) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	if err := s.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedBy,
+		&key.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+
+	for _, name := range strings.Split(scopes, ",") {
+		scope, err := domain.ParseAPIKeyScope(name)
+		if err != nil {
+			return nil, err
+		}
+		key.Scopes = append(key.Scopes, scope)
+	}
+	key.ExpiresAt = timePtr(expiresAt)
+	key.LastUsedAt = timePtr(lastUsedAt)
+	key.RevokedAt = timePtr(revokedAt)
+	return &key, nil
+}
+
+func scanAuditEntry(s scanner) (*domain.AuditEntry, error) {
+	var entry domain.AuditEntry
+	var changes, outcome string
+
+	if err := s.Scan(&entry.Sequence, &entry.Principal, &entry.SourceIP, &entry.TraceID, &entry.Method,
+		&entry.Route, &entry.Path, &entry.Subject, &entry.Payload, &changes, &entry.Status, &outcome,
+		&entry.Error, &entry.At); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(changes), &entry.Changes); err != nil {
+		return nil, err
+	}
+	entry.Outcome = domain.AuditOutcome(outcome)
+	return &entry, nil
+}
+
+// joinScopes stores the scopes of an API key as a comma separated list
+func joinScopes(scopes []domain.APIKeyScope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ",")
+}
+
+// Ensure Repository implements ports.TransactionalRepository interface
+var _ ports.TransactionalRepository = (*Repository)(nil)
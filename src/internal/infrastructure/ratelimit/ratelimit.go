@@ -0,0 +1,155 @@
+// Package ratelimit limits how often clients may act, with token buckets kept
+// in memory or, when several API instances share the limits, in Redis.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/auth"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+)
+
+// Backend names where token buckets are kept
+type Backend string
+
+const (
+	// BackendMemory keeps buckets in the API instance, so each instance
+	// limits on its own
+	BackendMemory Backend = "memory"
+	// BackendRedis keeps buckets in Redis, shared by every API instance
+	BackendRedis Backend = "redis"
+	// BackendNone turns rate limiting off
+	BackendNone Backend = "none"
+)
+
+// ParseBackend validates a backend name
+func ParseBackend(s string) (Backend, error) {
+	switch b := Backend(s); b {
+	case BackendMemory, BackendRedis, BackendNone:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown rate limit backend: %s", s)
+	}
+}
+
+// Policy describes a token bucket. A bucket holds up to Burst tokens and is
+// refilled at Rate tokens per second; every request takes one token.
+type Policy struct {
+	Rate  float64
+	Burst int
+}
+
+// Decision is the outcome of taking a token
+type Decision struct {
+	Allowed bool
+	// RetryAfter is how long until a token is available again, when the
+	// request was not allowed
+	RetryAfter time.Duration
+}
+
+// RetryAfterSeconds is RetryAfter rounded up to whole seconds, as sent in a
+// Retry-After header
+func (d Decision) RetryAfterSeconds() int {
+	return int(math.Ceil(d.RetryAfter.Seconds()))
+}
+
+// Store keeps token buckets
+type Store interface {
+	// Take takes a token from the bucket named key, creating a full bucket
+	// when there is none.
+	Take(ctx context.Context, key string, policy Policy) (Decision, error)
+}
+
+// Stats counts the decisions of a limiter
+type Stats struct {
+	Allowed uint64 `json:"allowed"`
+	Limited uint64 `json:"limited"`
+	Errors  uint64 `json:"errors"`
+}
+
+// Limiter applies one policy to buckets kept in a store. Keys are scoped by
+// the limiter's name, so limiters can share a store.
+type Limiter struct {
+	name   string
+	policy Policy
+	store  Store
+	log    *logger.Logger
+
+	allowed atomic.Uint64
+	limited atomic.Uint64
+	errors  atomic.Uint64
+}
+
+// NewLimiter creates a limiter. A limiter without a store allows everything.
+func NewLimiter(name string, policy Policy, store Store, log *logger.Logger) (*Limiter, error) {
+	if policy.Rate <= 0 || policy.Burst < 1 {
+		return nil, fmt.Errorf("invalid %s rate limit: rate must be positive and burst at least 1", name)
+	}
+
+	return &Limiter{
+		name:   name,
+		policy: policy,
+		store:  store,
+		log:    log,
+	}, nil
+}
+
+// Allow takes a token for key. When the store cannot be reached the request
+// is allowed, so an outage of the store does not take the API down with it.
+func (l *Limiter) Allow(ctx context.Context, key string) Decision {
+	if l.store == nil {
+		return Decision{Allowed: true}
+	}
+
+	decision, err := l.store.Take(ctx, l.name+":"+key, l.policy)
+	if err != nil {
+		l.errors.Add(1)
+		l.log.Warn(ctx, "Failed to check rate limit", "limiter", l.name, "key", key, "error", err)
+		return Decision{Allowed: true}
+	}
+
+	if decision.Allowed {
+		l.allowed.Add(1)
+	} else {
+		l.limited.Add(1)
+	}
+	return decision
+}
+
+// Stats returns the decisions made so far
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		Allowed: l.allowed.Load(),
+		Limited: l.limited.Load(),
+		Errors:  l.errors.Load(),
+	}
+}
+
+// refill returns the tokens in a bucket that held tokens elapsed ago
+func refill(tokens float64, elapsed time.Duration, policy Policy) float64 {
+	return math.Min(float64(policy.Burst), tokens+elapsed.Seconds()*policy.Rate)
+}
+
+// wait is how long a bucket holding tokens needs to refill to one token
+func wait(tokens float64, policy Policy) time.Duration {
+	return time.Duration((1 - tokens) / policy.Rate * float64(time.Second))
+}
+
+// ClientKey names the bucket of a client. Callers holding an API key are
+// limited per key, everyone else per IP address.
+func ClientKey(claims auth.Claims, ip string) string {
+	if claims.IsAPIKey() {
+		return claims.Subject
+	}
+	return "ip:" + ip
+}
+
+// FloorButtonKey names the bucket of the button for direction on a floor
+func FloorButtonKey(floor int, direction domain.Direction) string {
+	return fmt.Sprintf("%d:%d", floor, direction)
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix keeps the buckets apart from the rest of the Redis database
+const redisKeyPrefix = "lift-sim:ratelimit:"
+
+// takeScript refills a bucket and takes a token from it. The clock is Redis'
+// own, so API instances with drifting clocks see the same buckets. A bucket
+// expires once it would have refilled, as a full bucket is the same as none.
+//
+// KEYS[1] bucket hash, ARGV[1] rate per second, ARGV[2] burst.
+// Returns {1, 0} when allowed, or {0, milliseconds until a token is free}.
+var takeScript = goredis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + (now - updated) / 1000 * rate)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, wait}
+`)
+
+// RedisStore keeps token buckets in Redis, so every API instance draws from
+// the same buckets
+type RedisStore struct {
+	client *goredis.Client
+}
+
+// NewRedisStore creates a store on client
+func NewRedisStore(client *goredis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Take takes a token from the bucket named key
+func (s *RedisStore) Take(ctx context.Context, key string, policy Policy) (Decision, error) {
+	res, err := takeScript.Run(ctx, s.client, []string{redisKeyPrefix + key}, policy.Rate, policy.Burst).Int64Slice()
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to take token: %w", err)
+	}
+	if len(res) != 2 {
+		return Decision{}, fmt.Errorf("unexpected rate limit reply: %v", res)
+	}
+
+	if res[0] == 1 {
+		return Decision{Allowed: true}, nil
+	}
+	return Decision{RetryAfter: time.Duration(res[1]) * time.Millisecond}, nil
+}
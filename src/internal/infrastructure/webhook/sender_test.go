@@ -0,0 +1,223 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Avyukth/lift-simulation/internal/application/events"
+	"github.com/Avyukth/lift-simulation/internal/application/services"
+	"github.com/Avyukth/lift-simulation/internal/domain"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/persistence/sqlite"
+	"github.com/Avyukth/lift-simulation/internal/infrastructure/webhook"
+	"github.com/Avyukth/lift-simulation/pkg/logger"
+)
+
+// request is what the test endpoint saw of one delivery attempt
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// endpoint records the requests it receives and answers each with the next
+// status code of its script, repeating the last one once the script runs out
+type endpoint struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	e.mu.Lock()
+	e.requests = append(e.requests, request{header: r.Header.Clone(), body: body})
+	status := e.statuses[min(len(e.requests), len(e.statuses))-1]
+	e.mu.Unlock()
+
+	w.WriteHeader(status)
+	io.WriteString(w, http.StatusText(status))
+}
+
+func (e *endpoint) received() []request {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]request(nil), e.requests...)
+}
+
+func TestSendSignsRequest(t *testing.T) {
+	ep := &endpoint{statuses: []int{http.StatusNoContent}}
+	srv := httptest.NewServer(ep)
+	defer srv.Close()
+
+	hook := &domain.Webhook{ID: "hook-1", URL: srv.URL, Secret: "s3cret"}
+	delivery := &domain.WebhookDelivery{
+		ID:        "delivery-1",
+		EventType: domain.LiftArrived,
+		Payload:   []byte(`{"type":"LiftArrived"}`),
+	}
+
+	status, err := webhook.NewHTTPSender(time.Second).Send(context.Background(), hook, delivery)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("got status %d and error %v, want %d", status, err, http.StatusNoContent)
+	}
+
+	reqs := ep.received()
+	if len(reqs) != 1 {
+		t.Fatalf("endpoint received %d requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+	for header, want := range map[string]string{
+		webhook.HeaderID:       "hook-1",
+		webhook.HeaderDelivery: "delivery-1",
+		webhook.HeaderEvent:    "LiftArrived",
+		"Content-Type":         "application/json",
+	} {
+		if got := req.header.Get(header); got != want {
+			t.Errorf("%s header is %q, want %q", header, got, want)
+		}
+	}
+	if string(req.body) != string(delivery.Payload) {
+		t.Errorf("body is %s, want %s", req.body, delivery.Payload)
+	}
+
+	timestamp, signature := req.header.Get(webhook.HeaderTimestamp), req.header.Get(webhook.HeaderSignature)
+	if !strings.HasPrefix(signature, "sha256=") {
+		t.Errorf("signature %q lacks the sha256= prefix", signature)
+	}
+	if !webhook.Verify("s3cret", timestamp, req.body, signature) {
+		t.Errorf("signature %q does not verify for timestamp %q", signature, timestamp)
+	}
+	if webhook.Verify("other", timestamp, req.body, signature) {
+		t.Error("signature verifies with the wrong secret")
+	}
+}
+
+func TestSendReportsServerErrors(t *testing.T) {
+	srv := httptest.NewServer(&endpoint{statuses: []int{http.StatusServiceUnavailable}})
+	defer srv.Close()
+
+	hook := &domain.Webhook{ID: "hook-1", URL: srv.URL, Secret: "s3cret"}
+	delivery := &domain.WebhookDelivery{ID: "delivery-1", EventType: domain.LiftArrived, Payload: []byte(`{}`)}
+
+	status, err := webhook.NewHTTPSender(time.Second).Send(context.Background(), hook, delivery)
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", status, http.StatusServiceUnavailable)
+	}
+	if err == nil || !strings.Contains(err.Error(), "Service Unavailable") {
+		t.Errorf("got error %v, want one carrying the response", err)
+	}
+}
+
+func TestDeliveryRetriedAfterServerErrors(t *testing.T) {
+	ep := &endpoint{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}}
+	delivery, attempts := deliver(t, ep, 5)
+
+	if delivery.Status != domain.DeliveryDelivered {
+		t.Errorf("delivery is %s, want %s", delivery.Status, domain.DeliveryDelivered)
+	}
+	assertAttempts(t, attempts, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+
+	// Every retry is signed afresh with the same payload.
+	for i, req := range ep.received() {
+		if !webhook.Verify("s3cret", req.header.Get(webhook.HeaderTimestamp), req.body, req.header.Get(webhook.HeaderSignature)) {
+			t.Errorf("attempt %d is not signed", i+1)
+		}
+	}
+}
+
+func TestDeliveryDeadLetteredAfterMaxAttempts(t *testing.T) {
+	ep := &endpoint{statuses: []int{http.StatusServiceUnavailable}}
+	delivery, attempts := deliver(t, ep, 3)
+
+	if delivery.Status != domain.DeliveryDead {
+		t.Errorf("delivery is %s, want %s", delivery.Status, domain.DeliveryDead)
+	}
+	assertAttempts(t, attempts, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	if n := len(ep.received()); n != 3 {
+		t.Errorf("endpoint received %d requests, want 3", n)
+	}
+}
+
+// deliver subscribes ep to LiftArrived events, publishes one and waits for
+// the webhook service to finish with its delivery
+func deliver(t *testing.T, ep *endpoint, maxAttempts int) (*domain.WebhookDelivery, []*domain.WebhookAttempt) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	srv := httptest.NewServer(ep)
+	defer srv.Close()
+
+	log := logger.New(io.Discard, logger.LevelError, "TEST", nil)
+	repo, err := sqlite.NewRepository(filepath.Join(t.TempDir(), "lift.sqlite"), log)
+	if err != nil {
+		t.Fatalf("opening sqlite: %v", err)
+	}
+
+	bus := events.NewInMemoryEventBus()
+	svc := services.NewWebhookService(repo, webhook.NewHTTPSender(time.Second), bus, services.WebhookConfig{
+		MaxAttempts:    maxAttempts,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+		PollInterval:   10 * time.Millisecond,
+	}, log)
+
+	hook, err := svc.CreateWebhook(ctx, services.WebhookInput{URL: srv.URL, EventTypes: []string{"LiftArrived"}, Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("creating webhook: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	bus.Publish(ctx, domain.LiftArrivedEvent{LiftID: "lift-1", FloorNumber: 3})
+
+	for {
+		deliveries, err := svc.ListDeliveries(ctx, domain.WebhookDeliveryFilter{WebhookID: hook.ID, Limit: 10})
+		if err != nil {
+			t.Fatalf("listing deliveries: %v", err)
+		}
+		if len(deliveries) == 1 && deliveries[0].Status != domain.DeliveryPending {
+			delivery, attempts, err := svc.GetDelivery(ctx, deliveries[0].ID)
+			if err != nil {
+				t.Fatalf("getting delivery: %v", err)
+			}
+			return delivery, attempts
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("delivery not finished after %d requests", len(ep.received()))
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func assertAttempts(t *testing.T, attempts []*domain.WebhookAttempt, statuses ...int) {
+	t.Helper()
+	if len(attempts) != len(statuses) {
+		t.Fatalf("got %d attempts, want %d", len(attempts), len(statuses))
+	}
+	for i, a := range attempts {
+		if a.Attempt != i+1 || a.StatusCode != statuses[i] {
+			t.Errorf("attempt %d: got number %d and status %d, want status %d", i+1, a.Attempt, a.StatusCode, statuses[i])
+		}
+		if failed := statuses[i] >= 500; failed != (a.Error != "") {
+			t.Errorf("attempt %d: status %d recorded with error %q", i+1, a.StatusCode, a.Error)
+		}
+	}
+}
@@ -16,7 +16,7 @@ func NewFiberLogger(logger *Logger) *FiberLogger {
 
 // LogWithFiberContext logs a message with the given level and Fiber context
 func (fl *FiberLogger) LogWithFiberContext(c *fiber.Ctx, level Level, msg string, args ...any) {
-	ctx := c.Context()
+	ctx := c.UserContext()
 
 	// Add Fiber-specific information to the log
 	args = append(args,
@@ -64,10 +64,19 @@ func setStatusCode(ctx context.Context, statusCode int) {
 	v.StatusCode = statusCode
 }
 
-func setValues(ctx context.Context, v *Values) context.Context {
+// SetValues stores the request values in the context.
+func SetValues(ctx context.Context, v *Values) context.Context {
 	return context.WithValue(ctx, key, v)
 }
 
+// WithTraceID returns a context carrying fresh values for the given trace id.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return SetValues(ctx, &Values{
+		TraceID: traceID,
+		Now:     time.Now(),
+	})
+}
+
 func SetLogger(ctx context.Context, log *logger.Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, log)
 }